@@ -36,3 +36,20 @@ func TestRunFailureExitCodeAndError(t *testing.T) {
 		t.Fatalf("expected service error in stderr, got %q", got)
 	}
 }
+
+func TestRunEncryptedReportsReason(t *testing.T) {
+	t.Parallel()
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+
+	code := run([]string{"show", "--file", "../../testdata/pdf/encrypted-aes-256.pdf", "--password", "wrong", "--json"}, stdout, stderr)
+	if code != 6 {
+		t.Fatalf("run(show) code=%d want=6", code)
+	}
+	if got := stdout.String(); !strings.Contains(got, `"reason": "incorrect_password"`) || !strings.Contains(got, `"code": "pdf_encrypted"`) {
+		t.Fatalf("expected structured error on stdout, got %q", got)
+	}
+	if got := stderr.String(); !strings.Contains(got, "incorrect password") {
+		t.Fatalf("expected error in stderr, got %q", got)
+	}
+}
@@ -24,6 +24,18 @@ func (h *Handlers) Show(ctx context.Context, req model.ShowRequest) (model.ShowR
 	return h.svc.Show(ctx, req)
 }
 
+func (h *Handlers) History(ctx context.Context, req model.HistoryRequest) (model.HistoryResult, error) {
+	return h.svc.History(ctx, req)
+}
+
+func (h *Handlers) Revert(ctx context.Context, req model.RevertRequest) (model.RevertResult, error) {
+	return h.svc.Revert(ctx, req)
+}
+
+func (h *Handlers) Repair(ctx context.Context, req model.RepairRequest) (model.ShowResult, error) {
+	return h.svc.Repair(ctx, req)
+}
+
 func (h *Handlers) Set(ctx context.Context, req model.SetRequest) (model.ShowResult, error) {
 	return h.svc.Set(ctx, req)
 }
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"time"
 
@@ -44,7 +45,7 @@ func NewService(cfg ServiceConfig) *Service {
 }
 
 func (s *Service) Show(ctx context.Context, req model.ShowRequest) (model.ShowResult, error) {
-	rr, err := s.metadata.Read(ctx, req.InputPath)
+	rr, err := s.metadata.Read(ctx, model.MetadataReadRequest{InputPath: req.InputPath, Password: req.Password})
 	if err != nil {
 		return model.ShowResult{}, err
 	}
@@ -55,10 +56,79 @@ func (s *Service) Show(ctx context.Context, req model.ShowRequest) (model.ShowRe
 	return model.ShowResult{
 		InputPath:  req.InputPath,
 		Encrypted:  rr.Encrypted,
+		Linearized: rr.Linearized,
 		Metadata:   meta,
 		InfoFound:  rr.InfoFound,
 		XMPFound:   rr.XMPFound,
 		Normalized: rr.Normalized || normalized,
+		Warnings:   rr.Warnings,
+		Repair:     rr.Repair,
+		Structure:  rr.Structure,
+		Encryption: rr.Encryption,
+	}, nil
+}
+
+func (s *Service) History(ctx context.Context, req model.HistoryRequest) (model.HistoryResult, error) {
+	revs, err := s.metadata.History(ctx, req.InputPath)
+	if err != nil {
+		return model.HistoryResult{}, err
+	}
+	for i := range revs {
+		meta, _, err := normalizeMetadata(revs[i].Metadata, false)
+		if err != nil {
+			return model.HistoryResult{}, err
+		}
+		revs[i].Metadata = meta
+		if i > 0 {
+			revs[i].Changes = diffMetadata(revs[i-1].Metadata, meta)
+		}
+	}
+	return model.HistoryResult{
+		InputPath: req.InputPath,
+		Revisions: revs,
+	}, nil
+}
+
+func (s *Service) Revert(ctx context.Context, req model.RevertRequest) (model.RevertResult, error) {
+	res, err := s.metadata.Revert(ctx, model.MetadataRevertRequest{
+		InputPath:  req.IO.InputPath,
+		OutputPath: req.IO.OutputPath,
+		InPlace:    req.IO.InPlace,
+		To:         req.To,
+		Steps:      req.Steps,
+	})
+	if err != nil {
+		return model.RevertResult{}, err
+	}
+	meta, _, err := normalizeMetadata(res.Revision.Metadata, req.Exec.Strict)
+	if err != nil {
+		return model.RevertResult{}, err
+	}
+	res.Revision.Metadata = meta
+	res.InputPath = effectiveOutputPath(req.IO)
+	return res, nil
+}
+
+func (s *Service) Repair(ctx context.Context, req model.RepairRequest) (model.ShowResult, error) {
+	rr, err := s.metadata.Repair(ctx, model.MetadataRepairRequest{
+		InputPath:  req.IO.InputPath,
+		OutputPath: req.IO.OutputPath,
+		InPlace:    req.IO.InPlace,
+	})
+	if err != nil {
+		return model.ShowResult{}, err
+	}
+	meta, normalized, err := normalizeMetadata(rr.Metadata, req.Exec.Strict)
+	if err != nil {
+		return model.ShowResult{}, err
+	}
+	return model.ShowResult{
+		InputPath:  effectiveOutputPath(req.IO),
+		Metadata:   meta,
+		InfoFound:  rr.InfoFound,
+		XMPFound:   rr.XMPFound,
+		Normalized: normalized,
+		Repair:     rr.Repair,
 	}, nil
 }
 
@@ -72,6 +142,7 @@ func (s *Service) Set(ctx context.Context, req model.SetRequest) (model.ShowResu
 		OutputPath: req.IO.OutputPath,
 		InPlace:    req.IO.InPlace,
 		Strict:     req.Exec.Strict,
+		Options:    req.Write,
 		Set:        patch,
 	})
 	if err != nil {
@@ -84,10 +155,14 @@ func (s *Service) Set(ctx context.Context, req model.SetRequest) (model.ShowResu
 	return model.ShowResult{
 		InputPath:  effectiveOutputPath(req.IO),
 		Encrypted:  rr.Encrypted,
+		Linearized: rr.Linearized,
 		Metadata:   meta,
 		InfoFound:  rr.InfoFound,
 		XMPFound:   rr.XMPFound,
 		Normalized: rr.Normalized || normalized,
+		Warnings:   rr.Warnings,
+		Repair:     rr.Repair,
+		Encryption: rr.Encryption,
 	}, nil
 }
 
@@ -101,6 +176,7 @@ func (s *Service) Unset(ctx context.Context, req model.UnsetRequest) (model.Show
 		OutputPath: req.IO.OutputPath,
 		InPlace:    req.IO.InPlace,
 		Strict:     req.Exec.Strict,
+		Options:    req.Write,
 		Unset:      fields,
 		UnsetAll:   req.All,
 	})
@@ -114,10 +190,14 @@ func (s *Service) Unset(ctx context.Context, req model.UnsetRequest) (model.Show
 	return model.ShowResult{
 		InputPath:  effectiveOutputPath(req.IO),
 		Encrypted:  rr.Encrypted,
+		Linearized: rr.Linearized,
 		Metadata:   meta,
 		InfoFound:  rr.InfoFound,
 		XMPFound:   rr.XMPFound,
 		Normalized: rr.Normalized || normalized,
+		Warnings:   rr.Warnings,
+		Repair:     rr.Repair,
+		Encryption: rr.Encryption,
 	}, nil
 }
 
@@ -146,6 +226,7 @@ func (s *Service) TemplateApply(ctx context.Context, req model.TemplateApplyRequ
 	return s.Set(ctx, model.SetRequest{
 		IO:      req.IO,
 		Exec:    req.Exec,
+		Write:   req.Write,
 		Changes: record.Metadata,
 	})
 }
@@ -172,6 +253,18 @@ func effectiveOutputPath(io model.IOOptions) string {
 	return io.InputPath
 }
 
+// diffMetadata lists the fields whose values differ, in model.AllFields order.
+func diffMetadata(prev, next model.Metadata) []model.FieldChange {
+	var changes []model.FieldChange
+	for _, f := range model.AllFields {
+		from, to := prev.Value(f), next.Value(f)
+		if from != to {
+			changes = append(changes, model.FieldChange{Field: f, From: from, To: to})
+		}
+	}
+	return changes
+}
+
 func normalizePatch(patch model.MetadataPatch, strict bool) (model.MetadataPatch, error) {
 	var changed bool
 	fix := func(v **string) {
@@ -187,14 +280,31 @@ func normalizePatch(patch model.MetadataPatch, strict bool) (model.MetadataPatch
 	}
 	for _, field := range []**string{
 		&patch.Title,
-		&patch.Author,
 		&patch.Subject,
-		&patch.Keywords,
 		&patch.Creator,
 		&patch.Producer,
 	} {
 		fix(field)
 	}
+	// List items are kept as given, so an author may contain a semicolon;
+	// legacy single strings were already split where they were read.
+	fixList := func(l *model.List) {
+		if *l == nil {
+			return
+		}
+		out := model.List{}
+		for _, v := range *l {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		if !slices.Equal(out, *l) {
+			changed = true
+		}
+		*l = out
+	}
+	fixList(&patch.Authors)
+	fixList(&patch.Keywords)
 	var err error
 	patch.CreationDate, changed, err = normalizeDatePtr(patch.CreationDate, strict, changed)
 	if err != nil {
@@ -217,10 +327,22 @@ func normalizeMetadata(meta model.Metadata, strict bool) (model.Metadata, bool,
 			changed = true
 		}
 	}
+	normalizeList := func(l *[]string) {
+		var out []string
+		for _, v := range *l {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+		if !slices.Equal(out, *l) {
+			changed = true
+		}
+		*l = out
+	}
 	normalize(&meta.Title)
-	normalize(&meta.Author)
+	normalizeList(&meta.Authors)
 	normalize(&meta.Subject)
-	normalize(&meta.Keywords)
+	normalizeList(&meta.Keywords)
 	normalize(&meta.Creator)
 	normalize(&meta.Producer)
 
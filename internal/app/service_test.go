@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"testing"
 
@@ -53,8 +54,8 @@ func TestSetAndShowRoundTrip(t *testing.T) {
 			OutputPath: out,
 		},
 		Changes: model.MetadataPatch{
-			Title:  &title,
-			Author: &author,
+			Title:   &title,
+			Authors: model.List{author},
 		},
 	})
 	if err != nil {
@@ -65,7 +66,7 @@ func TestSetAndShowRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Show: %v", err)
 	}
-	if got.Metadata.Title != title || got.Metadata.Author != author {
+	if got.Metadata.Title != title || got.Metadata.Value(model.FieldAuthor) != author {
 		t.Fatalf("unexpected metadata: %+v", got.Metadata)
 	}
 	if !got.InfoFound {
@@ -76,6 +77,37 @@ func TestSetAndShowRoundTrip(t *testing.T) {
 	}
 }
 
+func TestSetKeepsListItems(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	in := copyFixture(t, "minimal.pdf")
+	out := filepath.Join(t.TempDir(), "out.pdf")
+
+	// Items are trimmed but not split, so they may contain the separators.
+	_, err := svc.Set(context.Background(), model.SetRequest{
+		IO: model.IOOptions{InputPath: in, OutputPath: out},
+		Changes: model.MetadataPatch{
+			Authors:  model.List{"Smith; Jones & Co.", " Poe ", ""},
+			Keywords: model.List{"pdf, metadata", "xmp"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+
+	got, err := svc.Show(context.Background(), model.ShowRequest{InputPath: out})
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if want := []string{"Smith; Jones & Co.", "Poe"}; !reflect.DeepEqual(got.Metadata.Authors, want) {
+		t.Fatalf("Authors=%q want %q", got.Metadata.Authors, want)
+	}
+	if want := []string{"pdf, metadata", "xmp"}; !reflect.DeepEqual(got.Metadata.Keywords, want) {
+		t.Fatalf("Keywords=%q want %q", got.Metadata.Keywords, want)
+	}
+}
+
 func TestTemplateSaveAndApply(t *testing.T) {
 	t.Parallel()
 
@@ -152,3 +184,92 @@ func TestBatchExecute(t *testing.T) {
 		t.Fatalf("unexpected batch result: %+v", result)
 	}
 }
+
+func TestHistoryDiffsConsecutiveRevisions(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	res, err := svc.History(context.Background(), model.HistoryRequest{InputPath: fixturePath("incremental-updates.pdf")})
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(res.Revisions) != 3 {
+		t.Fatalf("expected 3 revisions, got %d", len(res.Revisions))
+	}
+	if len(res.Revisions[0].Changes) != 0 {
+		t.Fatalf("first revision should have no changes: %+v", res.Revisions[0].Changes)
+	}
+	want := []model.FieldChange{
+		{Field: model.FieldTitle, From: "Revision 2", To: "Revision 3"},
+		{Field: model.FieldAuthor, From: "Original Author", To: "Second Author"},
+	}
+	if got := res.Revisions[2].Changes; !reflect.DeepEqual(got, want) {
+		t.Fatalf("unexpected changes: %+v", got)
+	}
+}
+
+func TestRevertRestoresEarlierRevision(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	in := copyFixture(t, "incremental-updates.pdf")
+	out := filepath.Join(t.TempDir(), "reverted.pdf")
+
+	res, err := svc.Revert(context.Background(), model.RevertRequest{
+		IO: model.IOOptions{InputPath: in, OutputPath: out},
+		To: 2,
+	})
+	if err != nil {
+		t.Fatalf("Revert: %v", err)
+	}
+	if res.InputPath != out || res.Revision.Index != 2 || res.Dropped != 1 {
+		t.Fatalf("unexpected revert result: %+v", res)
+	}
+
+	got, err := svc.Show(context.Background(), model.ShowRequest{InputPath: out})
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	if got.Metadata.Title != "Revision 2" {
+		t.Fatalf("expected title=%q, got %q", "Revision 2", got.Metadata.Title)
+	}
+}
+
+func TestRepairReportsReconstruction(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	out := filepath.Join(t.TempDir(), "repaired.pdf")
+
+	res, err := svc.Repair(context.Background(), model.RepairRequest{
+		IO: model.IOOptions{InputPath: fixturePath("malformed-trailer.pdf"), OutputPath: out},
+	})
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if res.InputPath != out || res.Repair == nil || res.Repair.Root != "1 0 R" {
+		t.Fatalf("unexpected repair result: %+v", res)
+	}
+
+	title := "After Repair"
+	if _, err := svc.Set(context.Background(), model.SetRequest{
+		IO:      model.IOOptions{InputPath: out, InPlace: true},
+		Changes: model.MetadataPatch{Title: &title},
+	}); err != nil {
+		t.Fatalf("Set on repaired file: %v", err)
+	}
+}
+
+func TestShowReportsStructure(t *testing.T) {
+	t.Parallel()
+
+	svc := newTestService(t)
+	res, err := svc.Show(context.Background(), model.ShowRequest{InputPath: fixturePath("linearized.pdf")})
+	if err != nil {
+		t.Fatalf("Show: %v", err)
+	}
+	st := res.Structure
+	if st == nil || st.HeaderVersion != "1.4" || st.Pages != 2 || st.Revisions != 1 || st.FileSize == 0 || !res.Linearized {
+		t.Fatalf("unexpected structure: %+v (linearized=%t)", st, res.Linearized)
+	}
+}
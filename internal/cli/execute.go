@@ -3,15 +3,24 @@ package cli
 import (
 	"fmt"
 	"io"
+
+	"pdfmeta/internal/output"
 )
 
-// Execute runs the CLI with the provided args and IO streams.
+// Execute runs the CLI with the provided args and IO streams. When the
+// failing command was asked for JSON, the error is also written to stdout as
+// a JSON document with its code and reason.
 func Execute(args []string, stdout io.Writer, stderr io.Writer) error {
 	root := NewRootCmd()
 	root.SetArgs(args)
 	root.SetOut(stdout)
 	root.SetErr(stderr)
-	if err := root.Execute(); err != nil {
+	if cmd, err := root.ExecuteC(); err != nil {
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			_ = writeRendered(cmd, true, func(formatter output.Formatter) ([]byte, error) {
+				return formatter.Err(err)
+			})
+		}
 		return fmt.Errorf("pdfmeta: %w", err)
 	}
 	return nil
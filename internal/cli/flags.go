@@ -1,6 +1,24 @@
 package cli
 
-import "errors"
+import (
+	"errors"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"pdfmeta/internal/model"
+)
+
+// passwordEnv supplies the password for encrypted PDFs when --password is
+// not given.
+const passwordEnv = "PDFMETA_PASSWORD"
+
+func resolvePassword(flag string) string {
+	if flag != "" {
+		return flag
+	}
+	return os.Getenv(passwordEnv)
+}
 
 func validateOutputMode(out string, inPlace bool) error {
 	if out != "" && inPlace {
@@ -11,3 +29,46 @@ func validateOutputMode(out string, inPlace bool) error {
 	}
 	return nil
 }
+
+// listFlag turns a repeatable list flag into a patch list. Repeated values
+// are kept as given; a lone value may list several entries, as before the
+// flag became repeatable.
+func listFlag(values []string, split func(string) []string) model.List {
+	if len(values) == 1 {
+		return model.SplitList(values[0], split)
+	}
+	return model.List(values)
+}
+
+// writeFlags are the write options shared by set, unset and template apply.
+type writeFlags struct {
+	objectStreams bool
+	rewrite       bool
+	linearized    string
+	repair        bool
+	force         bool
+	pdfa          bool
+	password      string
+}
+
+func addWriteFlags(cmd *cobra.Command, f *writeFlags) {
+	cmd.Flags().BoolVar(&f.objectStreams, "object-streams", false, "Pack new objects into a compressed object stream (emits an xref stream)")
+	cmd.Flags().BoolVar(&f.rewrite, "rewrite", false, "Rewrite the whole file with only live objects, dropping incremental history")
+	cmd.Flags().StringVar(&f.linearized, "linearized", "", "Policy for linearized PDFs: warn, refuse or relinearize (default warn)")
+	cmd.Flags().BoolVar(&f.repair, "repair", false, "Rebuild a damaged cross-reference table by scanning objects before writing (implies --rewrite)")
+	cmd.Flags().BoolVar(&f.force, "force", false, "Write certified PDFs; no DocMDP level permits metadata changes")
+	cmd.Flags().BoolVar(&f.pdfa, "pdfa", false, "Refuse writes that would break the PDF/A conformance the input declares")
+	cmd.Flags().StringVar(&f.password, "password", "", "Password for an encrypted PDF (default $"+passwordEnv+")")
+}
+
+func (f writeFlags) options() model.WriteOptions {
+	return model.WriteOptions{
+		ObjectStreams: f.objectStreams,
+		Rewrite:       f.rewrite,
+		Linearized:    model.LinearizedPolicy(f.linearized),
+		Repair:        f.repair,
+		Force:         f.force,
+		PDFA:          f.pdfa,
+		Password:      resolvePassword(f.password),
+	}
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"pdfmeta/internal/app"
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/output"
+	"pdfmeta/internal/validate"
+)
+
+type historyFlags struct {
+	file   string
+	asJSON bool
+}
+
+func newHistoryCmd(handlers *app.Handlers) *cobra.Command {
+	f := &historyFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List incremental revisions and the metadata at each",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := model.HistoryRequest{
+				InputPath: f.file,
+				JSON:      f.asJSON,
+			}
+			if err := validate.HistoryRequest(req); err != nil {
+				return err
+			}
+			result, err := handlers.History(context.Background(), req)
+			if err != nil {
+				return err
+			}
+			return writeRendered(cmd, f.asJSON, func(formatter output.Formatter) ([]byte, error) {
+				return formatter.History(result)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&f.file, "file", "", "Input PDF file")
+	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Output JSON")
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"pdfmeta/internal/app"
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/output"
+	"pdfmeta/internal/validate"
+)
+
+type repairFlags struct {
+	file    string
+	out     string
+	inPlace bool
+	asJSON  bool
+}
+
+func newRepairCmd(handlers *app.Handlers) *cobra.Command {
+	f := &repairFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "repair",
+		Short: "Rebuild a damaged cross-reference table by scanning objects",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := model.RepairRequest{
+				IO: model.IOOptions{
+					InputPath:  f.file,
+					OutputPath: f.out,
+					InPlace:    f.inPlace,
+				},
+				Exec: model.ExecOptions{
+					JSON: f.asJSON,
+				},
+			}
+			if err := validate.RepairRequest(req); err != nil {
+				return err
+			}
+			result, err := handlers.Repair(context.Background(), req)
+			if err != nil {
+				return err
+			}
+			return writeRendered(cmd, f.asJSON, func(formatter output.Formatter) ([]byte, error) {
+				return formatter.Show(result)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&f.file, "file", "", "Input PDF file")
+	cmd.Flags().StringVar(&f.out, "out", "", "Output PDF file")
+	cmd.Flags().BoolVar(&f.inPlace, "in-place", false, "Modify file in place using safe atomic replace")
+	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Emit result JSON")
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
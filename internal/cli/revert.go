@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"pdfmeta/internal/app"
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/output"
+	"pdfmeta/internal/validate"
+)
+
+type revertFlags struct {
+	file    string
+	out     string
+	inPlace bool
+	asJSON  bool
+	to      int
+	steps   int
+}
+
+func newRevertCmd(handlers *app.Handlers) *cobra.Command {
+	f := &revertFlags{}
+
+	cmd := &cobra.Command{
+		Use:   "revert",
+		Short: "Roll a PDF back to an earlier incremental revision",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := model.RevertRequest{
+				IO: model.IOOptions{
+					InputPath:  f.file,
+					OutputPath: f.out,
+					InPlace:    f.inPlace,
+				},
+				Exec: model.ExecOptions{
+					JSON: f.asJSON,
+				},
+				To:    f.to,
+				Steps: f.steps,
+			}
+			if err := validate.RevertRequest(req); err != nil {
+				return err
+			}
+			result, err := handlers.Revert(context.Background(), req)
+			if err != nil {
+				return err
+			}
+			return writeRendered(cmd, f.asJSON, func(formatter output.Formatter) ([]byte, error) {
+				return formatter.Revert(result)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&f.file, "file", "", "Input PDF file")
+	cmd.Flags().StringVar(&f.out, "out", "", "Output PDF file")
+	cmd.Flags().BoolVar(&f.inPlace, "in-place", false, "Modify file in place using safe atomic replace")
+	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Emit result JSON")
+	cmd.Flags().IntVar(&f.to, "to", 0, "Revision number to keep (1 is the original file; see history)")
+	cmd.Flags().IntVar(&f.steps, "steps", 0, "Number of newest revisions to drop")
+	_ = cmd.MarkFlagRequired("file")
+
+	return cmd
+}
@@ -24,6 +24,9 @@ func NewRootCmdWithDependencies(deps Dependencies) *cobra.Command {
 	}
 
 	cmd.AddCommand(newShowCmd(handlers))
+	cmd.AddCommand(newHistoryCmd(handlers))
+	cmd.AddCommand(newRevertCmd(handlers))
+	cmd.AddCommand(newRepairCmd(handlers))
 	cmd.AddCommand(newSetCmd(handlers))
 	cmd.AddCommand(newUnsetCmd(handlers))
 	cmd.AddCommand(newBatchCmd(handlers))
@@ -17,10 +17,11 @@ type setFlags struct {
 	inPlace    bool
 	strict     bool
 	asJSON     bool
+	write      writeFlags
 	title      string
-	author     string
+	authors    []string
 	subject    string
-	keywords   string
+	keywords   []string
 	creator    string
 	producer   string
 	createdAt  string
@@ -43,6 +44,7 @@ func newSetCmd(handlers *app.Handlers) *cobra.Command {
 					Strict: f.strict,
 					JSON:   f.asJSON,
 				},
+				Write:   f.write.options(),
 				Changes: patchFromSetFlags(cmd, f),
 			}
 			if err := validate.SetRequest(req); err != nil {
@@ -63,11 +65,12 @@ func newSetCmd(handlers *app.Handlers) *cobra.Command {
 	cmd.Flags().BoolVar(&f.inPlace, "in-place", false, "Modify file in place using safe atomic replace")
 	cmd.Flags().BoolVar(&f.strict, "strict", false, "Reject invalid metadata instead of auto-correcting")
 	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Emit result JSON")
+	addWriteFlags(cmd, &f.write)
 
 	cmd.Flags().StringVar(&f.title, "title", "", "Title")
-	cmd.Flags().StringVar(&f.author, "author", "", "Author")
+	cmd.Flags().StringArrayVar(&f.authors, "author", nil, "Author (repeat for several authors, in order; a single value may separate them by semicolons)")
 	cmd.Flags().StringVar(&f.subject, "subject", "", "Subject")
-	cmd.Flags().StringVar(&f.keywords, "keywords", "", "Keywords")
+	cmd.Flags().StringArrayVar(&f.keywords, "keywords", nil, "Keyword (repeat for several keywords; a single value may separate them by commas)")
 	cmd.Flags().StringVar(&f.creator, "creator", "", "Creator")
 	cmd.Flags().StringVar(&f.producer, "producer", "", "Producer")
 	cmd.Flags().StringVar(&f.createdAt, "creation-date", "", "Creation date")
@@ -83,13 +86,13 @@ func patchFromSetFlags(cmd *cobra.Command, f *setFlags) model.MetadataPatch {
 		patch.Title = &f.title
 	}
 	if cmd.Flags().Changed("author") {
-		patch.Author = &f.author
+		patch.Authors = listFlag(f.authors, model.SplitAuthors)
 	}
 	if cmd.Flags().Changed("subject") {
 		patch.Subject = &f.subject
 	}
 	if cmd.Flags().Changed("keywords") {
-		patch.Keywords = &f.keywords
+		patch.Keywords = listFlag(f.keywords, model.SplitKeywords)
 	}
 	if cmd.Flags().Changed("creator") {
 		patch.Creator = &f.creator
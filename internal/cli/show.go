@@ -12,8 +12,9 @@ import (
 )
 
 type showFlags struct {
-	file   string
-	asJSON bool
+	file     string
+	asJSON   bool
+	password string
 }
 
 func newShowCmd(handlers *app.Handlers) *cobra.Command {
@@ -26,6 +27,7 @@ func newShowCmd(handlers *app.Handlers) *cobra.Command {
 			req := model.ShowRequest{
 				InputPath: f.file,
 				JSON:      f.asJSON,
+				Password:  resolvePassword(f.password),
 			}
 			if err := validate.ShowRequest(req); err != nil {
 				return err
@@ -42,6 +44,7 @@ func newShowCmd(handlers *app.Handlers) *cobra.Command {
 
 	cmd.Flags().StringVar(&f.file, "file", "", "Input PDF file")
 	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Output JSON")
+	cmd.Flags().StringVar(&f.password, "password", "", "Password for an encrypted PDF (default $"+passwordEnv+")")
 	_ = cmd.MarkFlagRequired("file")
 
 	return cmd
@@ -16,9 +16,9 @@ type templateSaveFlags struct {
 	note       string
 	force      bool
 	title      string
-	author     string
+	authors    []string
 	subject    string
-	keywords   string
+	keywords   []string
 	creator    string
 	producer   string
 	createdAt  string
@@ -32,6 +32,7 @@ type templateApplyFlags struct {
 	inPlace bool
 	strict  bool
 	asJSON  bool
+	write   writeFlags
 }
 
 type templateListFlags struct {
@@ -91,9 +92,9 @@ func newTemplateSaveCmd(handlers *app.Handlers) *cobra.Command {
 	cmd.Flags().StringVar(&f.note, "note", "", "Template description")
 	cmd.Flags().BoolVar(&f.force, "force", false, "Overwrite existing template")
 	cmd.Flags().StringVar(&f.title, "title", "", "Title")
-	cmd.Flags().StringVar(&f.author, "author", "", "Author")
+	cmd.Flags().StringArrayVar(&f.authors, "author", nil, "Author (repeat for several authors, in order; a single value may separate them by semicolons)")
 	cmd.Flags().StringVar(&f.subject, "subject", "", "Subject")
-	cmd.Flags().StringVar(&f.keywords, "keywords", "", "Keywords")
+	cmd.Flags().StringArrayVar(&f.keywords, "keywords", nil, "Keyword (repeat for several keywords; a single value may separate them by commas)")
 	cmd.Flags().StringVar(&f.creator, "creator", "", "Creator")
 	cmd.Flags().StringVar(&f.producer, "producer", "", "Producer")
 	cmd.Flags().StringVar(&f.createdAt, "creation-date", "", "Creation date")
@@ -109,13 +110,13 @@ func patchFromTemplateSaveFlags(cmd *cobra.Command, f *templateSaveFlags) model.
 		patch.Title = &f.title
 	}
 	if cmd.Flags().Changed("author") {
-		patch.Author = &f.author
+		patch.Authors = listFlag(f.authors, model.SplitAuthors)
 	}
 	if cmd.Flags().Changed("subject") {
 		patch.Subject = &f.subject
 	}
 	if cmd.Flags().Changed("keywords") {
-		patch.Keywords = &f.keywords
+		patch.Keywords = listFlag(f.keywords, model.SplitKeywords)
 	}
 	if cmd.Flags().Changed("creator") {
 		patch.Creator = &f.creator
@@ -150,6 +151,7 @@ func newTemplateApplyCmd(handlers *app.Handlers) *cobra.Command {
 					Strict: f.strict,
 					JSON:   f.asJSON,
 				},
+				Write: f.write.options(),
 			}
 			if err := validate.TemplateApplyRequest(req); err != nil {
 				return err
@@ -170,6 +172,7 @@ func newTemplateApplyCmd(handlers *app.Handlers) *cobra.Command {
 	cmd.Flags().BoolVar(&f.inPlace, "in-place", false, "Modify file in place using safe atomic replace")
 	cmd.Flags().BoolVar(&f.strict, "strict", false, "Reject invalid metadata instead of auto-correcting")
 	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Emit result JSON")
+	addWriteFlags(cmd, &f.write)
 	_ = cmd.MarkFlagRequired("name")
 	_ = cmd.MarkFlagRequired("file")
 
@@ -17,6 +17,7 @@ type unsetFlags struct {
 	inPlace    bool
 	strict     bool
 	asJSON     bool
+	write      writeFlags
 	all        bool
 	title      bool
 	author     bool
@@ -46,6 +47,7 @@ func newUnsetCmd(handlers *app.Handlers) *cobra.Command {
 					Strict: f.strict,
 					JSON:   f.asJSON,
 				},
+				Write:  f.write.options(),
 				Fields: fields,
 				All:    f.all,
 			}
@@ -67,6 +69,7 @@ func newUnsetCmd(handlers *app.Handlers) *cobra.Command {
 	cmd.Flags().BoolVar(&f.inPlace, "in-place", false, "Modify file in place using safe atomic replace")
 	cmd.Flags().BoolVar(&f.strict, "strict", false, "Reject invalid metadata instead of auto-correcting")
 	cmd.Flags().BoolVar(&f.asJSON, "json", false, "Emit result JSON")
+	addWriteFlags(cmd, &f.write)
 
 	cmd.Flags().BoolVar(&f.all, "all", false, "Unset all supported metadata fields")
 	cmd.Flags().BoolVar(&f.title, "title", false, "Unset Title")
@@ -3,6 +3,7 @@ package cli
 import (
 	"bytes"
 	"context"
+	"slices"
 	"testing"
 
 	"pdfmeta/internal/model"
@@ -10,6 +11,9 @@ import (
 
 type fakeService struct {
 	showReq          model.ShowRequest
+	historyReq       model.HistoryRequest
+	revertReq        model.RevertRequest
+	repairReq        model.RepairRequest
 	setReq           model.SetRequest
 	unsetReq         model.UnsetRequest
 	batchReq         model.BatchRequest
@@ -25,6 +29,21 @@ func (f *fakeService) Show(_ context.Context, req model.ShowRequest) (model.Show
 	return model.ShowResult{InputPath: req.InputPath}, nil
 }
 
+func (f *fakeService) History(_ context.Context, req model.HistoryRequest) (model.HistoryResult, error) {
+	f.historyReq = req
+	return model.HistoryResult{InputPath: req.InputPath, Revisions: []model.Revision{{Index: 1}}}, nil
+}
+
+func (f *fakeService) Revert(_ context.Context, req model.RevertRequest) (model.RevertResult, error) {
+	f.revertReq = req
+	return model.RevertResult{InputPath: req.IO.InputPath}, nil
+}
+
+func (f *fakeService) Repair(_ context.Context, req model.RepairRequest) (model.ShowResult, error) {
+	f.repairReq = req
+	return model.ShowResult{InputPath: req.IO.InputPath}, nil
+}
+
 func (f *fakeService) Set(_ context.Context, req model.SetRequest) (model.ShowResult, error) {
 	f.setReq = req
 	return model.ShowResult{InputPath: req.IO.InputPath}, nil
@@ -82,6 +101,153 @@ func TestShowCommandWiresRequest(t *testing.T) {
 	}
 }
 
+func TestShowCommandPassword(t *testing.T) {
+	t.Setenv(passwordEnv, "from-env")
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{args: []string{"show", "--file", "doc.pdf"}, want: "from-env"},
+		{args: []string{"show", "--file", "doc.pdf", "--password", "from-flag"}, want: "from-flag"},
+	} {
+		svc := &fakeService{}
+		cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(tc.args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("execute %v: %v", tc.args, err)
+		}
+		if svc.showReq.Password != tc.want {
+			t.Fatalf("%v: password=%q want %q", tc.args, svc.showReq.Password, tc.want)
+		}
+	}
+}
+
+func TestWriteCommandsPassword(t *testing.T) {
+	t.Setenv(passwordEnv, "from-env")
+
+	svc := &fakeService{}
+	cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"set", "--file", "doc.pdf", "--in-place", "--title", "x", "--password", "owner"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute set: %v", err)
+	}
+	if svc.setReq.Write.Password != "owner" {
+		t.Fatalf("set password=%q want %q", svc.setReq.Write.Password, "owner")
+	}
+
+	cmd = NewRootCmdWithDependencies(Dependencies{Service: svc})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"unset", "--file", "doc.pdf", "--in-place", "--title"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute unset: %v", err)
+	}
+	if svc.unsetReq.Write.Password != "from-env" {
+		t.Fatalf("unset password=%q want %q", svc.unsetReq.Write.Password, "from-env")
+	}
+}
+
+func TestWriteCommandsForce(t *testing.T) {
+	t.Parallel()
+	svc := &fakeService{}
+	cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"set", "--file", "doc.pdf", "--in-place", "--title", "x", "--force"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute set: %v", err)
+	}
+	if !svc.setReq.Write.Force {
+		t.Fatalf("expected set --force to reach the write options")
+	}
+}
+
+func TestWriteCommandsShareWriteOptions(t *testing.T) {
+	t.Parallel()
+	writeArgs := []string{"--object-streams", "--rewrite", "--linearized", "refuse", "--repair", "--force", "--pdfa", "--password", "pw"}
+	want := model.WriteOptions{ObjectStreams: true, Rewrite: true, Linearized: model.LinearizedRefuse, Repair: true, Force: true, PDFA: true, Password: "pw"}
+
+	svc := &fakeService{}
+	for _, tc := range []struct {
+		args []string
+		got  func() model.WriteOptions
+	}{
+		{args: []string{"set", "--file", "doc.pdf", "--in-place", "--title", "x"}, got: func() model.WriteOptions { return svc.setReq.Write }},
+		{args: []string{"unset", "--file", "doc.pdf", "--in-place", "--title"}, got: func() model.WriteOptions { return svc.unsetReq.Write }},
+		{args: []string{"template", "apply", "--name", "release", "--file", "doc.pdf", "--in-place"}, got: func() model.WriteOptions { return svc.templateApplyReq.Write }},
+	} {
+		cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append(tc.args, writeArgs...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("execute %v: %v", tc.args, err)
+		}
+		if got := tc.got(); got != want {
+			t.Fatalf("%v: write options %+v want %+v", tc.args, got, want)
+		}
+	}
+}
+
+func TestHistoryCommandWiresRequest(t *testing.T) {
+	t.Parallel()
+	svc := &fakeService{}
+	cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs([]string{"history", "--file", "doc.pdf"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute history: %v", err)
+	}
+	if svc.historyReq.InputPath != "doc.pdf" || svc.historyReq.JSON {
+		t.Fatalf("unexpected history request: %+v", svc.historyReq)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("Revisions: 1")) {
+		t.Fatalf("unexpected history output: %q", out.String())
+	}
+}
+
+func TestRevertCommandWiresRequest(t *testing.T) {
+	t.Parallel()
+	svc := &fakeService{}
+	cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs([]string{"revert", "--file", "in.pdf", "--in-place", "--steps", "1"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute revert: %v", err)
+	}
+	if svc.revertReq.IO.InputPath != "in.pdf" || !svc.revertReq.IO.InPlace || svc.revertReq.Steps != 1 || svc.revertReq.To != 0 {
+		t.Fatalf("unexpected revert request: %+v", svc.revertReq)
+	}
+}
+
+func TestRepairCommandWiresRequest(t *testing.T) {
+	t.Parallel()
+	svc := &fakeService{}
+	cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetErr(out)
+	cmd.SetArgs([]string{"repair", "--file", "in.pdf", "--out", "fixed.pdf", "--json"})
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute repair: %v", err)
+	}
+	if svc.repairReq.IO.InputPath != "in.pdf" || svc.repairReq.IO.OutputPath != "fixed.pdf" || svc.repairReq.IO.InPlace || !svc.repairReq.Exec.JSON {
+		t.Fatalf("unexpected repair request: %+v", svc.repairReq)
+	}
+}
+
 func TestSetCommandWiresRequest(t *testing.T) {
 	t.Parallel()
 	svc := &fakeService{}
@@ -89,7 +255,7 @@ func TestSetCommandWiresRequest(t *testing.T) {
 	out := &bytes.Buffer{}
 	cmd.SetOut(out)
 	cmd.SetErr(out)
-	cmd.SetArgs([]string{"set", "--file", "in.pdf", "--out", "out.pdf", "--title", "new title"})
+	cmd.SetArgs([]string{"set", "--file", "in.pdf", "--out", "out.pdf", "--title", "new title", "--author", "Doe, J.", "--author", "Roe, R.", "--rewrite", "--linearized", "refuse", "--repair"})
 
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("execute set: %v", err)
@@ -100,6 +266,40 @@ func TestSetCommandWiresRequest(t *testing.T) {
 	if svc.setReq.Changes.Title == nil || *svc.setReq.Changes.Title != "new title" {
 		t.Fatalf("expected title patch, got %+v", svc.setReq.Changes)
 	}
+	if !slices.Equal(svc.setReq.Changes.Authors, model.List{"Doe, J.", "Roe, R."}) || svc.setReq.Changes.Keywords != nil {
+		t.Fatalf("expected two authors and no keywords, got %+v", svc.setReq.Changes)
+	}
+	if !svc.setReq.Write.Rewrite || svc.setReq.Write.ObjectStreams || svc.setReq.Write.Linearized != model.LinearizedRefuse || !svc.setReq.Write.Repair {
+		t.Fatalf("unexpected write options: %+v", svc.setReq.Write)
+	}
+}
+
+func TestSetCommandListFlags(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		args              []string
+		authors, keywords model.List
+	}{
+		// Repeated values are kept verbatim, separators and all.
+		{args: []string{"--author", "Smith; Jones & Co.", "--author", "Poe", "--keywords", "a, b", "--keywords", "c"}, authors: model.List{"Smith; Jones & Co.", "Poe"}, keywords: model.List{"a, b", "c"}},
+		// A lone value is read the way it was before the flags repeated.
+		{args: []string{"--author", "Doe, J.; Roe, R.", "--keywords", "a, b;c"}, authors: model.List{"Doe, J.", "Roe, R."}, keywords: model.List{"a", "b", "c"}},
+		{args: []string{"--author", ""}, authors: model.List{}},
+	} {
+		svc := &fakeService{}
+		cmd := NewRootCmdWithDependencies(Dependencies{Service: svc})
+		cmd.SetOut(&bytes.Buffer{})
+		cmd.SetErr(&bytes.Buffer{})
+		cmd.SetArgs(append([]string{"set", "--file", "in.pdf", "--in-place"}, tc.args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("execute set %v: %v", tc.args, err)
+		}
+		got := svc.setReq.Changes
+		if !slices.Equal(got.Authors, tc.authors) || (got.Authors == nil) != (tc.authors == nil) || !slices.Equal(got.Keywords, tc.keywords) {
+			t.Fatalf("%v: authors=%#v keywords=%#v", tc.args, got.Authors, got.Keywords)
+		}
+	}
 }
 
 func TestUnsetCommandWiresFields(t *testing.T) {
@@ -126,7 +326,7 @@ func TestTemplateApplyWiresRequest(t *testing.T) {
 	out := &bytes.Buffer{}
 	cmd.SetOut(out)
 	cmd.SetErr(out)
-	cmd.SetArgs([]string{"template", "apply", "--name", "release", "--file", "in.pdf", "--out", "out.pdf", "--strict", "--json"})
+	cmd.SetArgs([]string{"template", "apply", "--name", "release", "--file", "in.pdf", "--out", "out.pdf", "--strict", "--json", "--object-streams"})
 
 	if err := cmd.Execute(); err != nil {
 		t.Fatalf("execute template apply: %v", err)
@@ -140,6 +340,9 @@ func TestTemplateApplyWiresRequest(t *testing.T) {
 	if svc.templateApplyReq.IO.InputPath != "in.pdf" || svc.templateApplyReq.IO.OutputPath != "out.pdf" || svc.templateApplyReq.IO.InPlace {
 		t.Fatalf("unexpected IO options: %+v", svc.templateApplyReq.IO)
 	}
+	if !svc.templateApplyReq.Write.ObjectStreams {
+		t.Fatalf("unexpected write options: %+v", svc.templateApplyReq.Write)
+	}
 }
 
 func TestBatchCommandWiresRequest(t *testing.T) {
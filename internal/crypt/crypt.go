@@ -0,0 +1,174 @@
+// Package crypt implements the PDF Standard Security Handler: password
+// authentication and the RC4 and AES object ciphers for security handler
+// revisions 2 through 6. It works on plain values so that the PDF object
+// layer can feed it the parsed /Encrypt dictionary.
+package crypt
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Method is the cipher a crypt filter applies.
+type Method int
+
+const (
+	// MethodNone leaves data unchanged (the Identity crypt filter).
+	MethodNone Method = iota
+	// MethodRC4 is RC4 with a per-object key (/V2).
+	MethodRC4
+	// MethodAESV2 is AES-128-CBC with a per-object key (/AESV2).
+	MethodAESV2
+	// MethodAESV3 is AES-256-CBC with the file key (/AESV3).
+	MethodAESV3
+)
+
+func (m Method) String() string {
+	switch m {
+	case MethodNone:
+		return "Identity"
+	case MethodRC4:
+		return "V2"
+	case MethodAESV2:
+		return "AESV2"
+	case MethodAESV3:
+		return "AESV3"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+var (
+	// ErrPassword means the password matches neither the user nor the owner
+	// password.
+	ErrPassword = errors.New("incorrect password")
+	// ErrUnsupported means the encryption parameters are outside what the
+	// standard security handler defines.
+	ErrUnsupported = errors.New("unsupported encryption parameters")
+)
+
+// Params are the standard security handler entries of an /Encrypt
+// dictionary, with crypt filters already resolved to methods.
+type Params struct {
+	V int
+	R int
+	// Length is the file key length in bytes.
+	Length int
+	O      []byte
+	U      []byte
+	OE     []byte
+	UE     []byte
+	Perms  []byte
+	P      int32
+	// EncryptMetadata is false when the XMP metadata stream is stored in
+	// clear text.
+	EncryptMetadata bool
+	StmF            Method
+	StrF            Method
+	// ID is the first element of the trailer /ID array.
+	ID []byte
+}
+
+// Handler holds the file key recovered from a password.
+type Handler struct {
+	params Params
+	key    []byte
+	owner  bool
+}
+
+// Authenticate derives the file key from password, trying it first as the
+// user password and then as the owner password.
+func Authenticate(p Params, password string) (*Handler, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	if p.R >= 5 {
+		return authenticateAES256(p, password)
+	}
+	return authenticateLegacy(p, password)
+}
+
+func (p Params) check() error {
+	switch {
+	case p.R < 2 || p.R > 6:
+		return fmt.Errorf("%w: revision %d", ErrUnsupported, p.R)
+	case p.V < 1 || p.V == 3 || p.V > 5:
+		return fmt.Errorf("%w: version %d", ErrUnsupported, p.V)
+	case p.R >= 5 && p.Length != 32:
+		return fmt.Errorf("%w: revision %d needs a 256-bit key", ErrUnsupported, p.R)
+	case p.R < 5 && (p.Length < 5 || p.Length > 16):
+		return fmt.Errorf("%w: key length %d bytes", ErrUnsupported, p.Length)
+	case p.R >= 5 && (len(p.O) < 48 || len(p.U) < 48 || len(p.OE) < 32 || len(p.UE) < 32):
+		return fmt.Errorf("%w: /O, /U, /OE or /UE too short", ErrUnsupported)
+	case p.R < 5 && (len(p.O) < 32 || len(p.U) < 32):
+		return fmt.Errorf("%w: /O or /U too short", ErrUnsupported)
+	}
+	for _, m := range []Method{p.StmF, p.StrF} {
+		if m == MethodAESV3 && p.R < 5 || m != MethodNone && m != MethodAESV3 && p.R >= 5 {
+			return fmt.Errorf("%w: %s with revision %d", ErrUnsupported, m, p.R)
+		}
+	}
+	return nil
+}
+
+// Owner reports whether the owner password was supplied, which lifts the
+// /P restrictions.
+func (h *Handler) Owner() bool {
+	return h.owner
+}
+
+// EncryptMetadata reports whether the XMP metadata stream is encrypted.
+func (h *Handler) EncryptMetadata() bool {
+	return h.params.EncryptMetadata
+}
+
+// DecryptString decrypts a string belonging to object num gen.
+func (h *Handler) DecryptString(num, gen int, b []byte) ([]byte, error) {
+	return h.decrypt(h.params.StrF, num, gen, b)
+}
+
+// DecryptStream decrypts the raw (still filter-encoded) data of stream
+// object num gen.
+func (h *Handler) DecryptStream(num, gen int, b []byte) ([]byte, error) {
+	return h.decrypt(h.params.StmF, num, gen, b)
+}
+
+// EncryptString encrypts a string for object num gen.
+func (h *Handler) EncryptString(num, gen int, b []byte) ([]byte, error) {
+	return h.encrypt(h.params.StrF, num, gen, b)
+}
+
+// EncryptStream encrypts the filter-encoded data of stream object num gen.
+func (h *Handler) EncryptStream(num, gen int, b []byte) ([]byte, error) {
+	return h.encrypt(h.params.StmF, num, gen, b)
+}
+
+func (h *Handler) encrypt(m Method, num, gen int, b []byte) ([]byte, error) {
+	switch m {
+	case MethodNone:
+		return b, nil
+	case MethodRC4:
+		return rc4Crypt(h.objectKey(num, gen, false), b), nil
+	case MethodAESV2:
+		return aesEncrypt(h.objectKey(num, gen, true), b)
+	case MethodAESV3:
+		return aesEncrypt(h.key, b)
+	default:
+		return nil, fmt.Errorf("%w: method %s", ErrUnsupported, m)
+	}
+}
+
+func (h *Handler) decrypt(m Method, num, gen int, b []byte) ([]byte, error) {
+	switch m {
+	case MethodNone:
+		return b, nil
+	case MethodRC4:
+		return rc4Crypt(h.objectKey(num, gen, false), b), nil
+	case MethodAESV2:
+		return aesDecrypt(h.objectKey(num, gen, true), b)
+	case MethodAESV3:
+		return aesDecrypt(h.key, b)
+	default:
+		return nil, fmt.Errorf("%w: method %s", ErrUnsupported, m)
+	}
+}
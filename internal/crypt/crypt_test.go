@@ -0,0 +1,71 @@
+package crypt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestAuthenticateRejectsUnsupportedParams(t *testing.T) {
+	t.Parallel()
+	legacy := Params{V: 2, R: 3, Length: 16, O: make([]byte, 32), U: make([]byte, 32), StmF: MethodRC4, StrF: MethodRC4}
+	tests := []struct {
+		name   string
+		modify func(*Params)
+	}{
+		{name: "revision", modify: func(p *Params) { p.R = 7 }},
+		{name: "version", modify: func(p *Params) { p.V = 3 }},
+		{name: "key length", modify: func(p *Params) { p.Length = 32 }},
+		{name: "short O", modify: func(p *Params) { p.O = p.O[:16] }},
+		{name: "aesv3 before revision 5", modify: func(p *Params) { p.StmF = MethodAESV3 }},
+		{name: "revision 6 short key", modify: func(p *Params) { p.V, p.R = 5, 6 }},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := legacy
+			tc.modify(&p)
+			if _, err := Authenticate(p, ""); !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("Authenticate()=%v want ErrUnsupported", err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWrongPassword(t *testing.T) {
+	t.Parallel()
+	p := Params{V: 2, R: 3, Length: 16, O: bytes.Repeat([]byte{1}, 32), U: bytes.Repeat([]byte{2}, 32), StmF: MethodRC4, StrF: MethodRC4}
+	if _, err := Authenticate(p, "secret"); !errors.Is(err, ErrPassword) {
+		t.Fatalf("Authenticate()=%v want ErrPassword", err)
+	}
+}
+
+func TestAESDecryptRejectsBadInput(t *testing.T) {
+	t.Parallel()
+	key := make([]byte, 16)
+	for _, data := range [][]byte{make([]byte, 15), make([]byte, 16), make([]byte, 33)} {
+		if _, err := aesDecrypt(key, data); err == nil {
+			t.Fatalf("aesDecrypt(%d bytes) succeeded", len(data))
+		}
+	}
+	if got, err := aesDecrypt(key, nil); err != nil || len(got) != 0 {
+		t.Fatalf("aesDecrypt(nil)=%q, %v want empty", got, err)
+	}
+}
+
+func TestAESRoundTrip(t *testing.T) {
+	t.Parallel()
+	key := bytes.Repeat([]byte{7}, 32)
+	for _, n := range []int{0, 1, 15, 16, 17, 100} {
+		plain := bytes.Repeat([]byte{'a'}, n)
+		sealed, err := aesEncrypt(key, plain)
+		if err != nil {
+			t.Fatalf("aesEncrypt(%d bytes): %v", n, err)
+		}
+		got, err := aesDecrypt(key, sealed)
+		if err != nil || !bytes.Equal(got, plain) {
+			t.Fatalf("round trip of %d bytes=%q, %v", n, got, err)
+		}
+	}
+}
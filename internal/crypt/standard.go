@@ -0,0 +1,268 @@
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// passwordPad is the padding string of ISO 32000-1 7.6.3.3, Algorithm 2.
+var passwordPad = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+// maxAES256Password is the byte limit revisions 5 and 6 put on UTF-8
+// passwords.
+const maxAES256Password = 127
+
+// authenticateLegacy handles revisions 2-4 (Algorithms 2, 6 and 7).
+func authenticateLegacy(p Params, password string) (*Handler, error) {
+	pw := []byte(password)
+	if key := legacyFileKey(p, pw); legacyUserMatches(p, key) {
+		return &Handler{params: p, key: key}, nil
+	}
+	// Algorithm 7: the owner password decrypts /O to the user password.
+	user := append([]byte(nil), p.O[:32]...)
+	ownerKey := legacyOwnerKey(p, pw)
+	if p.R == 2 {
+		user = rc4Crypt(ownerKey, user)
+	} else {
+		for i := 19; i >= 0; i-- {
+			user = rc4Crypt(xorKey(ownerKey, byte(i)), user)
+		}
+	}
+	if key := legacyFileKey(p, user); legacyUserMatches(p, key) {
+		return &Handler{params: p, key: key, owner: true}, nil
+	}
+	return nil, ErrPassword
+}
+
+// legacyFileKey is Algorithm 2.
+func legacyFileKey(p Params, password []byte) []byte {
+	h := md5.New()
+	h.Write(padPassword(password))
+	h.Write(p.O[:32])
+	var perm [4]byte
+	binary.LittleEndian.PutUint32(perm[:], uint32(p.P))
+	h.Write(perm[:])
+	h.Write(p.ID)
+	if p.R >= 4 && !p.EncryptMetadata {
+		h.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	}
+	sum := h.Sum(nil)
+	n := p.Length
+	if p.R == 2 {
+		n = 5
+	}
+	if p.R >= 3 {
+		for i := 0; i < 50; i++ {
+			next := md5.Sum(sum[:n])
+			sum = next[:]
+		}
+	}
+	return sum[:n]
+}
+
+// legacyOwnerKey is steps (a)-(d) of Algorithm 3.
+func legacyOwnerKey(p Params, password []byte) []byte {
+	sum := md5.Sum(padPassword(password))
+	key := sum[:]
+	n := p.Length
+	if p.R == 2 {
+		n = 5
+	}
+	if p.R >= 3 {
+		for i := 0; i < 50; i++ {
+			next := md5.Sum(key)
+			key = next[:]
+		}
+	}
+	return key[:n]
+}
+
+// legacyUserMatches computes /U from key (Algorithms 4 and 5) and compares
+// it with the stored value. Revision 3 and later only fix the first 16 bytes.
+func legacyUserMatches(p Params, key []byte) bool {
+	if p.R == 2 {
+		return bytes.Equal(rc4Crypt(key, passwordPad), p.U[:32])
+	}
+	h := md5.New()
+	h.Write(passwordPad)
+	h.Write(p.ID)
+	u := rc4Crypt(key, h.Sum(nil))
+	for i := 1; i <= 19; i++ {
+		u = rc4Crypt(xorKey(key, byte(i)), u)
+	}
+	return bytes.Equal(u, p.U[:16])
+}
+
+// objectKey is Algorithm 1: the file key extended with the object number,
+// generation and, for AES, the "sAlT" suffix.
+func (h *Handler) objectKey(num, gen int, aes bool) []byte {
+	m := md5.New()
+	m.Write(h.key)
+	m.Write([]byte{byte(num), byte(num >> 8), byte(num >> 16), byte(gen), byte(gen >> 8)})
+	if aes {
+		m.Write([]byte("sAlT"))
+	}
+	n := len(h.key) + 5
+	if n > 16 {
+		n = 16
+	}
+	return m.Sum(nil)[:n]
+}
+
+// authenticateAES256 handles revisions 5 and 6 (Algorithms 2.A and 2.B).
+func authenticateAES256(p Params, password string) (*Handler, error) {
+	pw := []byte(password)
+	if len(pw) > maxAES256Password {
+		pw = pw[:maxAES256Password]
+	}
+	u := p.U[:48]
+	if bytes.Equal(aes256Hash(p.R, pw, u[32:40], nil), u[:32]) {
+		key, err := unwrapFileKey(aes256Hash(p.R, pw, u[40:48], nil), p.UE[:32])
+		if err != nil {
+			return nil, err
+		}
+		return &Handler{params: p, key: key}, nil
+	}
+	o := p.O[:48]
+	if bytes.Equal(aes256Hash(p.R, pw, o[32:40], u), o[:32]) {
+		key, err := unwrapFileKey(aes256Hash(p.R, pw, o[40:48], u), p.OE[:32])
+		if err != nil {
+			return nil, err
+		}
+		return &Handler{params: p, key: key, owner: true}, nil
+	}
+	return nil, ErrPassword
+}
+
+// aes256Hash is SHA-256 for revision 5 and the iterated hash of Algorithm
+// 2.B for revision 6. udata is the 48-byte /U value for owner checks.
+func aes256Hash(r int, password, salt, udata []byte) []byte {
+	h := sha256.New()
+	h.Write(password)
+	h.Write(salt)
+	h.Write(udata)
+	k := h.Sum(nil)
+	if r == 5 {
+		return k
+	}
+
+	var e []byte
+	for i := 0; i < 64 || int(e[len(e)-1]) > i-32; i++ {
+		block := make([]byte, 0, len(password)+len(k)+len(udata))
+		block = append(block, password...)
+		block = append(block, k...)
+		block = append(block, udata...)
+		k1 := bytes.Repeat(block, 64)
+
+		c, _ := aes.NewCipher(k[:16])
+		e = make([]byte, len(k1))
+		cipher.NewCBCEncrypter(c, k[16:32]).CryptBlocks(e, k1)
+
+		sum := 0
+		for _, b := range e[:16] {
+			sum += int(b)
+		}
+		var next hash.Hash
+		switch sum % 3 {
+		case 0:
+			next = sha256.New()
+		case 1:
+			next = sha512.New384()
+		default:
+			next = sha512.New()
+		}
+		next.Write(e)
+		k = next.Sum(nil)
+	}
+	return k[:32]
+}
+
+// unwrapFileKey decrypts /UE or /OE: AES-256 in CBC mode with a zero IV and
+// no padding.
+func unwrapFileKey(kek, wrapped []byte) ([]byte, error) {
+	c, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 32)
+	cipher.NewCBCDecrypter(c, make([]byte, aes.BlockSize)).CryptBlocks(key, wrapped)
+	return key, nil
+}
+
+// aesDecrypt decrypts data laid out as a 16-byte IV followed by CBC
+// ciphertext with PKCS#7 padding.
+func aesDecrypt(key, data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	if len(data) < 2*aes.BlockSize || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("aes ciphertext is not a whole number of blocks")
+	}
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data)-aes.BlockSize)
+	cipher.NewCBCDecrypter(c, data[:aes.BlockSize]).CryptBlocks(out, data[aes.BlockSize:])
+	pad := int(out[len(out)-1])
+	if pad < 1 || pad > aes.BlockSize {
+		return nil, errors.New("invalid aes padding")
+	}
+	return out[:len(out)-pad], nil
+}
+
+// aesEncrypt is the inverse of aesDecrypt with a random IV.
+func aesEncrypt(key, data []byte) ([]byte, error) {
+	c, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	out := make([]byte, aes.BlockSize+len(data)+pad)
+	if _, err := rand.Read(out[:aes.BlockSize]); err != nil {
+		return nil, err
+	}
+	body := out[aes.BlockSize:]
+	copy(body, data)
+	for i := len(data); i < len(body); i++ {
+		body[i] = byte(pad)
+	}
+	cipher.NewCBCEncrypter(c, out[:aes.BlockSize]).CryptBlocks(body, body)
+	return out, nil
+}
+
+func rc4Crypt(key, data []byte) []byte {
+	c, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	c.XORKeyStream(out, data)
+	return out
+}
+
+func xorKey(key []byte, v byte) []byte {
+	out := make([]byte, len(key))
+	for i, b := range key {
+		out[i] = b ^ v
+	}
+	return out
+}
+
+func padPassword(pw []byte) []byte {
+	out := make([]byte, 0, 32)
+	out = append(out, pw...)
+	if len(out) > 32 {
+		out = out[:32]
+	}
+	return append(out, passwordPad[:32-len(out)]...)
+}
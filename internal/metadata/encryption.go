@@ -0,0 +1,70 @@
+package metadata
+
+import (
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// User access permission bits of /P (ISO 32000-1, Table 22), numbered from 1.
+const (
+	permPrint     = 1 << (3 - 1)
+	permModify    = 1 << (4 - 1)
+	permCopy      = 1 << (5 - 1)
+	permAnnotate  = 1 << (6 - 1)
+	permFillForms = 1 << (9 - 1)
+	permAssemble  = 1 << (11 - 1)
+)
+
+// readEncryption describes doc's /Encrypt dictionary, or returns nil for
+// unencrypted documents and dictionaries that cannot be read.
+func readEncryption(doc *pdf.Document) *model.Encryption {
+	if !doc.Encrypted() {
+		return nil
+	}
+	sec, err := doc.Security()
+	if err != nil {
+		return nil
+	}
+	enc := &model.Encryption{
+		Filter:          sec.Filter,
+		SubFilter:       sec.SubFilter,
+		V:               sec.V,
+		R:               sec.R,
+		KeyLength:       sec.KeyLength,
+		StmF:            sec.StmF,
+		StrF:            sec.StrF,
+		EncryptMetadata: sec.EncryptMetadata,
+		Permissions:     decodePermissions(sec.P, sec.R),
+		Access:          model.AccessLocked,
+	}
+	for _, cf := range sec.CryptFilters {
+		enc.CryptFilters = append(enc.CryptFilters, model.CryptFilter{Name: cf.Name, Method: cf.Method, Length: cf.Length})
+	}
+	switch {
+	case doc.OwnerUnlocked():
+		enc.Access = model.AccessOwner
+	case doc.Unlocked():
+		enc.Access = model.AccessUser
+	}
+	return enc
+}
+
+// decodePermissions reads /P. Revision 2 has no separate form-filling or
+// assembly bits; they follow annotate and modify respectively.
+func decodePermissions(p int32, r int) model.Permissions {
+	has := func(bit int32) bool { return p&bit != 0 }
+	perms := model.Permissions{
+		P:         p,
+		Print:     has(permPrint),
+		Modify:    has(permModify),
+		Copy:      has(permCopy),
+		Annotate:  has(permAnnotate),
+		FillForms: has(permFillForms),
+		Assemble:  has(permAssemble),
+	}
+	if r < 3 {
+		perms.FillForms = perms.Annotate
+		perms.Assemble = perms.Modify
+	}
+	return perms
+}
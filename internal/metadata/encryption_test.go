@@ -0,0 +1,74 @@
+package metadata
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+func TestDecodePermissions(t *testing.T) {
+	tests := []struct {
+		name string
+		p    int32
+		r    int
+		want model.Permissions
+	}{
+		{name: "none", p: -3904, r: 3, want: model.Permissions{P: -3904}},
+		{name: "all", p: -4, r: 4, want: model.Permissions{P: -4, Print: true, Modify: true, Copy: true, Annotate: true, FillForms: true, Assemble: true}},
+		{name: "print and forms", p: -3904 | permPrint | permFillForms, r: 6, want: model.Permissions{P: -3904 | permPrint | permFillForms, Print: true, FillForms: true}},
+		{name: "revision 2 follows annotate and modify", p: -64 | permModify | permAnnotate, r: 2, want: model.Permissions{P: -64 | permModify | permAnnotate, Modify: true, Annotate: true, FillForms: true, Assemble: true}},
+	}
+	for _, tc := range tests {
+		if got := decodePermissions(tc.p, tc.r); got != tc.want {
+			t.Fatalf("%s: decodePermissions()=%+v want %+v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReadReportsEncryption(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		want     model.Encryption
+	}{
+		{
+			name: "encrypted-rc4-40.pdf",
+			want: model.Encryption{Filter: "Standard", V: 1, R: 2, KeyLength: 40, EncryptMetadata: true, Permissions: decodePermissions(-3904, 2), Access: model.AccessUser},
+		},
+		{
+			name: "encrypted-aes-128.pdf", password: "owner",
+			want: model.Encryption{
+				Filter: "Standard", V: 4, R: 4, KeyLength: 128, StmF: "StdCF", StrF: "StdCF",
+				CryptFilters:    []model.CryptFilter{{Name: "StdCF", Method: "AESV2", Length: 128}},
+				EncryptMetadata: true, Permissions: decodePermissions(-3904, 4), Access: model.AccessOwner,
+			},
+		},
+		{
+			name: "encrypted-aes-256.pdf",
+			want: model.Encryption{
+				Filter: "Standard", V: 5, R: 6, KeyLength: 256, StmF: "StdCF", StrF: "StdCF",
+				CryptFilters:    []model.CryptFilter{{Name: "StdCF", Method: "AESV3", Length: 256}},
+				EncryptMetadata: true, Permissions: decodePermissions(-3904, 6), Access: model.AccessLocked,
+			},
+		},
+	}
+	for _, tc := range tests {
+		res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath(tc.name), Password: tc.password})
+		if err != nil {
+			t.Fatalf("%s: Read: %v", tc.name, err)
+		}
+		if res.Encryption == nil || !reflect.DeepEqual(*res.Encryption, tc.want) {
+			t.Fatalf("%s: Encryption=%+v want %+v", tc.name, res.Encryption, tc.want)
+		}
+	}
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("minimal.pdf")})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Encryption != nil {
+		t.Fatalf("unencrypted file reported encryption: %+v", res.Encryption)
+	}
+}
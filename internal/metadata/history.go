@@ -0,0 +1,53 @@
+package metadata
+
+import (
+	"context"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// History reads the metadata in effect at every revision of inputPath by
+// parsing each revision's prefix of the file on its own. Changes are left for
+// the caller to compute.
+func (s *Store) History(ctx context.Context, inputPath string) ([]model.Revision, error) {
+	if err := ctxErr(ctx); err != nil {
+		return nil, err
+	}
+
+	doc, err := pdf.Open(inputPath)
+	if err != nil {
+		return nil, err
+	}
+	defer doc.Close()
+	revs := doc.Revisions()
+	if len(revs) == 0 {
+		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "no complete revision found (missing startxref or %%EOF)"}
+	}
+
+	out := make([]model.Revision, 0, len(revs))
+	for i, rev := range revs {
+		if err := ctxErr(ctx); err != nil {
+			return nil, err
+		}
+		prefix, err := doc.AtRevision(rev)
+		if err != nil {
+			return nil, err
+		}
+		// Encrypted revisions are only fully readable with the empty user
+		// password; otherwise just a clear-text XMP stream is.
+		_ = prefix.Unlock("")
+		meta, infoFound, xmpFound := readNativeMetadata(prefix)
+		out = append(out, model.Revision{
+			Index:       i + 1,
+			StartOffset: rev.Start,
+			EndOffset:   rev.End,
+			Size:        rev.Size(),
+			XRefOffset:  rev.XRefOffset,
+			Metadata:    meta,
+			InfoFound:   infoFound,
+			XMPFound:    xmpFound,
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"context"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+func TestHistoryReadsEachRevision(t *testing.T) {
+	revs, err := NewStore().History(context.Background(), fixturePath("incremental-updates.pdf"))
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	want := []model.Metadata{
+		{Title: "Revision 1", Authors: []string{"Original Author"}},
+		{Title: "Revision 2", Authors: []string{"Original Author"}},
+		{Title: "Revision 3", Authors: []string{"Second Author"}},
+	}
+	if len(revs) != len(want) {
+		t.Fatalf("History()=%d revisions want %d", len(revs), len(want))
+	}
+	for i, rev := range revs {
+		if rev.Index != i+1 || !rev.Metadata.Equal(want[i]) || !rev.InfoFound || rev.XMPFound {
+			t.Fatalf("revision %d=%#v want metadata %#v", i+1, rev, want[i])
+		}
+		if rev.Size != rev.EndOffset-rev.StartOffset || rev.Size <= 0 {
+			t.Fatalf("revision %d has inconsistent range %#v", i+1, rev)
+		}
+	}
+}
+
+func TestHistoryIncludesNewWrite(t *testing.T) {
+	path := copyFixture(t, "xref-stream.pdf")
+	before, err := NewStore().History(context.Background(), path)
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+
+	title := "Appended"
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+	after, err := NewStore().History(context.Background(), path)
+	if err != nil {
+		t.Fatalf("History after write: %v", err)
+	}
+	if len(after) != len(before)+1 {
+		t.Fatalf("History()=%d revisions want %d", len(after), len(before)+1)
+	}
+	last := after[len(after)-1]
+	if last.Metadata.Title != title || !last.XMPFound {
+		t.Fatalf("unexpected newest revision %#v", last)
+	}
+	if last.StartOffset != before[len(before)-1].EndOffset {
+		t.Fatalf("newest revision starts at %d want %d", last.StartOffset, before[len(before)-1].EndOffset)
+	}
+}
+
+func TestHistoryWithoutRevisions(t *testing.T) {
+	_, err := NewStore().History(context.Background(), fixturePath("truncated-no-eof.pdf"))
+	assertAppErrorCode(t, err, model.ErrPDFMalformed)
+}
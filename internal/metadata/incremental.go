@@ -0,0 +1,476 @@
+package metadata
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// pendingObject is one object appended by an incremental update. Packed
+// objects are stored in the update's object stream instead of the file body.
+type pendingObject struct {
+	ref    pdf.Ref
+	obj    pdf.Object
+	packed bool
+}
+
+// sectionOnlyTrailerKeys describe a single cross-reference section and are
+// not carried over into the trailer of a new section.
+var sectionOnlyTrailerKeys = []pdf.Name{
+	"Prev", "XRefStm",
+	"Type", "W", "Index", "Length", "Filter", "DecodeParms", "DL", "F", "FFilter", "FDecodeParms",
+}
+
+// updatePlan describes the objects a metadata write replaces or adds,
+// independent of how they are serialized.
+type updatePlan struct {
+	trailer   pdf.Dict
+	rootRef   pdf.Ref
+	infoRef   pdf.Ref
+	catalog   pdf.Dict
+	objs      []pendingObject
+	next      int
+	useStream bool
+}
+
+// writeNativeIncremental streams the original file unchanged and appends
+// only the new objects and cross-reference section.
+func writeNativeIncremental(doc *pdf.Document, meta model.Metadata, xmpPacket []byte, opts model.WriteOptions) (io.Reader, error) {
+	plan, err := planMetadataUpdate(doc, meta, xmpPacket, opts)
+	if err != nil {
+		return nil, err
+	}
+	startXRef, ok := doc.StartXRef()
+	if !ok {
+		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not parse startxref"}
+	}
+	trailer := nextTrailer(plan.trailer, plan.rootRef, plan.infoRef)
+	trailer["Prev"] = pdf.Integer(startXRef)
+
+	base := doc.Size()
+	var update []byte
+	last := make([]byte, 1)
+	if _, err := doc.ReadAt(last, base-1); err == nil && last[0] != '\n' {
+		update = append(update, '\n')
+	}
+	seal := documentSealer(doc)
+	if plan.useStream {
+		update, err = appendXRefStreamSection(update, base, seal, plan.objs, trailer, plan.next)
+	} else {
+		update, err = appendXRefTableSection(update, base, seal, plan.objs, trailer, plan.next)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return io.MultiReader(io.NewSectionReader(doc, 0, base), bytes.NewReader(update)), nil
+}
+
+// planMetadataUpdate resolves the catalog and decides which object numbers
+// the new Info, Metadata and catalog objects are written under.
+func planMetadataUpdate(doc *pdf.Document, meta model.Metadata, xmpPacket []byte, opts model.WriteOptions) (updatePlan, error) {
+	trailer, err := doc.Trailer()
+	if err != nil {
+		return updatePlan{}, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not parse trailer root reference", Cause: err}
+	}
+	rootRef, ok := trailer.Ref("Root")
+	if !ok {
+		return updatePlan{}, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not parse trailer root reference"}
+	}
+
+	maxObj := doc.MaxObjectNumber()
+	if maxObj < 1 {
+		return updatePlan{}, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not detect object numbers"}
+	}
+
+	rootObj, err := doc.Object(rootRef)
+	if err != nil {
+		return updatePlan{}, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not read catalog object", Cause: err}
+	}
+	rootDict, ok := rootObj.(pdf.Dict)
+	if !ok {
+		return updatePlan{}, &model.AppError{Code: model.ErrPDFMalformed, Message: "catalog dictionary missing"}
+	}
+
+	// Existing objects are overwritten under their own number and generation;
+	// only objects that are genuinely missing get fresh numbers. Without the
+	// key of an encrypted document the Info dictionary is kept as it is.
+	locked := doc.Encrypted() && !doc.Unlocked()
+	next := maxObj + 1
+	allocate := func() pdf.Ref {
+		ref := pdf.Ref{Num: next}
+		next++
+		return ref
+	}
+	var info pdf.Dict
+	infoRef, ok := existingRef(doc, trailer.Get("Info"))
+	if (!ok || infoRef == rootRef) && !locked {
+		infoRef = allocate()
+	} else if !locked {
+		info, _ = resolveDict(doc, infoRef)
+	}
+	metadataRef, ok := existingRef(doc, rootDict.Get("Metadata"))
+	if !ok || metadataRef == rootRef || metadataRef == infoRef {
+		metadataRef = allocate()
+	}
+
+	catalog := rootDict.Clone()
+	oldMetadataRef, _ := rootDict.Ref("Metadata")
+	catalogChanged := oldMetadataRef != metadataRef
+	catalog["Metadata"] = metadataRef
+
+	// Cross-reference streams and object streams need PDF 1.5; an update can
+	// raise the version through the catalog without touching the header.
+	useStream := doc.UsesXRefStreams() || opts.ObjectStreams
+	if useStream && versionBefore(documentVersion(doc, rootDict), 1, 5) {
+		catalog["Version"] = pdf.Name("1.5")
+		catalogChanged = true
+	}
+
+	if locked && catalogChanged {
+		return updatePlan{}, &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonPasswordRequired, Message: "adding an XMP stream to an encrypted pdf needs the document key"}
+	}
+
+	var objs []pendingObject
+	if !locked {
+		objs = append(objs, pendingObject{ref: infoRef, obj: renderInfoObject(info, meta), packed: opts.ObjectStreams && infoRef.Gen == 0})
+	}
+	objs = append(objs, pendingObject{ref: metadataRef, obj: renderMetadataObject(xmpPacket)})
+	if catalogChanged {
+		objs = append(objs, pendingObject{ref: rootRef, obj: catalog, packed: opts.ObjectStreams && rootRef.Gen == 0})
+	}
+	return updatePlan{
+		trailer:   trailer,
+		rootRef:   rootRef,
+		infoRef:   infoRef,
+		catalog:   catalog,
+		objs:      objs,
+		next:      next,
+		useStream: useStream,
+	}, nil
+}
+
+// existingRef reports whether obj is a reference to an object that exists in
+// the document and can therefore be overwritten in place.
+func existingRef(doc *pdf.Document, obj pdf.Object) (pdf.Ref, bool) {
+	ref, ok := obj.(pdf.Ref)
+	if !ok {
+		return pdf.Ref{}, false
+	}
+	if _, err := doc.Object(ref); err != nil {
+		return pdf.Ref{}, false
+	}
+	return ref, true
+}
+
+// nextTrailer carries the previous trailer (including /ID) forward and
+// points it at the catalog and Info objects. A zero info keeps the previous
+// /Info entry.
+func nextTrailer(prev pdf.Dict, root, info pdf.Ref) pdf.Dict {
+	dict := prev.Clone()
+	for _, key := range sectionOnlyTrailerKeys {
+		delete(dict, key)
+	}
+	dict["Root"] = root
+	if info != (pdf.Ref{}) {
+		dict["Info"] = info
+	}
+	return dict
+}
+
+// sectionWriter serializes the objects of a cross-reference section one at
+// a time and records where each one starts, so callers can stream the
+// pieces instead of building the whole section first.
+type sectionWriter struct {
+	seal    sealer
+	offset  int64
+	records map[int]xrefRecord
+	packed  []pendingObject
+}
+
+// xrefRecord is one row of a cross-reference stream.
+type xrefRecord struct {
+	typ    int
+	field2 int64
+	field3 int
+}
+
+// newSectionWriter starts a section whose first object is written at file
+// position offset.
+func newSectionWriter(offset int64, seal sealer) *sectionWriter {
+	return &sectionWriter{seal: seal, offset: offset, records: map[int]xrefRecord{}}
+}
+
+// object returns the serialized object. Packed objects return nothing; they
+// are held for the object stream xrefStream writes.
+func (s *sectionWriter) object(o pendingObject) ([]byte, error) {
+	if o.packed {
+		s.packed = append(s.packed, o)
+		return nil, nil
+	}
+	obj, err := s.seal(o.ref, o.ref, o.obj)
+	if err != nil {
+		return nil, err
+	}
+	out := pdf.AppendIndirectObject(nil, o.ref, obj)
+	s.records[o.ref.Num] = xrefRecord{typ: 1, field2: s.offset, field3: o.ref.Gen}
+	s.offset += int64(len(out))
+	return out, nil
+}
+
+// xrefTable ends the section with a classic xref table listing only the
+// objects written, and the trailer.
+func (s *sectionWriter) xrefTable(trailer pdf.Dict, size int) []byte {
+	offsets := make(map[int]int64, len(s.records))
+	gens := make(map[int]int, len(s.records))
+	for num, r := range s.records {
+		offsets[num] = r.field2
+		gens[num] = r.field3
+	}
+	out := renderXRef(offsets, gens)
+	return s.trailer(out, trailer, size)
+}
+
+// completeXRefTable ends the section with a classic xref table covering
+// object numbers 0..size-1, chaining unused numbers into the free list.
+func (s *sectionWriter) completeXRefTable(trailer pdf.Dict, size int) []byte {
+	var free []int
+	for num := 0; num < size; num++ {
+		if _, ok := s.records[num]; !ok {
+			free = append(free, num)
+		}
+	}
+	nextFree := make(map[int]int, len(free))
+	for i, num := range free {
+		if i+1 < len(free) {
+			nextFree[num] = free[i+1]
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("xref\n0 %d\n", size))
+	for num := 0; num < size; num++ {
+		if r, ok := s.records[num]; ok {
+			b.WriteString(fmt.Sprintf("%010d %05d n \n", r.field2, r.field3))
+			continue
+		}
+		gen := 0
+		if num == 0 {
+			gen = 65535
+		}
+		b.WriteString(fmt.Sprintf("%010d %05d f \n", nextFree[num], gen))
+	}
+	return s.trailer([]byte(b.String()), trailer, size)
+}
+
+// trailer appends the trailer dictionary and startxref for a classic table
+// written at the current offset.
+func (s *sectionWriter) trailer(out []byte, trailer pdf.Dict, size int) []byte {
+	dict := trailer.Clone()
+	dict["Size"] = pdf.Integer(size)
+	out = append(out, "trailer\n"...)
+	out = pdf.AppendObject(out, dict)
+	out = append(out, '\n')
+	return appendStartXRef(out, s.offset)
+}
+
+// xrefStream ends the section with the held packed objects in a fresh object
+// stream and a /Type /XRef stream indexing everything written. New object
+// numbers for the two streams start at next. The xref stream is never
+// encrypted; packed objects are encrypted with their object stream.
+func (s *sectionWriter) xrefStream(trailer pdf.Dict, next int) ([]byte, error) {
+	var out []byte
+	if len(s.packed) > 0 {
+		refs := make([]pdf.Ref, len(s.packed))
+		objs := make([]pdf.Object, len(s.packed))
+		for i, o := range s.packed {
+			refs[i], objs[i] = o.ref, o.obj
+		}
+		stm, err := pdf.NewObjectStream(refs, objs)
+		if err != nil {
+			return nil, &model.AppError{Code: model.ErrInternal, Message: "build object stream", Cause: err}
+		}
+		stmRef := pdf.Ref{Num: next}
+		next++
+		sealed, err := s.seal(stmRef, stmRef, stm)
+		if err != nil {
+			return nil, err
+		}
+		s.records[stmRef.Num] = xrefRecord{typ: 1, field2: s.offset}
+		out = pdf.AppendIndirectObject(out, stmRef, sealed)
+		s.offset += int64(len(out))
+		for i, ref := range refs {
+			s.records[ref.Num] = xrefRecord{typ: 2, field2: int64(stmRef.Num), field3: i}
+		}
+	}
+
+	xrefRef := pdf.Ref{Num: next}
+	xrefOffset := s.offset
+	s.records[xrefRef.Num] = xrefRecord{typ: 1, field2: xrefOffset}
+
+	nums := make([]int, 0, len(s.records))
+	var max2, max3 int64
+	for num, r := range s.records {
+		nums = append(nums, num)
+		if r.field2 > max2 {
+			max2 = r.field2
+		}
+		if int64(r.field3) > max3 {
+			max3 = int64(r.field3)
+		}
+	}
+	sort.Ints(nums)
+	w2, w3 := byteWidth(max2), byteWidth(max3)
+
+	var rows []byte
+	for _, num := range nums {
+		r := s.records[num]
+		rows = append(rows, byte(r.typ))
+		rows = appendBigEndian(rows, r.field2, w2)
+		rows = appendBigEndian(rows, int64(r.field3), w3)
+	}
+	var index pdf.Array
+	for _, run := range xrefRuns(nums) {
+		index = append(index, pdf.Integer(run[0]), pdf.Integer(run[1]))
+	}
+
+	dict := trailer.Clone()
+	dict["Type"] = pdf.Name("XRef")
+	dict["Size"] = pdf.Integer(xrefRef.Num + 1)
+	dict["W"] = pdf.Array{pdf.Integer(1), pdf.Integer(w2), pdf.Integer(w3)}
+	dict["Index"] = index
+	out = pdf.AppendIndirectObject(out, xrefRef, pdf.FlateEncode(pdf.Stream{Dict: dict, Data: rows}))
+	return appendStartXRef(out, xrefOffset), nil
+}
+
+// appendXRefTableSection writes objs followed by a classic xref table and
+// trailer. Packed flags are ignored; classic sections cannot index them.
+// Offsets are relative to base, the file position where out starts.
+func appendXRefTableSection(out []byte, base int64, seal sealer, objs []pendingObject, trailer pdf.Dict, size int) ([]byte, error) {
+	s := newSectionWriter(base+int64(len(out)), seal)
+	for _, o := range objs {
+		o.packed = false
+		piece, err := s.object(o)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, piece...)
+	}
+	return append(out, s.xrefTable(trailer, size)...), nil
+}
+
+// appendXRefStreamSection writes objs, packing the flagged ones into a fresh
+// object stream, and indexes them with a /Type /XRef stream. New object
+// numbers for the object stream and xref stream start at next. Offsets are
+// relative to base, the file position where out starts.
+func appendXRefStreamSection(out []byte, base int64, seal sealer, objs []pendingObject, trailer pdf.Dict, next int) ([]byte, error) {
+	s := newSectionWriter(base+int64(len(out)), seal)
+	for _, o := range objs {
+		piece, err := s.object(o)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, piece...)
+	}
+	end, err := s.xrefStream(trailer, next)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, end...), nil
+}
+
+func appendStartXRef(out []byte, xrefOffset int64) []byte {
+	out = append(out, "startxref\n"...)
+	out = append(out, strconv.FormatInt(xrefOffset, 10)+"\n"...)
+	return append(out, "%%EOF\n"...)
+}
+
+func renderXRef(offsets map[int]int64, gens map[int]int) []byte {
+	nums := make([]int, 0, len(offsets))
+	for num := range offsets {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	var b strings.Builder
+	b.WriteString("xref\n")
+	i := 0
+	for _, run := range xrefRuns(nums) {
+		b.WriteString(fmt.Sprintf("%d %d\n", run[0], run[1]))
+		for _, num := range nums[i : i+run[1]] {
+			b.WriteString(fmt.Sprintf("%010d %05d n \n", offsets[num], gens[num]))
+		}
+		i += run[1]
+	}
+	return []byte(b.String())
+}
+
+// xrefRuns groups sorted object numbers into [first, count] subsections.
+func xrefRuns(nums []int) [][2]int {
+	var runs [][2]int
+	for _, num := range nums {
+		if n := len(runs); n > 0 && runs[n-1][0]+runs[n-1][1] == num {
+			runs[n-1][1]++
+			continue
+		}
+		runs = append(runs, [2]int{num, 1})
+	}
+	return runs
+}
+
+func byteWidth(v int64) int {
+	w := 1
+	for v > 0xFF {
+		v >>= 8
+		w++
+	}
+	return w
+}
+
+func appendBigEndian(dst []byte, v int64, width int) []byte {
+	for i := width - 1; i >= 0; i-- {
+		dst = append(dst, byte(v>>(8*i)))
+	}
+	return dst
+}
+
+// documentVersion returns the later of the header version and the catalog
+// /Version entry.
+func documentVersion(doc *pdf.Document, catalog pdf.Dict) string {
+	v := doc.Version()
+	if cv, ok := catalog.Get("Version").(pdf.Name); ok {
+		major, minor, ok := parseVersion(v)
+		if !ok || !versionBefore(string(cv), major, minor) {
+			return string(cv)
+		}
+	}
+	return v
+}
+
+// versionBefore reports whether v ("major.minor") is older than the given
+// version. Unparsable versions count as older.
+func versionBefore(v string, major, minor int) bool {
+	gotMajor, gotMinor, ok := parseVersion(v)
+	if !ok {
+		return true
+	}
+	return gotMajor < major || (gotMajor == major && gotMinor < minor)
+}
+
+func parseVersion(v string) (int, int, bool) {
+	majorStr, minorStr, ok := strings.Cut(v, ".")
+	if !ok {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(majorStr)
+	minor, err2 := strconv.Atoi(minorStr)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,253 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+func writeInPlace(t *testing.T, path string, opts model.WriteOptions, patch model.MetadataPatch) []byte {
+	t.Helper()
+	if _, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Options:   opts,
+		Set:       patch,
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	return b
+}
+
+func openTrailer(t *testing.T, path string) (*pdf.Document, pdf.Dict) {
+	t.Helper()
+	doc, err := pdf.Open(path)
+	if err != nil {
+		t.Fatalf("pdf.Open: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("xref chain broken after write: %v", err)
+	}
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	return doc, trailer
+}
+
+func TestIncrementalWriteStreamsOriginalBytes(t *testing.T) {
+	orig, err := os.ReadFile(fixturePath("incremental-updates.pdf"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	// Without a final EOL the update must start on a new line, and every
+	// offset in it must account for that extra byte.
+	orig = bytes.TrimRight(orig, "\r\n")
+	path := writeTempPDF(t, "no-eol.pdf", string(orig))
+
+	title := "Streamed"
+	out := writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+	if !bytes.HasPrefix(out, orig) || out[len(orig)] != '\n' {
+		t.Fatalf("output does not start with the original bytes followed by EOL")
+	}
+	openTrailer(t, path)
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != "Second Author" {
+		t.Fatalf("unexpected metadata after write: %#v", res.Metadata)
+	}
+}
+
+func TestIncrementalWriteMatchesXRefStreamStyle(t *testing.T) {
+	path := copyFixture(t, "xref-stream.pdf")
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	title := "Stream Style"
+	out := writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+	if bytes.Contains(out[len(orig):], []byte("trailer")) {
+		t.Fatalf("expected an xref stream section, got a classic trailer")
+	}
+
+	doc, trailer := openTrailer(t, path)
+	if !doc.UsesXRefStreams() {
+		t.Fatalf("expected newest section to be an xref stream")
+	}
+	id := pdf.Array{pdf.String{Bytes: []byte{0x0A, 0x0B, 0x0C}, Hex: true}, pdf.String{Bytes: []byte{0x0A, 0x0B, 0x0C}, Hex: true}}
+	if !reflect.DeepEqual(trailer.Get("ID"), id) {
+		t.Fatalf("/ID=%#v want %#v", trailer.Get("ID"), id)
+	}
+	if _, ok := trailer.Int("Prev"); !ok {
+		t.Fatalf("expected /Prev in new xref stream")
+	}
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || res.Metadata.Producer != "fixture" {
+		t.Fatalf("unexpected metadata after write: %#v", res.Metadata)
+	}
+}
+
+func TestIncrementalWriteCarriesTrailerKeys(t *testing.T) {
+	path := writeTempPDF(t, "trailer-keys.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog >>\nendobj\n"+
+		"xref\n0 2\n0000000000 65535 f \n0000000009 00000 n \n"+
+		"trailer\n<< /Size 2 /Root 1 0 R /ID [<AA> <BB>] /Custom (kept) >>\nstartxref\n45\n%%EOF\n")
+
+	title := "Classic"
+	out := writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+	if !bytes.Contains(out, []byte("trailer\n")) {
+		t.Fatalf("expected a classic trailer")
+	}
+
+	doc, trailer := openTrailer(t, path)
+	if doc.UsesXRefStreams() {
+		t.Fatalf("classic source must keep a classic xref table")
+	}
+	if got := string(trailer.Get("Custom").(pdf.String).Bytes); got != "kept" {
+		t.Fatalf("/Custom=%q want kept", got)
+	}
+	if id, ok := trailer.Get("ID").(pdf.Array); !ok || len(id) != 2 {
+		t.Fatalf("/ID not carried over: %#v", trailer.Get("ID"))
+	}
+	if prev, _ := trailer.Int("Prev"); prev != 45 {
+		t.Fatalf("/Prev=%d want 45", prev)
+	}
+}
+
+func TestIncrementalWriteObjectStreams(t *testing.T) {
+	path := copyFixture(t, "incremental-updates.pdf")
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	title := "Packed Title"
+	out := writeInPlace(t, path, model.WriteOptions{ObjectStreams: true}, model.MetadataPatch{Title: &title})
+
+	doc, trailer := openTrailer(t, path)
+	if !doc.UsesXRefStreams() {
+		t.Fatalf("object streams require an xref stream section")
+	}
+	infoRef, _ := trailer.Ref("Info")
+	added := out[len(orig):]
+	if !bytes.Contains(added, []byte("/Type /ObjStm")) || bytes.Contains(added, []byte(fmt.Sprintf("\n%d 0 obj", infoRef.Num))) {
+		t.Fatalf("expected Info dictionary to be packed into an object stream")
+	}
+	root, err := doc.Resolve(trailer.Get("Root"))
+	if err != nil {
+		t.Fatalf("Resolve(Root): %v", err)
+	}
+	if v := root.(pdf.Dict).Get("Version"); v != pdf.Name("1.5") {
+		t.Fatalf("catalog /Version=%#v want /1.5", v)
+	}
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != "Second Author" || !res.XMPFound {
+		t.Fatalf("unexpected metadata after packed write: %#v", res)
+	}
+}
+
+func TestIncrementalWriteReusesObjectNumbers(t *testing.T) {
+	path := copyFixture(t, "incremental-updates.pdf")
+
+	for i, title := range []string{"Edit 1", "Edit 2", "Edit 3"} {
+		title := title
+		out := writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+		doc, trailer := openTrailer(t, path)
+
+		// Info (3) is overwritten in place; Metadata is allocated once as 4.
+		if info, _ := trailer.Ref("Info"); info != (pdf.Ref{Num: 3}) {
+			t.Fatalf("write %d: /Info=%v want 3 0 R", i, info)
+		}
+		if got := doc.MaxObjectNumber(); got != 4 {
+			t.Fatalf("write %d: MaxObjectNumber()=%d want 4", i, got)
+		}
+		if size, _ := trailer.Int("Size"); size != 5 {
+			t.Fatalf("write %d: /Size=%d want 5", i, size)
+		}
+		// The catalog only needs rewriting when /Metadata is first added.
+		catalogWrites := bytes.Count(out, []byte("\n1 0 obj"))
+		if want := 2; catalogWrites != want {
+			t.Fatalf("write %d: catalog written %d times want %d", i, catalogWrites, want)
+		}
+	}
+}
+
+func TestIncrementalWriteKeepsGeneration(t *testing.T) {
+	objs := []string{
+		"1 0 obj\n<< /Type /Catalog /Metadata 4 3 R >>\nendobj\n",
+		"2 5 obj\n<< /Title (old) >>\nendobj\n",
+		"4 3 obj\n<< /Type /Metadata /Subtype /XML /Length 0 >>\nstream\n\nendstream\nendobj\n",
+	}
+	body := "%PDF-1.4\n"
+	var offsets []int
+	for _, o := range objs {
+		offsets = append(offsets, len(body))
+		body += o
+	}
+	xref := fmt.Sprintf("xref\n0 3\n0000000000 65535 f \n%010d 00000 n \n%010d 00005 n \n4 1\n%010d 00003 n \n", offsets[0], offsets[1], offsets[2])
+	path := writeTempPDF(t, "gen.pdf", body+xref+fmt.Sprintf("trailer\n<< /Size 5 /Root 1 0 R /Info 2 5 R >>\nstartxref\n%d\n%%%%EOF\n", len(body)))
+
+	title := "new"
+	out := writeInPlace(t, path, model.WriteOptions{ObjectStreams: true}, model.MetadataPatch{Title: &title})
+	added := out[len(body)+len(xref):]
+	// Objects with a non-zero generation cannot live in an object stream.
+	if !bytes.Contains(added, []byte("2 5 obj")) || !bytes.Contains(added, []byte("4 3 obj")) {
+		t.Fatalf("expected Info and Metadata to be rewritten with their generations:\n%s", added)
+	}
+
+	_, trailer := openTrailer(t, path)
+	if info, _ := trailer.Ref("Info"); info != (pdf.Ref{Num: 2, Gen: 5}) {
+		t.Fatalf("/Info=%v want 2 5 R", info)
+	}
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || !res.XMPFound {
+		t.Fatalf("unexpected metadata after write: %#v", res)
+	}
+}
+
+func TestXRefRuns(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   []int
+		want [][2]int
+	}{
+		{name: "empty", in: nil, want: nil},
+		{name: "contiguous", in: []int{4, 5, 6}, want: [][2]int{{4, 3}}},
+		{name: "gaps", in: []int{1, 3, 4, 9}, want: [][2]int{{1, 1}, {3, 2}, {9, 1}}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := xrefRuns(tc.in); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("xrefRuns(%v)=%v want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,586 @@
+package metadata
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// linearLayout assigns every live object to one part of a linearized file
+// (ISO 32000-1, Annex F). pages[0] is the whole first-page section with the
+// page object first; later entries hold a page object and the objects only
+// that page uses. Objects used by several later pages are shared.
+type linearLayout struct {
+	catalog    pdf.Ref
+	pages      [][]pdf.Ref
+	sharedRefs [][]pdf.Ref
+	shared     []pdf.Ref
+	other      []pdf.Ref
+}
+
+// writeNativeLinearized writes the updated document as a single-revision
+// linearized file. Objects are renumbered so that the first-page section
+// can be indexed by its own cross-reference table at the front of the file.
+// Linearized files use classic cross-reference tables, so object streams
+// are refused rather than dropped.
+func writeNativeLinearized(doc *pdf.Document, meta model.Metadata, xmpPacket []byte, opts model.WriteOptions) (io.Reader, error) {
+	if opts.ObjectStreams {
+		return nil, &model.AppError{Code: model.ErrValidation, Message: "--object-streams cannot be combined with --linearized relinearize; linearized files use classic xref tables"}
+	}
+	plan, err := planMetadataUpdate(doc, meta, xmpPacket, opts)
+	if err != nil {
+		return nil, err
+	}
+	trailer := nextTrailer(plan.trailer, plan.rootRef, plan.infoRef)
+
+	overlay := make(map[pdf.Ref]pdf.Object, len(plan.objs))
+	for _, o := range plan.objs {
+		overlay[o.ref] = o.obj
+	}
+	load := func(ref pdf.Ref) (pdf.Object, error) {
+		if obj, ok := overlay[ref]; ok {
+			return obj, nil
+		}
+		obj, err := doc.Object(ref)
+		if err != nil {
+			return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: fmt.Sprintf("read object %s", ref), Cause: err}
+		}
+		return obj, nil
+	}
+	live := reachableObjects(doc, trailer, overlay)
+	layout, err := planLinearLayout(live, plan.rootRef)
+	if err != nil {
+		return nil, err
+	}
+	header := "%PDF-" + documentVersion(doc, plan.catalog) + "\n%\xe2\xe3\xcf\xd3\n"
+	return layout.serialize(header, live, load, trailer, documentSealer(doc))
+}
+
+// planLinearLayout walks the page tree and sorts objects into the first-page
+// section, per-page sections, shared objects and everything else.
+func planLinearLayout(live map[pdf.Ref]pdf.Object, root pdf.Ref) (linearLayout, error) {
+	catalog, ok := live[root].(pdf.Dict)
+	if !ok {
+		return linearLayout{}, &model.AppError{Code: model.ErrPDFMalformed, Message: "catalog dictionary missing"}
+	}
+	pages, treeNodes := pageTree(live, catalog.Get("Pages"))
+	if len(pages) == 0 {
+		return linearLayout{}, &model.AppError{Code: model.ErrValidation, Message: "cannot linearize a document without pages"}
+	}
+	treeNodes[root] = true
+
+	closures := make([][]pdf.Ref, len(pages))
+	users := map[pdf.Ref]int{}
+	for i, page := range pages {
+		closures[i] = pageClosure(live, page, treeNodes)
+		for _, ref := range closures[i] {
+			users[ref]++
+		}
+	}
+
+	layout := linearLayout{catalog: root}
+	placed := map[pdf.Ref]bool{root: true}
+	layout.pages = append(layout.pages, closures[0])
+	layout.sharedRefs = append(layout.sharedRefs, nil)
+	for _, ref := range closures[0] {
+		placed[ref] = true
+	}
+	var sharedSet = map[pdf.Ref]bool{}
+	for i := 1; i < len(pages); i++ {
+		var own, refs []pdf.Ref
+		for _, ref := range closures[i] {
+			switch {
+			case placed[ref] || users[ref] > 1:
+				refs = append(refs, ref)
+				if !placed[ref] && !sharedSet[ref] {
+					sharedSet[ref] = true
+					layout.shared = append(layout.shared, ref)
+				}
+			default:
+				own = append(own, ref)
+			}
+		}
+		for _, ref := range own {
+			placed[ref] = true
+		}
+		layout.pages = append(layout.pages, own)
+		layout.sharedRefs = append(layout.sharedRefs, refs)
+	}
+	for _, ref := range layout.shared {
+		placed[ref] = true
+	}
+
+	for ref := range live {
+		if !placed[ref] {
+			layout.other = append(layout.other, ref)
+		}
+	}
+	sort.Slice(layout.other, func(i, j int) bool { return layout.other[i].Num < layout.other[j].Num })
+	return layout, nil
+}
+
+// pageTree returns the leaf pages in document order and the set of every
+// page tree node visited, leaves included.
+func pageTree(live map[pdf.Ref]pdf.Object, pagesRoot pdf.Object) ([]pdf.Ref, map[pdf.Ref]bool) {
+	var pages []pdf.Ref
+	nodes := map[pdf.Ref]bool{}
+	var walk func(obj pdf.Object)
+	walk = func(obj pdf.Object) {
+		ref, ok := obj.(pdf.Ref)
+		if !ok || nodes[ref] {
+			return
+		}
+		node, ok := live[ref].(pdf.Dict)
+		if !ok {
+			return
+		}
+		nodes[ref] = true
+		kids := node.Get("Kids")
+		if kidsRef, ok := kids.(pdf.Ref); ok {
+			kids = live[kidsRef]
+		}
+		if arr, ok := kids.(pdf.Array); ok && node.TypeName() != "Page" {
+			for _, kid := range arr {
+				walk(kid)
+			}
+			return
+		}
+		pages = append(pages, ref)
+	}
+	walk(pagesRoot)
+	return pages, nodes
+}
+
+// pageClosure lists page followed by every object reachable from it without
+// passing through another page tree node (such as /Parent or an annotation's
+// /P), in depth-first order.
+func pageClosure(live map[pdf.Ref]pdf.Object, page pdf.Ref, treeNodes map[pdf.Ref]bool) []pdf.Ref {
+	out := []pdf.Ref{page}
+	seen := map[pdf.Ref]bool{page: true}
+	var visit func(obj pdf.Object)
+	visit = func(obj pdf.Object) {
+		switch v := obj.(type) {
+		case pdf.Ref:
+			if seen[v] || treeNodes[v] {
+				return
+			}
+			target, ok := live[v]
+			if !ok {
+				return
+			}
+			seen[v] = true
+			out = append(out, v)
+			visit(target)
+		case pdf.Array:
+			for _, e := range v {
+				visit(e)
+			}
+		case pdf.Dict:
+			for _, k := range v.Keys() {
+				visit(v[k])
+			}
+		case pdf.Stream:
+			for _, k := range v.Dict.Keys() {
+				if k != "Length" {
+					visit(v.Dict[k])
+				}
+			}
+		}
+	}
+	visit(live[page])
+	return out
+}
+
+// serialize lays the file out in Annex F order: linearization dictionary,
+// first-page xref and trailer, catalog, primary hint stream, first-page
+// section, remaining pages, shared objects, other objects, main xref.
+// Fixed-width numbers in the first two parts let every offset be computed
+// before anything is written. Encrypted objects are re-encrypted under their
+// new numbers.
+//
+// The remaining pages, shared and other objects are serialized twice: once
+// to measure them for the offsets, and again, one at a time, while the
+// output is read. Only the front of the file up to the end of the first
+// page is held in memory.
+func (l linearLayout) serialize(header string, live map[pdf.Ref]pdf.Object, load func(pdf.Ref) (pdf.Object, error), trailer pdf.Dict, seal sealer) (io.Reader, error) {
+	var main []pdf.Ref
+	for _, own := range l.pages[1:] {
+		main = append(main, own...)
+	}
+	main = append(main, l.shared...)
+	main = append(main, l.other...)
+
+	renum := map[pdf.Ref]pdf.Ref{}
+	for i, ref := range main {
+		renum[ref] = pdf.Ref{Num: i + 1}
+	}
+	mainSize := len(main) + 1
+	linRef := pdf.Ref{Num: mainSize}
+	renum[l.catalog] = pdf.Ref{Num: mainSize + 1}
+	hintRef := pdf.Ref{Num: mainSize + 2}
+	for i, ref := range l.pages[0] {
+		renum[ref] = pdf.Ref{Num: mainSize + 3 + i}
+	}
+	size := mainSize + 3 + len(l.pages[0])
+
+	body := func(ref pdf.Ref) ([]byte, error) {
+		obj, err := load(ref)
+		if err != nil {
+			return nil, err
+		}
+		if obj, err = seal(ref, renum[ref], renumberRefs(obj, renum)); err != nil {
+			return nil, err
+		}
+		return pdf.AppendIndirectObject(nil, renum[ref], obj), nil
+	}
+	catalog, err := body(l.catalog)
+	if err != nil {
+		return nil, err
+	}
+	firstPage := make([][]byte, len(l.pages[0]))
+	for i, ref := range l.pages[0] {
+		if firstPage[i], err = body(ref); err != nil {
+			return nil, err
+		}
+	}
+
+	first := renumberRefs(trailer, renum).(pdf.Dict)
+	first["Size"] = pdf.Integer(size)
+	firstCount := size - mainSize
+
+	// Offsets up to the hint stream do not depend on any value still unknown.
+	linLen := len(linearizationDict(linRef, 0, 0, 0, 0, 0, 0, 0))
+	firstXRefOff := len(header) + linLen
+	firstXRefLen := len(firstPageXRef(mainSize, make([]int, firstCount), first, 0))
+	catalogOff := firstXRefOff + firstXRefLen
+	hintOff := catalogOff + len(catalog)
+
+	// Hint tables record offsets as if the hint stream were absent.
+	offsets := map[pdf.Ref]int{}
+	lengths := map[pdf.Ref]int{}
+	pos := hintOff
+	for i, ref := range l.pages[0] {
+		offsets[ref], lengths[ref] = pos, len(firstPage[i])
+		pos += len(firstPage[i])
+	}
+	endFirstPage := pos
+	for _, ref := range main {
+		b, err := body(ref)
+		if err != nil {
+			return nil, err
+		}
+		offsets[ref], lengths[ref] = pos, len(b)
+		pos += len(b)
+	}
+	hintStream, err := seal(pdf.Ref{}, hintRef, l.hintStream(live, offsets, lengths, endFirstPage, renum))
+	if err != nil {
+		return nil, err
+	}
+	hint := pdf.AppendIndirectObject(nil, hintRef, hintStream)
+	shift := len(hint)
+
+	mainXRefOff := pos + shift
+	mainXRef := fmt.Sprintf("xref\n0 %d\n", mainSize)
+	firstEntry := mainXRefOff + len(mainXRef) - 1
+	entries := make([]int, mainSize)
+	for _, ref := range main {
+		entries[renum[ref].Num] = offsets[ref] + shift
+	}
+	var tail bytes.Buffer
+	tail.WriteString(mainXRef)
+	for num, off := range entries {
+		if num == 0 {
+			tail.WriteString("0000000000 65535 f \n")
+			continue
+		}
+		fmt.Fprintf(&tail, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&tail, "trailer\n<< /Size %d >>\nstartxref\n%d\n%%%%EOF\n", mainSize, firstXRefOff)
+	fileLen := mainXRefOff + tail.Len()
+
+	firstOffsets := make([]int, firstCount)
+	firstOffsets[0] = len(header)
+	firstOffsets[1] = catalogOff
+	firstOffsets[2] = hintOff
+	for i, ref := range l.pages[0] {
+		firstOffsets[3+i] = offsets[ref] + shift
+	}
+
+	front := make([]byte, 0, endFirstPage+shift)
+	front = append(front, header...)
+	front = append(front, linearizationDict(linRef, fileLen, hintOff, len(hint), renum[l.pages[0][0]].Num, endFirstPage+shift, len(l.pages), firstEntry)...)
+	front = append(front, firstPageXRef(mainSize, firstOffsets, first, mainXRefOff)...)
+	front = append(front, catalog...)
+	front = append(front, hint...)
+	for _, b := range firstPage {
+		front = append(front, b...)
+	}
+
+	i := -1
+	return &pieceReader{next: func() ([]byte, error) {
+		switch {
+		case i < 0:
+			i++
+			return front, nil
+		case i < len(main):
+			ref := main[i]
+			i++
+			b, err := body(ref)
+			if err != nil {
+				return nil, err
+			}
+			// Every offset written so far assumes the measured length.
+			if len(b) != lengths[ref] {
+				return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: fmt.Sprintf("object %s changed length while writing", ref)}
+			}
+			return b, nil
+		case i == len(main):
+			i++
+			return tail.Bytes(), nil
+		default:
+			return nil, io.EOF
+		}
+	}}, nil
+}
+
+// linearizationDict renders the parameter dictionary with fixed-width values
+// so its length is known before the values are.
+func linearizationDict(ref pdf.Ref, fileLen, hintOff, hintLen, firstPage, endFirstPage, pages, mainXRefEntry int) []byte {
+	return fmt.Appendf(nil, "%d 0 obj\n<< /Linearized 1 /L %010d /H [%010d %010d] /O %010d /E %010d /N %010d /T %010d >>\nendobj\n",
+		ref.Num, fileLen, hintOff, hintLen, firstPage, endFirstPage, pages, mainXRefEntry)
+}
+
+// firstPageXRef renders the cross-reference section for objects first..,
+// whose trailer links the main section through a fixed-width /Prev.
+func firstPageXRef(first int, offsets []int, trailer pdf.Dict, prev int) []byte {
+	out := fmt.Appendf(nil, "xref\n%d %d\n", first, len(offsets))
+	for _, off := range offsets {
+		out = fmt.Appendf(out, "%010d 00000 n \n", off)
+	}
+	dict := pdf.AppendObject(nil, trailer)
+	dict = dict[:len(dict)-len(" >>")]
+	out = append(out, "trailer\n"...)
+	out = append(out, dict...)
+	out = fmt.Appendf(out, " /Prev %010d >>\nstartxref\n0\n%%%%EOF\n", prev)
+	return out
+}
+
+// hintStream builds the primary hint stream: the page offset hint table
+// followed by the shared object hint table (Annex F.4). Every shared object
+// forms its own group. A page's content stream entry locates its /Contents
+// stream when that is a single stream written with the page; otherwise it
+// spans the whole page, as Acrobat writes it.
+func (l linearLayout) hintStream(live map[pdf.Ref]pdf.Object, offsets, lengths map[pdf.Ref]int, endFirstPage int, renum map[pdf.Ref]pdf.Ref) pdf.Stream {
+	sharedIndex := map[pdf.Ref]int{}
+	var sharedEntries []pdf.Ref
+	for _, ref := range l.pages[0] {
+		sharedIndex[ref] = len(sharedEntries)
+		sharedEntries = append(sharedEntries, ref)
+	}
+	for _, ref := range l.shared {
+		sharedIndex[ref] = len(sharedEntries)
+		sharedEntries = append(sharedEntries, ref)
+	}
+
+	n := len(l.pages)
+	nobjs := make([]int, n)
+	pageLen := make([]int, n)
+	for i, own := range l.pages {
+		nobjs[i] = len(own)
+		if i == 0 {
+			pageLen[i] = endFirstPage - offsets[own[0]]
+			continue
+		}
+		for _, ref := range own {
+			pageLen[i] += lengths[ref]
+		}
+	}
+	contentOff := make([]int, n)
+	contentLen := make([]int, n)
+	for i, own := range l.pages {
+		start := offsets[own[0]]
+		contentLen[i] = pageLen[i]
+		page, _ := live[own[0]].(pdf.Dict)
+		ref, ok := page.Get("Contents").(pdf.Ref)
+		if _, stream := live[ref].(pdf.Stream); !ok || !stream {
+			continue
+		}
+		if off, ok := offsets[ref]; ok && off >= start && off+lengths[ref] <= start+pageLen[i] {
+			contentOff[i], contentLen[i] = off-start, lengths[ref]
+		}
+	}
+	minObjs, maxObjs := minMax(nobjs)
+	minLen, maxLen := minMax(pageLen)
+	minContentOff, maxContentOff := minMax(contentOff)
+	minContentLen, maxContentLen := minMax(contentLen)
+	maxShared, maxID := 0, 0
+	for _, refs := range l.sharedRefs {
+		if len(refs) > maxShared {
+			maxShared = len(refs)
+		}
+		for _, ref := range refs {
+			if sharedIndex[ref] > maxID {
+				maxID = sharedIndex[ref]
+			}
+		}
+	}
+	objBits, lenBits := bitsFor(maxObjs-minObjs), bitsFor(maxLen-minLen)
+	contentOffBits, contentLenBits := bitsFor(maxContentOff-minContentOff), bitsFor(maxContentLen-minContentLen)
+	sharedBits, idBits := bitsFor(maxShared), bitsFor(maxID)
+
+	var w bitWriter
+	w.write(minObjs, 32)
+	w.write(offsets[l.pages[0][0]], 32)
+	w.write(objBits, 16)
+	w.write(minLen, 32)
+	w.write(lenBits, 16)
+	w.write(minContentOff, 32)
+	w.write(contentOffBits, 16)
+	w.write(minContentLen, 32)
+	w.write(contentLenBits, 16)
+	w.write(sharedBits, 16)
+	w.write(idBits, 16)
+	w.write(0, 16) // numerator bits
+	w.write(1, 16) // denominator
+	w.flush()
+	for i := range l.pages {
+		w.write(nobjs[i]-minObjs, objBits)
+	}
+	w.flush()
+	for i := range l.pages {
+		w.write(pageLen[i]-minLen, lenBits)
+	}
+	w.flush()
+	for _, refs := range l.sharedRefs {
+		w.write(len(refs), sharedBits)
+	}
+	w.flush()
+	for _, refs := range l.sharedRefs {
+		for _, ref := range refs {
+			w.write(sharedIndex[ref], idBits)
+		}
+	}
+	w.flush()
+	// Numerators take zero bits.
+	for i := range l.pages {
+		w.write(contentOff[i]-minContentOff, contentOffBits)
+	}
+	w.flush()
+	for i := range l.pages {
+		w.write(contentLen[i]-minContentLen, contentLenBits)
+	}
+	w.flush()
+	sharedTable := len(w.buf)
+
+	groupLen := make([]int, len(sharedEntries))
+	for i, ref := range sharedEntries {
+		groupLen[i] = lengths[ref]
+	}
+	minGroup, maxGroup := minMax(groupLen)
+	groupBits := bitsFor(maxGroup - minGroup)
+	firstShared, firstSharedOff := 0, 0
+	if len(l.shared) > 0 {
+		firstShared, firstSharedOff = renum[l.shared[0]].Num, offsets[l.shared[0]]
+	}
+	w.write(firstShared, 32)
+	w.write(firstSharedOff, 32)
+	w.write(len(l.pages[0]), 32)
+	w.write(len(sharedEntries), 32)
+	w.write(0, 16) // one object per group
+	w.write(minGroup, 32)
+	w.write(groupBits, 16)
+	w.flush()
+	for _, length := range groupLen {
+		w.write(length-minGroup, groupBits)
+	}
+	w.flush()
+	for range groupLen {
+		w.write(0, 1) // no MD5 signatures
+	}
+	w.flush()
+
+	return pdf.Stream{Dict: pdf.Dict{"S": pdf.Integer(sharedTable)}, Data: w.buf}
+}
+
+// renumberRefs returns a copy of obj with references mapped through renum.
+// References to objects that are not written become null.
+func renumberRefs(obj pdf.Object, renum map[pdf.Ref]pdf.Ref) pdf.Object {
+	switch v := obj.(type) {
+	case pdf.Ref:
+		if to, ok := renum[v]; ok {
+			return to
+		}
+		return pdf.Null{}
+	case pdf.Array:
+		out := make(pdf.Array, len(v))
+		for i, e := range v {
+			out[i] = renumberRefs(e, renum)
+		}
+		return out
+	case pdf.Dict:
+		out := make(pdf.Dict, len(v))
+		for k, e := range v {
+			out[k] = renumberRefs(e, renum)
+		}
+		return out
+	case pdf.Stream:
+		return pdf.Stream{Dict: renumberRefs(v.Dict, renum).(pdf.Dict), Data: v.Data}
+	}
+	return obj
+}
+
+// bitWriter packs big-endian bit fields, as hint tables require.
+type bitWriter struct {
+	buf  []byte
+	cur  byte
+	used uint
+}
+
+func (w *bitWriter) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		w.cur = w.cur<<1 | byte((v>>uint(i))&1)
+		w.used++
+		if w.used == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.used = 0, 0
+		}
+	}
+}
+
+// flush pads the current byte with zero bits.
+func (w *bitWriter) flush() {
+	if w.used > 0 {
+		w.buf = append(w.buf, w.cur<<(8-w.used))
+		w.cur, w.used = 0, 0
+	}
+}
+
+// bitsFor returns the number of bits needed to represent v.
+func bitsFor(v int) int {
+	n := 0
+	for v > 0 {
+		v >>= 1
+		n++
+	}
+	return n
+}
+
+func minMax(vs []int) (int, int) {
+	if len(vs) == 0 {
+		return 0, 0
+	}
+	lo, hi := vs[0], vs[0]
+	for _, v := range vs[1:] {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	return lo, hi
+}
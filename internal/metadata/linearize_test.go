@@ -0,0 +1,536 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+	"pdfmeta/internal/xmp"
+)
+
+func TestWriteLinearizedWarnsByDefault(t *testing.T) {
+	path := copyFixture(t, "linearized.pdf")
+
+	title := "Appended"
+	res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{InputPath: path, InPlace: true, Set: model.MetadataPatch{Title: &title}})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(res.Warnings) != 1 || res.Linearized {
+		t.Fatalf("expected a linearization warning, got %#v", res)
+	}
+	doc, _ := openTrailer(t, path)
+	if doc.Linearized() {
+		t.Fatalf("appended update must not report the file as linearized")
+	}
+
+	// The linearization is already stale, so further writes do not warn again.
+	res, err = NewStore().Write(context.Background(), model.MetadataWriteRequest{InputPath: path, InPlace: true, Set: model.MetadataPatch{Title: &title}})
+	if err != nil || len(res.Warnings) != 0 {
+		t.Fatalf("second Write: warnings=%v err=%v", res.Warnings, err)
+	}
+}
+
+func TestWriteLinearizedRefuse(t *testing.T) {
+	path := copyFixture(t, "linearized.pdf")
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	title := "Refused"
+	_, err = NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Options:   model.WriteOptions{Linearized: model.LinearizedRefuse},
+		Set:       model.MetadataPatch{Title: &title},
+	})
+	assertAppErrorCode(t, err, model.ErrConflict)
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, orig) {
+		t.Fatalf("refused write modified the file")
+	}
+}
+
+func TestWriteRelinearized(t *testing.T) {
+	path := copyFixture(t, "linearized.pdf")
+
+	title := "Fast Web View"
+	res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Options:   model.WriteOptions{Linearized: model.LinearizedRelinearize},
+		Set:       model.MetadataPatch{Title: &title},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !res.Linearized || len(res.Warnings) != 0 {
+		t.Fatalf("unexpected write result: %#v", res)
+	}
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	assertLinearizedLayout(t, out)
+
+	read, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !read.Linearized || read.Metadata.Title != title || read.Metadata.Value(model.FieldAuthor) != "Web Team" || !read.XMPFound {
+		t.Fatalf("unexpected metadata after relinearize: %#v", read)
+	}
+}
+
+func TestWriteRelinearizedRefusesObjectStreams(t *testing.T) {
+	path := copyFixture(t, "linearized.pdf")
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+
+	title := "Packed"
+	_, err = NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Options:   model.WriteOptions{Linearized: model.LinearizedRelinearize, ObjectStreams: true},
+		Set:       model.MetadataPatch{Title: &title},
+	})
+	assertAppErrorCode(t, err, model.ErrValidation)
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, orig) {
+		t.Fatalf("refused write modified the file")
+	}
+}
+
+// TestRelinearizeStreamsOneObjectAtATime checks that only the front of the
+// file, up to the end of the first page, is rendered in one piece; every
+// later object is serialized as it is read.
+func TestRelinearizeStreamsOneObjectAtATime(t *testing.T) {
+	doc, _ := openTrailer(t, copyFixture(t, "linearized.pdf"))
+	defer doc.Close()
+	packet, err := xmp.Marshal(model.Metadata{Title: "Pieces"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	r, err := writeNativeLinearized(doc, model.Metadata{Title: "Pieces"}, packet, model.WriteOptions{})
+	if err != nil {
+		t.Fatalf("writeNativeLinearized: %v", err)
+	}
+
+	var pieces [][]byte
+	for {
+		piece, err := r.(*pieceReader).next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		pieces = append(pieces, piece)
+	}
+	if len(pieces) < 3 || !bytes.HasPrefix(pieces[len(pieces)-1], []byte("xref\n")) {
+		t.Fatalf("unexpected pieces: %q", pieces)
+	}
+	out := bytes.Join(pieces, nil)
+	assertLinearizedLayout(t, out)
+	lin, err := pdf.ParseObject(out[bytes.Index(out, []byte("<<")):])
+	if err != nil {
+		t.Fatalf("parse linearization dictionary: %v", err)
+	}
+	if end, _ := lin.(pdf.Dict).Int("E"); int(end) != len(pieces[0]) {
+		t.Fatalf("front piece is %d bytes, first page ends at %d", len(pieces[0]), end)
+	}
+	for _, piece := range pieces[1 : len(pieces)-1] {
+		if bytes.Count(piece, []byte("endobj")) != 1 {
+			t.Fatalf("piece is not a single object: %q", piece)
+		}
+	}
+}
+
+// assertLinearizedLayout checks the parameter dictionary and both
+// cross-reference sections against the bytes actually written.
+func assertLinearizedLayout(t *testing.T, out []byte) {
+	t.Helper()
+	doc, err := pdf.ParseBytes("out.pdf", out)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+	if n := len(doc.Revisions()); n != 1 {
+		t.Fatalf("Revisions()=%d want 1", n)
+	}
+	lin, ok := doc.Linearization()
+	if !ok || lin.Length != int64(len(out)) {
+		t.Fatalf("Linearization()=%+v (ok=%v) for %d bytes", lin, ok, len(out))
+	}
+	page, err := doc.Object(pdf.Ref{Num: lin.FirstPage})
+	if err != nil || page.(pdf.Dict).TypeName() != "Page" {
+		t.Fatalf("/O %d is not a page: %#v err=%v", lin.FirstPage, page, err)
+	}
+
+	linObj, err := pdf.ParseObject(out[bytes.Index(out, []byte("<<")):])
+	if err != nil {
+		t.Fatalf("parse linearization dictionary: %v", err)
+	}
+	dict := linObj.(pdf.Dict)
+	hint := dict.Get("H").(pdf.Array)
+	hintOff, hintLen := int(hint[0].(pdf.Integer)), int(hint[1].(pdf.Integer))
+	hintObj := out[hintOff : hintOff+hintLen]
+	if !bytes.Contains(hintObj[:20], []byte(" 0 obj\n<< /Length")) || !bytes.Contains(hintObj, []byte("/S ")) || !bytes.HasSuffix(hintObj, []byte("endobj\n")) {
+		t.Fatalf("/H does not cover the hint stream object: %q", hintObj)
+	}
+	end, _ := dict.Int("E")
+	if !bytes.HasPrefix(out[end:], []byte("1 0 obj")) {
+		t.Fatalf("/E=%d should end the first-page section: %q", end, out[end:end+10])
+	}
+	mainEntry, _ := dict.Int("T")
+	if !bytes.HasPrefix(out[mainEntry:], []byte("\n0000000000 65535 f")) {
+		t.Fatalf("/T=%d does not precede the main xref entries", mainEntry)
+	}
+
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	prev, _ := trailer.Int("Prev")
+	if !bytes.HasPrefix(out[prev:], []byte("xref\n0 ")) {
+		t.Fatalf("first-page /Prev=%d does not point at the main xref", prev)
+	}
+	if _, ok := trailer.Get("ID").(pdf.Array); !ok {
+		t.Fatalf("/ID not carried into the first-page trailer")
+	}
+}
+
+func TestPlanLinearLayoutSharedObjects(t *testing.T) {
+	t.Parallel()
+
+	ref := func(n int) pdf.Ref { return pdf.Ref{Num: n} }
+	page := func(font ...pdf.Object) pdf.Dict {
+		return pdf.Dict{"Type": pdf.Name("Page"), "Parent": ref(2), "Resources": pdf.Array(font)}
+	}
+	live := map[pdf.Ref]pdf.Object{
+		ref(1):  pdf.Dict{"Type": pdf.Name("Catalog"), "Pages": ref(2)},
+		ref(2):  pdf.Dict{"Type": pdf.Name("Pages"), "Kids": pdf.Array{ref(3), ref(4), ref(5)}},
+		ref(3):  page(ref(10)),
+		ref(4):  page(ref(10), ref(11), ref(12)),
+		ref(5):  page(ref(11)),
+		ref(10): pdf.Name("first page font"),
+		ref(11): pdf.Name("shared by later pages"),
+		ref(12): pdf.Name("private to page 2"),
+		ref(20): pdf.Name("info"),
+	}
+	layout, err := planLinearLayout(live, ref(1))
+	if err != nil {
+		t.Fatalf("planLinearLayout: %v", err)
+	}
+	want := linearLayout{
+		catalog:    ref(1),
+		pages:      [][]pdf.Ref{{ref(3), ref(10)}, {ref(4), ref(12)}, {ref(5)}},
+		sharedRefs: [][]pdf.Ref{nil, {ref(10), ref(11)}, {ref(11)}},
+		shared:     []pdf.Ref{ref(11)},
+		other:      []pdf.Ref{ref(2), ref(20)},
+	}
+	if !reflect.DeepEqual(layout, want) {
+		t.Fatalf("planLinearLayout()=%+v\nwant %+v", layout, want)
+	}
+
+	delete(live, ref(3))
+	delete(live, ref(4))
+	delete(live, ref(5))
+	if _, err := planLinearLayout(live, ref(1)); err == nil {
+		t.Fatalf("expected an error for a document without pages")
+	}
+}
+
+func TestBitWriter(t *testing.T) {
+	t.Parallel()
+
+	var w bitWriter
+	w.write(1, 1)
+	w.write(5, 3)
+	w.flush()
+	w.write(0x1234, 16)
+	w.write(3, 2)
+	w.flush()
+	if want := []byte{0xD0, 0x12, 0x34, 0xC0}; !bytes.Equal(w.buf, want) {
+		t.Fatalf("bitWriter=%x want %x", w.buf, want)
+	}
+	if bitsFor(0) != 0 || bitsFor(1) != 1 || bitsFor(255) != 8 || bitsFor(256) != 9 {
+		t.Fatalf("bitsFor mismatch")
+	}
+}
+
+// TestRelinearizedHintTables decodes the hint tables from the bytes as
+// ISO 32000-1 Annex F lays them out, and checks every offset and length
+// against where the objects actually are in the written file.
+func TestRelinearizedHintTables(t *testing.T) {
+	// Page 1 has a private font; pages 2 and 3 share one, and page 2 also
+	// uses a private graphics state.
+	objs := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 6 0 R 10 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Length 9 >>\nstream\nfirst one\nendstream",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Times-Roman >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 7 0 R /Resources << /Font << /F1 8 0 R >> /ExtGState << /G 9 0 R >> >> >>",
+		"<< /Length 30 >>\nstream\nsecond page, a longer content\nendstream",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /ExtGState /CA 0.5 >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 11 0 R /Resources << /Font << /F1 8 0 R >> >> >>",
+		"<< /Length 5 >>\nstream\nthird\nendstream",
+	}
+	body := "%PDF-1.4\n"
+	xref := fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for i, o := range objs {
+		xref += fmt.Sprintf("%010d 00000 n \n", len(body))
+		body += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	path := writeTempPDF(t, "pages.pdf", body+xref+fmt.Sprintf("trailer\n<< /Size %d /Root 1 0 R /ID [<0102> <0102>] >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, len(body)))
+	src, _ := openTrailer(t, path)
+	defer src.Close()
+	packet, err := xmp.Marshal(model.Metadata{Title: "Hints"})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	r, err := writeNativeLinearized(src, model.Metadata{Title: "Hints"}, packet, model.WriteOptions{})
+	if err != nil {
+		t.Fatalf("writeNativeLinearized: %v", err)
+	}
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read linearized output: %v", err)
+	}
+	assertLinearizedLayout(t, out)
+	doc, err := pdf.ParseBytes("out.pdf", out)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+
+	// Where each object starts and ends, found by scanning the bytes.
+	start, end := map[int]int{}, map[int]int{}
+	var order []int
+	for _, m := range regexp.MustCompile(`(?m)^(\d+) 0 obj\n`).FindAllSubmatchIndex(out, -1) {
+		num, _ := strconv.Atoi(string(out[m[2]:m[3]]))
+		start[num] = m[0]
+		end[num] = m[0] + bytes.Index(out[m[0]:], []byte("endobj\n")) + len("endobj\n")
+		order = append(order, num)
+	}
+	objectsIn := func(from, to int) int {
+		n := 0
+		for _, num := range order {
+			if start[num] >= from && start[num] < to {
+				n++
+			}
+		}
+		return n
+	}
+
+	linObj, err := pdf.ParseObject(out[bytes.Index(out, []byte("<<")):])
+	if err != nil {
+		t.Fatalf("parse linearization dictionary: %v", err)
+	}
+	lin := linObj.(pdf.Dict)
+	h := lin.Get("H").(pdf.Array)
+	hintOff, hintLen := int(h[0].(pdf.Integer)), int(h[1].(pdf.Integer))
+	firstPageNum, _ := lin.Int("O")
+	endFirstPage, _ := lin.Int("E")
+	// Hint table offsets leave out the hint stream.
+	actual := func(off int) int {
+		if off >= hintOff {
+			return off + hintLen
+		}
+		return off
+	}
+
+	hintNum := -1
+	for num, off := range start {
+		if off == hintOff {
+			hintNum = num
+		}
+	}
+	hintObj, err := doc.Object(pdf.Ref{Num: hintNum})
+	if err != nil {
+		t.Fatalf("hint stream object %d: %v", hintNum, err)
+	}
+	hintStream := hintObj.(pdf.Stream)
+	data, err := doc.DecodeStream(hintStream)
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	sharedAt, _ := hintStream.Dict.Int("S")
+
+	// Page offset hint table (Tables F.3 and F.4); each item starts on a
+	// byte boundary.
+	hints := &hintReader{data: data}
+	minObjs, firstLoc, objBits := hints.read(32), hints.read(32), hints.read(16)
+	minLen, lenBits := hints.read(32), hints.read(16)
+	minContentOff, contentOffBits := hints.read(32), hints.read(16)
+	minContentLen, contentLenBits := hints.read(32), hints.read(16)
+	sharedBits, idBits, numeratorBits, _ := hints.read(16), hints.read(16), hints.read(16), hints.read(16)
+	const pages = 3
+	items := func(bits, least int) []int {
+		vs := make([]int, pages)
+		for i := range vs {
+			vs[i] = least + hints.read(bits)
+		}
+		hints.align()
+		return vs
+	}
+	nobjs := items(objBits, minObjs)
+	pageLen := items(lenBits, minLen)
+	nshared := items(sharedBits, 0)
+	sharedIDs := make([][]int, pages)
+	for i := range sharedIDs {
+		for j := 0; j < nshared[i]; j++ {
+			sharedIDs[i] = append(sharedIDs[i], hints.read(idBits))
+		}
+	}
+	hints.align()
+	for i := 0; i < pages; i++ {
+		for j := 0; j < nshared[i]; j++ {
+			hints.read(numeratorBits)
+		}
+	}
+	hints.align()
+	contentOff := items(contentOffBits, minContentOff)
+	contentLen := items(contentLenBits, minContentLen)
+	if int64(hints.pos) != sharedAt {
+		t.Fatalf("page offset hint table ends at %d, /S is %d", hints.pos, sharedAt)
+	}
+
+	// Shared object hint table (Tables F.5 and F.6).
+	firstShared, firstSharedLoc, firstPageEntries, entries := hints.read(32), hints.read(32), hints.read(32), hints.read(32)
+	groupObjBits, minGroup, groupBits := hints.read(16), hints.read(32), hints.read(16)
+	groupLen := make([]int, entries)
+	for i := range groupLen {
+		groupLen[i] = minGroup + hints.read(groupBits)
+	}
+	hints.align()
+	if groupObjBits != 0 {
+		t.Fatalf("groups should hold one object each, got %d bits", groupObjBits)
+	}
+
+	kids, _ := doc.Resolve(mustDict(t, doc, mustDict(t, doc, pdf.Ref{Num: mustRoot(t, doc)}).Get("Pages")).Get("Kids"))
+	var pageStart int
+	for i, kid := range kids.(pdf.Array) {
+		num := kid.(pdf.Ref).Num
+		if i == 0 {
+			pageStart = firstLoc
+			if num != int(firstPageNum) || start[num] != actual(firstLoc) {
+				t.Fatalf("first page object %d at %d, hint table says %d", num, start[num], actual(firstLoc))
+			}
+			if actual(firstLoc+pageLen[0]) != int(endFirstPage) {
+				t.Fatalf("first page ends at %d, /E is %d", actual(firstLoc+pageLen[0]), endFirstPage)
+			}
+		}
+		from, to := actual(pageStart), actual(pageStart+pageLen[i])
+		if start[num] != from {
+			t.Fatalf("page %d: object %d at %d, hint table says %d", i, num, start[num], from)
+		}
+		if got := objectsIn(from, to); got != nobjs[i] {
+			t.Fatalf("page %d: %d objects in [%d, %d), hint table says %d", i, got, from, to, nobjs[i])
+		}
+		page := mustDict(t, doc, kid)
+		contents := page.Get("Contents").(pdf.Ref).Num
+		if start[contents] != actual(pageStart+contentOff[i]) || end[contents]-start[contents] != contentLen[i] {
+			t.Fatalf("page %d: contents %d at [%d, %d), hint table says %d+%d", i, contents, start[contents], end[contents], actual(pageStart+contentOff[i]), contentLen[i])
+		}
+
+		// Shared identifiers index the first-page objects, then the shared
+		// objects section; pages 2 and 3 both list their font.
+		var shared []int
+		for _, id := range sharedIDs[i] {
+			num := firstShared + id - firstPageEntries
+			if id < firstPageEntries {
+				num = order[slices.Index(order, int(firstPageNum))+id]
+			}
+			if start[num] >= from && start[num] < to {
+				t.Fatalf("page %d: shared object %d lies inside the page", i, num)
+			}
+			shared = append(shared, num)
+		}
+		font := mustDict(t, doc, page.Get("Resources")).Get("Font").(pdf.Dict).Get("F1").(pdf.Ref).Num
+		if i > 0 && !slices.Contains(shared, font) {
+			t.Fatalf("page %d: font %d not among shared objects %v", i, font, shared)
+		}
+		pageStart += pageLen[i]
+	}
+
+	if firstPageEntries != nobjs[0] || entries != nobjs[0]+1 {
+		t.Fatalf("shared entries: %d first-page, %d total; want %d and %d", firstPageEntries, entries, nobjs[0], nobjs[0]+1)
+	}
+	if start[firstShared] != actual(firstSharedLoc) {
+		t.Fatalf("first shared object %d at %d, hint table says %d", firstShared, start[firstShared], actual(firstSharedLoc))
+	}
+	first := slices.Index(order, int(firstPageNum))
+	for i, length := range groupLen {
+		num := firstShared + i - firstPageEntries
+		if i < firstPageEntries {
+			num = order[first+i]
+		}
+		if end[num]-start[num] != length {
+			t.Fatalf("shared group %d (object %d) is %d bytes, hint table says %d", i, num, end[num]-start[num], length)
+		}
+	}
+}
+
+// hintReader reads big-endian bit fields from a hint stream.
+type hintReader struct {
+	data []byte
+	pos  int
+	bit  uint
+}
+
+func (r *hintReader) read(bits int) int {
+	v := 0
+	for ; bits > 0; bits-- {
+		if r.pos >= len(r.data) {
+			return v << bits
+		}
+		v = v<<1 | int(r.data[r.pos]>>(7-r.bit)&1)
+		if r.bit++; r.bit == 8 {
+			r.pos, r.bit = r.pos+1, 0
+		}
+	}
+	return v
+}
+
+func (r *hintReader) align() {
+	if r.bit > 0 {
+		r.pos, r.bit = r.pos+1, 0
+	}
+}
+
+func mustDict(t *testing.T, doc *pdf.Document, obj pdf.Object) pdf.Dict {
+	t.Helper()
+	v, err := doc.Resolve(obj)
+	if err != nil {
+		t.Fatalf("Resolve(%v): %v", obj, err)
+	}
+	dict, ok := v.(pdf.Dict)
+	if !ok {
+		t.Fatalf("%v is not a dictionary: %#v", obj, v)
+	}
+	return dict
+}
+
+func mustRoot(t *testing.T, doc *pdf.Document) int {
+	t.Helper()
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	root, _ := trailer.Ref("Root")
+	return root.Num
+}
@@ -0,0 +1,139 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+	"pdfmeta/internal/xmp"
+)
+
+// pdfDatePattern splits a PDF date (D:YYYYMMDDHHmmSSOHH'mm') into its
+// components; everything after the year is optional.
+var pdfDatePattern = regexp.MustCompile(`^D:(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?(?:([Zz])|([+-])(\d{2})'?(?:(\d{2})'?)?)?$`)
+
+// readPDFA returns the PDF/A identification declared in doc's XMP packet.
+func readPDFA(doc *pdf.Document) (model.PDFA, bool) {
+	packet, ok := metadataPacket(doc)
+	if !ok {
+		return model.PDFA{}, false
+	}
+	return xmp.PDFA(packet)
+}
+
+// pdfaProblems lists the ways writing meta would break the conformance of a
+// PDF/A document. PDF/A requires every Info entry to be equivalent to its
+// XMP property; /Author matches only a dc:creator with a single entry.
+func pdfaProblems(doc *pdf.Document, id model.PDFA, meta model.Metadata, opts model.WriteOptions) []string {
+	var problems []string
+	if id.Part == 1 && opts.ObjectStreams {
+		problems = append(problems, "PDF/A-1 does not allow object streams")
+	}
+	if doc.Encrypted() && !doc.Unlocked() {
+		problems = append(problems, "the encrypted Info dictionary cannot be updated to match the XMP packet")
+	}
+	if len(meta.Authors) > 1 {
+		problems = append(problems, fmt.Sprintf("%d authors cannot be written as the single dc:creator entry that /Author must match", len(meta.Authors)))
+	}
+	for _, f := range []model.Field{model.FieldCreationDate, model.FieldModDate} {
+		v := meta.Value(f)
+		if v == "" {
+			continue
+		}
+		_, okPDF := pdfDate(v)
+		_, okXMP := xmpDate(v)
+		if !okPDF || !okXMP {
+			problems = append(problems, fmt.Sprintf("%s %q cannot be written as both a PDF date and an XMP date", f, v))
+		}
+	}
+	return problems
+}
+
+// pdfaViews returns the values written to Info and to XMP for a PDF/A
+// document: the same metadata with dates in PDF and XMP syntax respectively.
+// Dates that cannot be converted are left as they are.
+func pdfaViews(meta model.Metadata) (info, x model.Metadata) {
+	info, x = meta, meta
+	for _, d := range []struct {
+		src       string
+		info, xmp *string
+	}{
+		{meta.CreationDate, &info.CreationDate, &x.CreationDate},
+		{meta.ModDate, &info.ModDate, &x.ModDate},
+	} {
+		if v, ok := pdfDate(d.src); ok {
+			*d.info = v
+		}
+		if v, ok := xmpDate(d.src); ok {
+			*d.xmp = v
+		}
+	}
+	return info, x
+}
+
+// pdfDate converts an RFC3339 or PDF date to PDF date syntax.
+func pdfDate(v string) (string, bool) {
+	if pdfDatePattern.MatchString(v) {
+		return v, true
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return "", false
+	}
+	out := t.Format("D:20060102150405")
+	_, offset := t.Zone()
+	if offset == 0 {
+		return out + "Z", true
+	}
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	return out + fmt.Sprintf("%s%02d'%02d'", sign, offset/3600, offset%3600/60), true
+}
+
+// xmpDate converts an RFC3339 or PDF date to the ISO 8601 subset XMP uses.
+// A PDF date keeps its precision; a time without seconds is written to the
+// minute.
+func xmpDate(v string) (string, bool) {
+	if _, err := time.Parse(time.RFC3339, v); err == nil {
+		return v, true
+	}
+	m := pdfDatePattern.FindStringSubmatch(v)
+	if m == nil {
+		return "", false
+	}
+	year, month, day, hour, minute, second := m[1], m[2], m[3], m[4], m[5], m[6]
+	out := year
+	if month == "" {
+		return out, true
+	}
+	out += "-" + month
+	if day == "" {
+		return out, true
+	}
+	out += "-" + day
+	if hour == "" {
+		return out, true
+	}
+	if minute == "" {
+		minute = "00"
+	}
+	out += "T" + hour + ":" + minute
+	if second != "" {
+		out += ":" + second
+	}
+	switch {
+	case m[7] != "":
+		out += "Z"
+	case m[8] != "":
+		tzMinute := m[10]
+		if tzMinute == "" {
+			tzMinute = "00"
+		}
+		out += m[8] + m[9] + ":" + tzMinute
+	}
+	return out, true
+}
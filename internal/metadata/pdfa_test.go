@@ -0,0 +1,178 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+	"pdfmeta/internal/xmp"
+)
+
+// writePDFAFile writes a file whose XMP packet declares the given PDF/A
+// identification.
+func writePDFAFile(t *testing.T, id model.PDFA) string {
+	t.Helper()
+	packet, err := xmp.MarshalPDFA(model.Metadata{Title: "Archive", CreationDate: "2020-01-02T03:04:05Z"}, id)
+	if err != nil {
+		t.Fatalf("MarshalPDFA: %v", err)
+	}
+	objs := []string{
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R /Metadata 3 0 R >>\nendobj\n",
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n",
+		fmt.Sprintf("3 0 obj\n<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(packet)+1, packet),
+		"4 0 obj\n<< /Title (Archive) /CreationDate (D:20200102030405Z) >>\nendobj\n",
+	}
+	body := "%PDF-1.7\n"
+	xref := "xref\n0 5\n0000000000 65535 f \n"
+	for _, o := range objs {
+		xref += fmt.Sprintf("%010d 00000 n \n", len(body))
+		body += o
+	}
+	return writeTempPDF(t, "pdfa.pdf", body+xref+fmt.Sprintf("trailer\n<< /Size 5 /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(body)))
+}
+
+func TestReadReportsPDFA(t *testing.T) {
+	path := writePDFAFile(t, model.PDFA{Part: 2, Conformance: "B"})
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Structure.PDFA == nil || res.Structure.PDFA.String() != "PDF/A-2b" {
+		t.Fatalf("PDFA=%+v", res.Structure.PDFA)
+	}
+}
+
+func TestWritePDFAKeepsConformance(t *testing.T) {
+	path := writePDFAFile(t, model.PDFA{Part: 2, Conformance: "B"})
+	title, date := "Updated", "2024-05-01T10:00:00+02:00"
+	writeInPlace(t, path, model.WriteOptions{PDFA: true}, model.MetadataPatch{Title: &title, ModDate: &date})
+
+	doc, trailer := openTrailer(t, path)
+	defer doc.Close()
+	packet, ok := metadataPacket(doc)
+	if !ok {
+		t.Fatalf("metadata stream missing after write")
+	}
+	if id, ok := xmp.PDFA(packet); !ok || id != (model.PDFA{Part: 2, Conformance: "B"}) {
+		t.Fatalf("PDF/A identification lost: %+v", id)
+	}
+	x, err := xmp.Unmarshal(packet)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	info, _ := resolveDict(doc, trailer.Get("Info"))
+	infoMeta := parseInfoDict(doc, info)
+	if x.Title != title || infoMeta.Title != title {
+		t.Fatalf("titles differ: info %q xmp %q", infoMeta.Title, x.Title)
+	}
+	if x.ModDate != date || infoMeta.ModDate != "D:20240501100000+02'00'" {
+		t.Fatalf("dates: info %q xmp %q", infoMeta.ModDate, x.ModDate)
+	}
+	if x.CreationDate != "2020-01-02T03:04:05Z" || infoMeta.CreationDate != "D:20200102030405Z" {
+		t.Fatalf("creation dates: info %q xmp %q", infoMeta.CreationDate, x.CreationDate)
+	}
+	root, _ := resolveDict(doc, trailer.Get("Root"))
+	obj, _ := doc.Resolve(root.Get("Metadata"))
+	if stream, ok := obj.(pdf.Stream); !ok || stream.Dict.Get("Filter") != nil {
+		t.Fatalf("XMP stream must stay uncompressed: %+v", obj)
+	}
+}
+
+func TestWritePDFAReplacesMalformedXMP(t *testing.T) {
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/" pdfaid:part="2" pdfaid:conformance="B">` +
+		`</rdf:RDF></x:xmpmeta>`
+	path := writeXMPFile(t, packet, "<< /Title (Archive) >>")
+	title := "Updated"
+	res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Options:   model.WriteOptions{PDFA: true},
+		Set:       model.MetadataPatch{Title: &title},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "XMP packet replaced") {
+		t.Fatalf("warnings=%q", res.Warnings)
+	}
+
+	doc, _ := openTrailer(t, path)
+	defer doc.Close()
+	got, ok := metadataPacket(doc)
+	if !ok {
+		t.Fatalf("metadata stream missing after write")
+	}
+	if id, ok := xmp.PDFA(got); !ok || id != (model.PDFA{Part: 2, Conformance: "B"}) {
+		t.Fatalf("PDF/A identification lost: %+v\n%s", id, got)
+	}
+	if x, err := xmp.Unmarshal(got); err != nil || x.Title != title {
+		t.Fatalf("Unmarshal=%+v, %v", x, err)
+	}
+}
+
+func TestWritePDFARefusesBreakingChanges(t *testing.T) {
+	date := "yesterday"
+	tests := []struct {
+		name  string
+		id    model.PDFA
+		opts  model.WriteOptions
+		patch model.MetadataPatch
+	}{
+		{name: "unconvertible date", id: model.PDFA{Part: 2, Conformance: "B"}, patch: model.MetadataPatch{ModDate: &date}},
+		{name: "object streams", id: model.PDFA{Part: 1, Conformance: "B"}, opts: model.WriteOptions{ObjectStreams: true}},
+		{name: "several authors", id: model.PDFA{Part: 2, Conformance: "B"}, patch: model.MetadataPatch{Authors: model.List{"Ann", "Bo"}}},
+	}
+	for _, tc := range tests {
+		path := writePDFAFile(t, tc.id)
+		opts := tc.opts
+		opts.PDFA = true
+		_, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+			InputPath:  path,
+			OutputPath: filepath.Join(t.TempDir(), "out.pdf"),
+			Options:    opts,
+			Set:        tc.patch,
+		})
+		assertAppErrorCode(t, err, model.ErrConflict)
+
+		res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+			InputPath:  path,
+			OutputPath: filepath.Join(t.TempDir(), "out.pdf"),
+			Options:    tc.opts,
+			Set:        tc.patch,
+		})
+		if err != nil {
+			t.Fatalf("%s: Write without --pdfa: %v", tc.name, err)
+		}
+		if len(res.Warnings) != 1 {
+			t.Fatalf("%s: warnings=%q", tc.name, res.Warnings)
+		}
+	}
+}
+
+func TestPDFAndXMPDates(t *testing.T) {
+	tests := []struct {
+		in, pdf, xmp string
+	}{
+		{in: "2024-05-01T10:00:00Z", pdf: "D:20240501100000Z", xmp: "2024-05-01T10:00:00Z"},
+		{in: "2024-05-01T10:00:00-05:30", pdf: "D:20240501100000-05'30'", xmp: "2024-05-01T10:00:00-05:30"},
+		{in: "D:20240501100000+02'00'", pdf: "D:20240501100000+02'00'", xmp: "2024-05-01T10:00:00+02:00"},
+		{in: "D:2024", pdf: "D:2024", xmp: "2024"},
+		{in: "D:202405011030", pdf: "D:202405011030", xmp: "2024-05-01T10:30"},
+	}
+	for _, tc := range tests {
+		if got, ok := pdfDate(tc.in); !ok || got != tc.pdf {
+			t.Fatalf("pdfDate(%q)=%q,%v want %q", tc.in, got, ok, tc.pdf)
+		}
+		if got, ok := xmpDate(tc.in); !ok || got != tc.xmp {
+			t.Fatalf("xmpDate(%q)=%q,%v want %q", tc.in, got, ok, tc.xmp)
+		}
+	}
+	if _, ok := xmpDate("yesterday"); ok {
+		t.Fatalf("xmpDate accepted an invalid date")
+	}
+}
@@ -0,0 +1,79 @@
+package metadata
+
+import (
+	"context"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// Repair rebuilds the object index by scanning for object headers and writes
+// a clean single-revision file with a fresh xref table and trailer. Objects
+// no longer reachable from the recovered catalog are dropped. The result
+// describes the repaired file as Read would.
+func (s *Store) Repair(ctx context.Context, req model.MetadataRepairRequest) (model.MetadataReadResult, error) {
+	if err := ctxErr(ctx); err != nil {
+		return model.MetadataReadResult{}, err
+	}
+	if req.InputPath == "" {
+		return model.MetadataReadResult{}, &model.AppError{Code: model.ErrValidation, Message: "input path is required"}
+	}
+	dst, err := writeTarget(model.MetadataWriteRequest{InputPath: req.InputPath, OutputPath: req.OutputPath, InPlace: req.InPlace})
+	if err != nil {
+		return model.MetadataReadResult{}, err
+	}
+
+	doc, err := pdf.Open(req.InputPath)
+	if err != nil {
+		return model.MetadataReadResult{}, err
+	}
+	defer doc.Close()
+	if doc.Encrypted() {
+		return model.MetadataReadResult{}, &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonRepairUnsupported, Message: "cannot repair encrypted pdf"}
+	}
+	report, err := rebuildDocument(doc)
+	if err != nil {
+		return model.MetadataReadResult{}, err
+	}
+
+	trailer, err := doc.Trailer()
+	if err != nil {
+		return model.MetadataReadResult{}, err
+	}
+	catalog, _ := resolveDict(doc, trailer.Get("Root"))
+	if err := writeOutput(dst, rewriteLive(doc, trailer, catalog, nil, false, false)); err != nil {
+		return model.MetadataReadResult{}, err
+	}
+
+	// Describe the repaired file the way show would.
+	repaired, err := pdf.Open(dst)
+	if err != nil {
+		return model.MetadataReadResult{}, err
+	}
+	defer repaired.Close()
+	res := describeDocument(repaired, nil)
+	res.Repair = report
+	return res, nil
+}
+
+// rebuildDocument replaces doc's cross-reference data with a scan-based index
+// and describes what was recovered.
+func rebuildDocument(doc *pdf.Document) (*model.RepairReport, error) {
+	rec, err := doc.Rebuild()
+	if err != nil {
+		return nil, err
+	}
+	report := &model.RepairReport{
+		Objects:    rec.Objects,
+		Root:       rec.Root.String(),
+		RootSource: rec.RootSource,
+		InfoSource: rec.InfoSource,
+	}
+	if rec.XRefError != nil {
+		report.XRefError = rec.XRefError.Error()
+	}
+	if rec.InfoSource != "" {
+		report.Info = rec.Info.String()
+	}
+	return report, nil
+}
@@ -0,0 +1,109 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+func TestRepairWritesCleanXRef(t *testing.T) {
+	dst := filepath.Join(t.TempDir(), "repaired.pdf")
+	res, err := NewStore().Repair(context.Background(), model.MetadataRepairRequest{
+		InputPath:  fixturePath("malformed-trailer.pdf"),
+		OutputPath: dst,
+	})
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	r := res.Repair
+	if r == nil || r.XRefError == "" || r.Objects != 3 || r.Root != "1 0 R" || r.RootSource != pdf.SourceScan || r.Info != "" {
+		t.Fatalf("unexpected repair report %#v", r)
+	}
+	// The result has the same shape as show, describing the repaired file.
+	if res.Structure == nil || res.Structure.Revisions != 1 || res.Structure.Pages != 1 {
+		t.Fatalf("repair result structure %#v", res.Structure)
+	}
+
+	doc, trailer := openTrailer(t, dst)
+	if size, _ := trailer.Int("Size"); size != 4 {
+		t.Fatalf("trailer /Size=%d want 4", size)
+	}
+	if len(doc.Revisions()) != 1 {
+		t.Fatalf("repaired file has %d revisions want 1", len(doc.Revisions()))
+	}
+	page, err := doc.Object(pdf.Ref{Num: 3})
+	if err != nil || page.(pdf.Dict).TypeName() != "Page" {
+		t.Fatalf("page object lost: %v %v", page, err)
+	}
+}
+
+func TestRepairRecoversInfoByScanning(t *testing.T) {
+	path := writeTempPDF(t, "lost-trailer.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n"+
+		"3 0 obj\n<< /Title (Recovered) /Author (Scanner) >>\nendobj\n"+
+		"xref\n0 4\n0000000000 65535 f \n0000000099 00000 n \n")
+
+	res, err := NewStore().Repair(context.Background(), model.MetadataRepairRequest{InputPath: path, InPlace: true})
+	if err != nil {
+		t.Fatalf("Repair: %v", err)
+	}
+	if res.Repair.Info != "3 0 R" || res.Repair.InfoSource != pdf.SourceScan {
+		t.Fatalf("unexpected repair report %#v", res.Repair)
+	}
+	openTrailer(t, path)
+	got, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.Metadata.Title != "Recovered" || got.Metadata.Value(model.FieldAuthor) != "Scanner" || !got.InfoFound {
+		t.Fatalf("unexpected metadata after repair: %#v", got)
+	}
+}
+
+func TestRepairRejectsEncrypted(t *testing.T) {
+	_, err := NewStore().Repair(context.Background(), model.MetadataRepairRequest{
+		InputPath:  fixturePath("encrypted-marker.pdf"),
+		OutputPath: filepath.Join(t.TempDir(), "out.pdf"),
+	})
+	assertAppErrorCode(t, err, model.ErrPDFEncrypted)
+}
+
+func TestWriteWithRepairOption(t *testing.T) {
+	path := copyFixture(t, "malformed-trailer.pdf")
+
+	title := "Repaired"
+	res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Options:   model.WriteOptions{Repair: true},
+		Set:       model.MetadataPatch{Title: &title},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if res.Repair == nil || res.Repair.RootSource != pdf.SourceScan {
+		t.Fatalf("expected repair report, got %#v", res.Repair)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if bytes.Contains(out, []byte("/Size BAD")) || bytes.Count(out, []byte("startxref")) != 1 {
+		t.Fatalf("repaired write kept the damaged trailer")
+	}
+	openTrailer(t, path)
+	got, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if got.Metadata.Title != title || !got.XMPFound {
+		t.Fatalf("unexpected metadata after repaired write: %#v", got)
+	}
+}
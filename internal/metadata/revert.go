@@ -0,0 +1,118 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"pdfmeta/internal/filesafe"
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// Revert truncates the file at the end of an earlier revision. Incremental
+// updates only append, so the prefix is byte-for-byte the file as it was
+// saved then. The prefix must parse with an intact xref chain and end on the
+// same boundary before anything is written.
+func (s *Store) Revert(ctx context.Context, req model.MetadataRevertRequest) (model.RevertResult, error) {
+	if err := ctxErr(ctx); err != nil {
+		return model.RevertResult{}, err
+	}
+	if req.InputPath == "" {
+		return model.RevertResult{}, &model.AppError{Code: model.ErrValidation, Message: "input path is required"}
+	}
+	dst, err := writeTarget(model.MetadataWriteRequest{InputPath: req.InputPath, OutputPath: req.OutputPath, InPlace: req.InPlace})
+	if err != nil {
+		return model.RevertResult{}, err
+	}
+
+	doc, err := pdf.Open(req.InputPath)
+	if err != nil {
+		return model.RevertResult{}, err
+	}
+	defer doc.Close()
+	revs := doc.Revisions()
+	target, err := revertTarget(len(revs), req.To, req.Steps)
+	if err != nil {
+		return model.RevertResult{}, err
+	}
+	rev := revs[target-1]
+
+	prefix, err := doc.AtRevision(rev)
+	if err != nil {
+		return model.RevertResult{}, err
+	}
+	if err := checkRevisionBoundary(prefix, rev, target); err != nil {
+		return model.RevertResult{}, err
+	}
+
+	if err := filesafe.WriteAtomicFromReader(dst, io.NewSectionReader(doc, 0, rev.End), 0o644); err != nil {
+		return model.RevertResult{}, &model.AppError{Code: model.ErrIO, Message: fmt.Sprintf("write %q", dst), Cause: err}
+	}
+
+	meta, infoFound, xmpFound := readNativeMetadata(prefix)
+	return model.RevertResult{
+		InputPath: dst,
+		Revision: model.Revision{
+			Index:       target,
+			StartOffset: rev.Start,
+			EndOffset:   rev.End,
+			Size:        rev.Size(),
+			XRefOffset:  rev.XRefOffset,
+			Metadata:    meta,
+			InfoFound:   infoFound,
+			XMPFound:    xmpFound,
+		},
+		Dropped: len(revs) - target,
+	}, nil
+}
+
+// revertTarget maps --to/--steps onto a 1-based revision index that is older
+// than the current one.
+func revertTarget(total, to, steps int) (int, error) {
+	if total == 0 {
+		return 0, &model.AppError{Code: model.ErrPDFMalformed, Message: "no complete revision found (missing startxref or %%EOF)"}
+	}
+	target := to
+	if steps > 0 {
+		target = total - steps
+	}
+	switch {
+	case target < 1:
+		return 0, &model.AppError{Code: model.ErrValidation, Message: fmt.Sprintf("cannot drop %d of %d revisions; at least one must remain", steps, total)}
+	case target > total:
+		return 0, &model.AppError{Code: model.ErrValidation, Message: fmt.Sprintf("revision %d does not exist; file has %d", target, total)}
+	case target == total:
+		return 0, &model.AppError{Code: model.ErrValidation, Message: fmt.Sprintf("revision %d is already the current revision", target)}
+	}
+	return target, nil
+}
+
+// checkRevisionBoundary confirms that the truncated file is a complete PDF
+// whose newest section is the one the boundary was found for.
+func checkRevisionBoundary(prefix *pdf.Document, rev pdf.Revision, index int) error {
+	boundaryErr := func(cause error) error {
+		return &model.AppError{
+			Code:    model.ErrPDFMalformed,
+			Message: fmt.Sprintf("revision %d boundary at offset %d is not a complete revision", index, rev.End),
+			Cause:   cause,
+		}
+	}
+	if err := prefix.XRefError(); err != nil {
+		return boundaryErr(err)
+	}
+	if start, ok := prefix.StartXRef(); !ok || start != rev.XRefOffset {
+		return boundaryErr(fmt.Errorf("startxref does not match offset %d", rev.XRefOffset))
+	}
+	if n := len(prefix.Revisions()); n != index {
+		return boundaryErr(fmt.Errorf("truncated file has %d revisions, want %d", n, index))
+	}
+	trailer, err := prefix.Trailer()
+	if err != nil {
+		return boundaryErr(err)
+	}
+	if _, ok := resolveDict(prefix, trailer.Get("Root")); !ok {
+		return boundaryErr(fmt.Errorf("catalog dictionary missing"))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+func TestRevertUndoesWrite(t *testing.T) {
+	path := copyFixture(t, "incremental-updates.pdf")
+	orig, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	title := "Undo Me"
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+
+	res, err := NewStore().Revert(context.Background(), model.MetadataRevertRequest{InputPath: path, InPlace: true, Steps: 1})
+	if err != nil {
+		t.Fatalf("Revert: %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read reverted: %v", err)
+	}
+	if !bytes.Equal(got, orig) {
+		t.Fatalf("revert did not restore the original bytes")
+	}
+	if res.Revision.Index != 3 || res.Dropped != 1 || res.Revision.Metadata.Title != "Revision 3" {
+		t.Fatalf("unexpected revert result: %#v", res)
+	}
+}
+
+func TestRevertToRevisionWritesOutput(t *testing.T) {
+	in := fixturePath("incremental-updates.pdf")
+	out := filepath.Join(t.TempDir(), "rev1.pdf")
+
+	res, err := NewStore().Revert(context.Background(), model.MetadataRevertRequest{InputPath: in, OutputPath: out, To: 1})
+	if err != nil {
+		t.Fatalf("Revert: %v", err)
+	}
+	if res.InputPath != out || res.Dropped != 2 {
+		t.Fatalf("unexpected revert result: %#v", res)
+	}
+	read, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: out})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if read.Metadata.Title != "Revision 1" || read.Metadata.Value(model.FieldAuthor) != "Original Author" {
+		t.Fatalf("unexpected metadata after revert: %#v", read.Metadata)
+	}
+}
+
+func TestRevertRejectsInvalidTargets(t *testing.T) {
+	in := fixturePath("incremental-updates.pdf")
+	tests := []struct {
+		name  string
+		to    int
+		steps int
+	}{
+		{name: "current revision", to: 3},
+		{name: "beyond history", to: 4},
+		{name: "drop every revision", steps: 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := filepath.Join(t.TempDir(), "out.pdf")
+			_, err := NewStore().Revert(context.Background(), model.MetadataRevertRequest{InputPath: in, OutputPath: out, To: tc.to, Steps: tc.steps})
+			assertAppErrorCode(t, err, model.ErrValidation)
+			if _, err := os.Stat(out); !os.IsNotExist(err) {
+				t.Fatalf("output must not be written on error")
+			}
+		})
+	}
+}
+
+func TestRevertRejectsBrokenBoundary(t *testing.T) {
+	// The first section's startxref points at the wrong offset, so the
+	// truncated file would not have a usable xref chain.
+	path := writeTempPDF(t, "broken.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog >>\nendobj\n"+
+		"xref\n0 2\n0000000000 65535 f \n0000000009 00000 n \n"+
+		"trailer\n<< /Size 2 /Root 1 0 R >>\nstartxref\n7\n%%EOF\n"+
+		"2 0 obj\n(x)\nendobj\n"+
+		"trailer\n<< /Size 3 /Root 1 0 R /Prev 7 >>\nstartxref\n999\n%%EOF\n")
+
+	_, err := NewStore().Revert(context.Background(), model.MetadataRevertRequest{InputPath: path, InPlace: true, To: 1})
+	assertAppErrorCode(t, err, model.ErrPDFMalformed)
+}
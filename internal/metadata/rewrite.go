@@ -0,0 +1,185 @@
+package metadata
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// writeNativeRewrite serializes only the objects reachable from the updated
+// trailer into a single-revision file. Earlier revisions, unreferenced
+// objects and old object/xref streams are dropped. Object numbers and
+// generations are kept.
+func writeNativeRewrite(doc *pdf.Document, meta model.Metadata, xmpPacket []byte, opts model.WriteOptions) (io.Reader, error) {
+	plan, err := planMetadataUpdate(doc, meta, xmpPacket, opts)
+	if err != nil {
+		return nil, err
+	}
+	trailer := nextTrailer(plan.trailer, plan.rootRef, plan.infoRef)
+
+	overlay := make(map[pdf.Ref]pdf.Object, len(plan.objs))
+	for _, o := range plan.objs {
+		overlay[o.ref] = o.obj
+	}
+	return rewriteLive(doc, trailer, plan.catalog, overlay, plan.useStream, opts.ObjectStreams), nil
+}
+
+// rewriteLive returns a complete single-section file holding every object
+// reachable from trailer. The file is serialized as it is read: objects are
+// loaded again and written one at a time, so only their numbers (and the
+// small objects packed into an object stream) are held in memory.
+func rewriteLive(doc *pdf.Document, trailer, catalog pdf.Dict, overlay map[pdf.Ref]pdf.Object, useStream, objectStreams bool) io.Reader {
+	live := reachableRefs(doc, trailer, overlay)
+
+	refs := make([]pdf.Ref, 0, len(live))
+	for ref := range live {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].Num < refs[j].Num })
+	size := 1
+	for _, ref := range refs {
+		if ref.Num+1 > size {
+			size = ref.Num + 1
+		}
+	}
+
+	version := documentVersion(doc, catalog)
+	if useStream && versionBefore(version, 1, 5) {
+		version = "1.5"
+	}
+	header := []byte("%PDF-" + version + "\n%\xe2\xe3\xcf\xd3\n")
+	s := newSectionWriter(int64(len(header)), documentSealer(doc))
+
+	// The /Encrypt dictionary must stay readable before decryption starts,
+	// so it is never packed.
+	encryptRef, _ := trailer.Ref("Encrypt")
+	i := -1
+	return &pieceReader{next: func() ([]byte, error) {
+		for {
+			switch {
+			case i < 0:
+				i++
+				return header, nil
+			case i < len(refs):
+				ref := refs[i]
+				i++
+				obj, ok := overlay[ref]
+				if !ok {
+					var err error
+					if obj, err = doc.Object(ref); err != nil {
+						return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: fmt.Sprintf("read object %s", ref), Cause: err}
+					}
+				}
+				packed := objectStreams && ref.Gen == 0 && !live[ref] && ref != encryptRef
+				piece, err := s.object(pendingObject{ref: ref, obj: obj, packed: packed})
+				if err != nil || piece != nil {
+					return piece, err
+				}
+			case i == len(refs):
+				i++
+				if useStream {
+					return s.xrefStream(trailer, size)
+				}
+				return s.completeXRefTable(trailer, size), nil
+			default:
+				return nil, io.EOF
+			}
+		}
+	}}
+}
+
+// pieceReader reads the pieces next returns in turn, until next returns an
+// error; io.EOF ends the output.
+type pieceReader struct {
+	next func() ([]byte, error)
+	buf  []byte
+	err  error
+}
+
+func (r *pieceReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.buf, r.err = r.next()
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// reachableObjects loads every object reachable from trailer. Objects in
+// overlay replace the document's version. Stream data is dropped, so only
+// the dictionaries stay in memory; writers load the object again to write
+// it.
+func reachableObjects(doc *pdf.Document, trailer pdf.Dict, overlay map[pdf.Ref]pdf.Object) map[pdf.Ref]pdf.Object {
+	live := map[pdf.Ref]pdf.Object{}
+	for ref := range reachableRefs(doc, trailer, overlay) {
+		obj, ok := overlay[ref]
+		if !ok {
+			var err error
+			if obj, err = doc.Object(ref); err != nil {
+				continue
+			}
+		}
+		if s, ok := obj.(pdf.Stream); ok {
+			obj = pdf.Stream{Dict: s.Dict}
+		}
+		live[ref] = obj
+	}
+	return live
+}
+
+// reachableRefs walks every indirect reference reachable from trailer and
+// reports, for each, whether it is a stream. Objects in overlay replace the
+// document's version. Dangling references are skipped; they resolve to null
+// either way. Objects are dropped once walked.
+func reachableRefs(doc *pdf.Document, trailer pdf.Dict, overlay map[pdf.Ref]pdf.Object) map[pdf.Ref]bool {
+	live := map[pdf.Ref]bool{}
+	var pending []pdf.Ref
+	var collect func(obj pdf.Object)
+	collect = func(obj pdf.Object) {
+		switch v := obj.(type) {
+		case pdf.Ref:
+			if _, seen := live[v]; !seen {
+				live[v] = false
+				pending = append(pending, v)
+			}
+		case pdf.Array:
+			for _, e := range v {
+				collect(e)
+			}
+		case pdf.Dict:
+			for _, e := range v {
+				collect(e)
+			}
+		case pdf.Stream:
+			for k, e := range v.Dict {
+				// The serializer writes a direct /Length.
+				if k != "Length" {
+					collect(e)
+				}
+			}
+		}
+	}
+
+	collect(trailer)
+	for len(pending) > 0 {
+		ref := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		obj, ok := overlay[ref]
+		if !ok {
+			var err error
+			if obj, err = doc.Object(ref); err != nil {
+				delete(live, ref)
+				continue
+			}
+		}
+		_, live[ref] = obj.(pdf.Stream)
+		collect(obj)
+	}
+	return live
+}
@@ -0,0 +1,151 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+func TestRewriteDropsIncrementalHistory(t *testing.T) {
+	path := copyFixture(t, "incremental-updates.pdf")
+
+	title := "Only Revision"
+	out := writeInPlace(t, path, model.WriteOptions{Rewrite: true}, model.MetadataPatch{Title: &title})
+	if n := bytes.Count(out, []byte("startxref")); n != 1 {
+		t.Fatalf("expected a single revision, found %d startxref keywords", n)
+	}
+	for _, stale := range []string{"Revision 1", "Revision 2", "Revision 3", "Original Author"} {
+		if bytes.Contains(out, []byte(stale)) {
+			t.Fatalf("rewritten file still contains %q", stale)
+		}
+	}
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4\n")) {
+		t.Fatalf("unexpected header %q", out[:9])
+	}
+
+	_, trailer := openTrailer(t, path)
+	if _, ok := trailer.Int("Prev"); ok {
+		t.Fatalf("rewritten trailer must not link a previous section")
+	}
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != "Second Author" || !res.XMPFound {
+		t.Fatalf("unexpected metadata after rewrite: %#v", res)
+	}
+}
+
+func TestRewriteDropsUnreachableObjects(t *testing.T) {
+	path := writeTempPDF(t, "orphans.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 /Extra [3 0 R] >>\nendobj\n"+
+		"3 0 obj\n(kept)\nendobj\n"+
+		"9 0 obj\n(orphan)\nendobj\n"+
+		"trailer\n<< /Size 10 /Root 1 0 R /ID [<01> <02>] >>\nstartxref\n0\n%%EOF\n")
+
+	title := "Clean"
+	out := writeInPlace(t, path, model.WriteOptions{Rewrite: true}, model.MetadataPatch{Title: &title})
+	if bytes.Contains(out, []byte("orphan")) {
+		t.Fatalf("unreachable object was not dropped")
+	}
+	if !bytes.Contains(out, []byte("(kept)")) {
+		t.Fatalf("reachable object was dropped")
+	}
+
+	doc, trailer := openTrailer(t, path)
+	if id, ok := trailer.Get("ID").(pdf.Array); !ok || len(id) != 2 {
+		t.Fatalf("/ID not carried over: %#v", trailer.Get("ID"))
+	}
+	if _, err := doc.Object(pdf.Ref{Num: 9}); err == nil {
+		t.Fatalf("object 9 should be free after rewrite")
+	}
+}
+
+func TestRewriteKeepsXRefStreamStyle(t *testing.T) {
+	path := copyFixture(t, "object-streams.pdf")
+
+	title := "Packed Rewrite"
+	out := writeInPlace(t, path, model.WriteOptions{Rewrite: true, ObjectStreams: true}, model.MetadataPatch{Title: &title})
+	if bytes.Contains(out, []byte("trailer")) {
+		t.Fatalf("expected an xref stream, got a classic trailer")
+	}
+
+	doc, trailer := openTrailer(t, path)
+	if !doc.UsesXRefStreams() {
+		t.Fatalf("expected rewritten file to use an xref stream")
+	}
+	id := pdf.Array{pdf.String{Bytes: []byte{0x0D, 0x0E, 0x0F}, Hex: true}, pdf.String{Bytes: []byte{0x0D, 0x0E, 0x0F}, Hex: true}}
+	if !reflect.DeepEqual(trailer.Get("ID"), id) {
+		t.Fatalf("/ID=%#v want %#v", trailer.Get("ID"), id)
+	}
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != "Packed Author" {
+		t.Fatalf("unexpected metadata after rewrite: %#v", res.Metadata)
+	}
+}
+
+func TestRewriteInlinesIndirectStreamLength(t *testing.T) {
+	path := writeTempPDF(t, "length.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 /Extra 5 0 R >>\nendobj\n"+
+		"5 0 obj\n<< /Length 6 0 R >>\nstream\nabc\nendstream\nendobj\n"+
+		"6 0 obj\n3\nendobj\n"+
+		"trailer\n<< /Size 7 /Root 1 0 R >>\nstartxref\n0\n%%EOF\n")
+
+	title := "Inline"
+	out := writeInPlace(t, path, model.WriteOptions{Rewrite: true}, model.MetadataPatch{Title: &title})
+	if bytes.Contains(out, []byte("6 0 obj")) {
+		t.Fatalf("length-only object 6 should be dropped")
+	}
+
+	doc, _ := openTrailer(t, path)
+	obj, err := doc.Object(pdf.Ref{Num: 5})
+	if err != nil {
+		t.Fatalf("Object(5): %v", err)
+	}
+	s := obj.(pdf.Stream)
+	if n, _ := s.Dict.Int("Length"); n != 3 || string(s.Data) != "abc" {
+		t.Fatalf("unexpected stream after rewrite: %#v", s)
+	}
+}
+
+func TestRewriteStreamsOneObjectAtATime(t *testing.T) {
+	doc, trailer := openTrailer(t, copyFixture(t, "incremental-updates.pdf"))
+	defer doc.Close()
+	root, _ := trailer.Ref("Root")
+	catalog, _ := resolveDict(doc, root)
+	r := rewriteLive(doc, nextTrailer(trailer, root, pdf.Ref{}), catalog, nil, false, false).(*pieceReader)
+
+	var pieces [][]byte
+	for {
+		piece, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		pieces = append(pieces, piece)
+	}
+	if len(pieces) < 3 || !bytes.HasPrefix(pieces[0], []byte("%PDF-")) || !bytes.HasPrefix(pieces[len(pieces)-1], []byte("xref\n")) {
+		t.Fatalf("unexpected pieces: %q", pieces)
+	}
+	for _, piece := range pieces[1 : len(pieces)-1] {
+		if bytes.Count(piece, []byte("endobj")) != 1 {
+			t.Fatalf("piece is not a single object: %q", piece)
+		}
+	}
+	out, err := pdf.ParseBytes("rewritten.pdf", bytes.Join(pieces, nil))
+	if err != nil || out.XRefError() != nil {
+		t.Fatalf("rewritten file does not parse: %v, %v", err, out.XRefError())
+	}
+}
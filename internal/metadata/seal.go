@@ -0,0 +1,25 @@
+package metadata
+
+import "pdfmeta/internal/pdf"
+
+// sealer prepares an object read as src for writing as dst. For encrypted
+// documents it re-encrypts strings and stream data with the object key of
+// dst; otherwise it returns obj unchanged. src is the zero Ref for objects
+// that do not come from the document.
+type sealer func(src, dst pdf.Ref, obj pdf.Object) (pdf.Object, error)
+
+// documentSealer encrypts with doc's file key. The /Encrypt dictionary is
+// always written in clear.
+func documentSealer(doc *pdf.Document) sealer {
+	var encryptRef pdf.Ref
+	var indirect bool
+	if trailer, err := doc.Trailer(); err == nil {
+		encryptRef, indirect = trailer.Ref("Encrypt")
+	}
+	return func(src, dst pdf.Ref, obj pdf.Object) (pdf.Object, error) {
+		if indirect && src == encryptRef {
+			return obj, nil
+		}
+		return doc.EncryptObject(dst, obj)
+	}
+}
@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+func TestWriteEncryptedKeepsEncryption(t *testing.T) {
+	fixtures := []struct {
+		name string
+		user string
+	}{
+		{name: "encrypted-rc4-40.pdf"},
+		{name: "encrypted-rc4-128.pdf", user: "user"},
+		{name: "encrypted-aes-128.pdf"},
+		{name: "encrypted-aes-256-r5.pdf"},
+		{name: "encrypted-aes-256.pdf", user: "user"},
+	}
+	options := map[string]model.WriteOptions{
+		"incremental":    {},
+		"object streams": {ObjectStreams: true},
+		"rewrite":        {Rewrite: true},
+		"rewrite packed": {Rewrite: true, ObjectStreams: true},
+	}
+	title := "Updated Title"
+	for _, fx := range fixtures {
+		_, before := openTrailer(t, fixturePath(fx.name))
+		for mode, opts := range options {
+			path := copyFixture(t, fx.name)
+			opts.Password = "owner"
+			out := writeInPlace(t, path, opts, model.MetadataPatch{Title: &title})
+			if bytes.Contains(out, []byte(title)) {
+				t.Fatalf("%s (%s): title written in clear", fx.name, mode)
+			}
+
+			_, after := openTrailer(t, path)
+			for _, key := range []pdf.Name{"Encrypt", "ID"} {
+				if got, want := pdf.AppendObject(nil, after.Get(key)), pdf.AppendObject(nil, before.Get(key)); !bytes.Equal(got, want) {
+					t.Fatalf("%s (%s): /%s=%s want %s", fx.name, mode, key, got, want)
+				}
+			}
+
+			res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path, Password: fx.user})
+			if err != nil {
+				t.Fatalf("%s (%s): Read: %v", fx.name, mode, err)
+			}
+			if !res.Encrypted || res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != "Secret Author" || !res.XMPFound {
+				t.Fatalf("%s (%s): unexpected result after write: %+v", fx.name, mode, res)
+			}
+		}
+	}
+}
+
+func TestWriteEncryptedNeedsPassword(t *testing.T) {
+	title := "x"
+	for _, password := range []string{"", "wrong"} {
+		in := copyFixture(t, "encrypted-aes-256.pdf")
+		out := filepath.Join(t.TempDir(), "out.pdf")
+		_, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+			InputPath:  in,
+			OutputPath: out,
+			Options:    model.WriteOptions{Password: password},
+			Set:        model.MetadataPatch{Title: &title},
+		})
+		assertAppErrorCode(t, err, model.ErrPDFEncrypted)
+		if _, err := os.Stat(out); !os.IsNotExist(err) {
+			t.Fatalf("password %q: output written despite error", password)
+		}
+	}
+}
+
+func TestWriteClearMetadataWithoutPassword(t *testing.T) {
+	path := copyFixture(t, "encrypted-clear-metadata.pdf")
+	title := "Indexed Title"
+	res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Set:       model.MetadataPatch{Title: &title},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if res.InfoFound || len(res.Warnings) != 1 {
+		t.Fatalf("expected info left alone with one warning, got %+v", res)
+	}
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !bytes.Contains(out, []byte(title)) {
+		t.Fatalf("expected clear-text XMP with the new title")
+	}
+
+	locked, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if locked.Metadata.Title != title || locked.InfoFound || !locked.XMPFound {
+		t.Fatalf("unexpected result without password: %+v", locked)
+	}
+	unlocked, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path, Password: "user"})
+	if err != nil {
+		t.Fatalf("Read with password: %v", err)
+	}
+	if unlocked.Metadata.Value(model.FieldAuthor) != "Secret Author" || !unlocked.InfoFound {
+		t.Fatalf("encrypted info not kept: %+v", unlocked)
+	}
+}
+
+func TestWriteClearMetadataNeedsKeyToRewrite(t *testing.T) {
+	title := "x"
+	for _, opts := range []model.WriteOptions{{Rewrite: true}, {ObjectStreams: true}, {Password: "wrong"}} {
+		in := copyFixture(t, "encrypted-clear-metadata.pdf")
+		_, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+			InputPath:  in,
+			OutputPath: filepath.Join(t.TempDir(), "out.pdf"),
+			Options:    opts,
+			Set:        model.MetadataPatch{Title: &title},
+		})
+		assertAppErrorCode(t, err, model.ErrPDFEncrypted)
+	}
+}
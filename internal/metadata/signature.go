@@ -0,0 +1,51 @@
+package metadata
+
+import (
+	"fmt"
+
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// readSignatures lists doc's signed signature fields for show.
+func readSignatures(doc *pdf.Document) []model.Signature {
+	var out []model.Signature
+	for _, sig := range doc.Signatures() {
+		out = append(out, model.Signature{
+			Field:       sig.Field,
+			SubFilter:   sig.SubFilter,
+			ByteRange:   sig.ByteRange,
+			Covered:     sig.Covered(),
+			Name:        sig.Name,
+			Reason:      sig.Reason,
+			Location:    sig.Location,
+			SigningTime: sig.SigningTime,
+			Certifying:  sig.DocMDP != 0,
+		})
+	}
+	return out
+}
+
+// checkSignatures applies the write policy for signed documents. Existing
+// signatures stay valid only if the signed bytes are kept and the update is
+// appended, so modes that rewrite the file are refused outright. No DocMDP
+// level permits metadata changes (level 2 allows form filling and signing,
+// level 3 also annotations), so certified documents are refused unless
+// opts.Force is set.
+func checkSignatures(doc *pdf.Document, opts model.WriteOptions) ([]string, error) {
+	sigs := doc.Signatures()
+	level, certified := doc.DocMDP()
+	if len(sigs) == 0 && !certified {
+		return nil, nil
+	}
+	if opts.Rewrite || opts.Repair || opts.Linearized == model.LinearizedRelinearize {
+		return nil, &model.AppError{Code: model.ErrConflict, Message: "pdf is signed; --rewrite, --repair and --linearized relinearize would invalidate its signatures"}
+	}
+	if !certified {
+		return nil, nil
+	}
+	if !opts.Force {
+		return nil, &model.AppError{Code: model.ErrConflict, Message: fmt.Sprintf("pdf is certified with DocMDP level %d, which does not permit metadata changes; use --force to write anyway", level)}
+	}
+	return []string{fmt.Sprintf("pdf is certified with DocMDP level %d; the update invalidates the certification", level)}, nil
+}
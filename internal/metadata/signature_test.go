@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+// writeSignedPDF writes a one-page file whose signature field is signed over
+// the whole original file. A non-zero docMDP certifies it at that level.
+func writeSignedPDF(t *testing.T, docMDP int) (string, []byte) {
+	t.Helper()
+	perms := ""
+	reference := ""
+	if docMDP != 0 {
+		perms = " /Perms << /DocMDP 5 0 R >>"
+		reference = fmt.Sprintf(" /Reference [<< /Type /SigRef /TransformMethod /DocMDP /TransformParams << /Type /TransformParams /P %d /V /1.2 >> >>]", docMDP)
+	}
+	objs := []string{
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R] /SigFlags 3 >>" + perms + " >>\nendobj\n",
+		"2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n",
+		"3 0 obj\n<< /Type /Page /Parent 2 0 R /Annots [4 0 R] >>\nendobj\n",
+		"4 0 obj\n<< /FT /Sig /T (Signature1) /Subtype /Widget /Rect [0 0 0 0] /P 3 0 R /V 5 0 R >>\nendobj\n",
+		"5 0 obj\n<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached /ByteRange [0 0 0 0] /Contents <00>" + reference + " >>\nendobj\n",
+		"6 0 obj\n<< /Title (Signed) >>\nendobj\n",
+	}
+	body := "%PDF-1.7\n"
+	xref := "xref\n0 7\n0000000000 65535 f \n"
+	for _, o := range objs {
+		xref += fmt.Sprintf("%010d 00000 n \n", len(body))
+		body += o
+	}
+	content := body + xref + fmt.Sprintf("trailer\n<< /Size 7 /Root 1 0 R /Info 6 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(body))
+	path := writeTempPDF(t, "signed.pdf", content)
+	return path, []byte(content)
+}
+
+func TestWriteSignedAppendsUpdate(t *testing.T) {
+	path, orig := writeSignedPDF(t, 0)
+	title := "Updated"
+	out := writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+	if !bytes.HasPrefix(out, orig) {
+		t.Fatalf("signed bytes were modified")
+	}
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != title || !res.Structure.Signed || len(res.Structure.Signatures) != 1 || res.Structure.Signatures[0].Field != "Signature1" {
+		t.Fatalf("unexpected result after write: %+v", res.Structure)
+	}
+}
+
+func TestWriteSignedRefusesRewrite(t *testing.T) {
+	title := "x"
+	for _, opts := range []model.WriteOptions{
+		{Rewrite: true},
+		{Repair: true},
+		{Linearized: model.LinearizedRelinearize, Force: true},
+		{Rewrite: true, Force: true},
+	} {
+		path, _ := writeSignedPDF(t, 0)
+		_, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+			InputPath:  path,
+			OutputPath: filepath.Join(t.TempDir(), "out.pdf"),
+			Options:    opts,
+			Set:        model.MetadataPatch{Title: &title},
+		})
+		assertAppErrorCode(t, err, model.ErrConflict)
+	}
+}
+
+func TestWriteCertifiedPolicy(t *testing.T) {
+	title := "x"
+	tests := []struct {
+		level    int
+		force    bool
+		wantErr  bool
+		warnings int
+	}{
+		{level: 1, wantErr: true},
+		{level: 1, force: true, warnings: 1},
+		// Levels 2 and 3 allow form filling, signing and annotations, but
+		// not metadata changes.
+		{level: 2, wantErr: true},
+		{level: 2, force: true, warnings: 1},
+		{level: 3, wantErr: true},
+		{level: 3, force: true, warnings: 1},
+	}
+	for _, tc := range tests {
+		path, _ := writeSignedPDF(t, tc.level)
+		out := filepath.Join(t.TempDir(), "out.pdf")
+		res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+			InputPath:  path,
+			OutputPath: out,
+			Options:    model.WriteOptions{Force: tc.force},
+			Set:        model.MetadataPatch{Title: &title},
+		})
+		if tc.wantErr {
+			assertAppErrorCode(t, err, model.ErrConflict)
+			if _, err := os.Stat(out); !os.IsNotExist(err) {
+				t.Fatalf("level %d: output written despite error", tc.level)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("level %d force %t: Write: %v", tc.level, tc.force, err)
+		}
+		if len(res.Warnings) != tc.warnings {
+			t.Fatalf("level %d force %t: warnings=%q", tc.level, tc.force, res.Warnings)
+		}
+	}
+}
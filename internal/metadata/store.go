@@ -3,11 +3,11 @@ package metadata
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"regexp"
-	"sort"
-	"strconv"
+	"io"
 	"strings"
+	"unicode/utf8"
 
 	"pdfmeta/internal/filesafe"
 	"pdfmeta/internal/model"
@@ -23,24 +23,49 @@ func NewStore() *Store {
 
 var _ model.MetadataStore = (*Store)(nil)
 
-func (s *Store) Read(ctx context.Context, inputPath string) (model.MetadataReadResult, error) {
+func (s *Store) Read(ctx context.Context, req model.MetadataReadRequest) (model.MetadataReadResult, error) {
 	if err := ctxErr(ctx); err != nil {
 		return model.MetadataReadResult{}, err
 	}
 
-	doc, err := pdf.Open(inputPath)
+	doc, err := pdf.Open(req.InputPath)
 	if err != nil {
 		return model.MetadataReadResult{}, err
 	}
+	defer doc.Close()
+
+	// Without a password the file is still described, just not its
+	// encrypted strings, which would only decode as garbage.
+	var warnings []string
+	if err := doc.Unlock(req.Password); err != nil {
+		if req.Password != "" {
+			return model.MetadataReadResult{}, err
+		}
+		if clearMetadata(doc) {
+			warnings = append(warnings, fmt.Sprintf("info not decrypted: %v (use --password); metadata read from the unencrypted XMP stream", err))
+		} else {
+			warnings = append(warnings, fmt.Sprintf("metadata not decrypted: %v (use --password)", err))
+		}
+	}
 
-	meta, infoFound, xmpFound := readNativeMetadata(doc.Bytes())
+	return describeDocument(doc, warnings), nil
+}
+
+// describeDocument builds the show result for doc: its metadata, structure
+// and encryption.
+func describeDocument(doc *pdf.Document, warnings []string) model.MetadataReadResult {
+	meta, infoFound, xmpFound := readNativeMetadata(doc)
 	return model.MetadataReadResult{
 		Encrypted:  doc.Encrypted(),
+		Linearized: doc.Linearized(),
 		Metadata:   meta,
 		InfoFound:  infoFound,
 		XMPFound:   xmpFound,
 		Normalized: false,
-	}, nil
+		Warnings:   warnings,
+		Structure:  readStructure(doc),
+		Encryption: readEncryption(doc),
+	}
 }
 
 func (s *Store) Write(ctx context.Context, req model.MetadataWriteRequest) (model.MetadataReadResult, error) {
@@ -59,37 +84,125 @@ func (s *Store) Write(ctx context.Context, req model.MetadataWriteRequest) (mode
 	if err != nil {
 		return model.MetadataReadResult{}, err
 	}
-	if doc.Encrypted() {
-		return model.MetadataReadResult{}, &model.AppError{Code: model.ErrPDFEncrypted, Message: "cannot write encrypted pdf"}
+	defer doc.Close()
+	var report *model.RepairReport
+	if req.Options.Repair {
+		if report, err = rebuildDocument(doc); err != nil {
+			return model.MetadataReadResult{}, err
+		}
+	}
+	// Encrypted documents are written with their own key, so /Encrypt and
+	// /ID carry over unchanged. Without the key, a clear-text XMP stream
+	// can still be replaced; the encrypted Info dictionary is left alone.
+	var warnings []string
+	if err := doc.Unlock(req.Options.Password); err != nil {
+		if req.Options.Password != "" || !clearMetadata(doc) {
+			return model.MetadataReadResult{}, err
+		}
+		if req.Options.Rewrite || req.Options.Repair || req.Options.ObjectStreams || req.Options.Linearized == model.LinearizedRelinearize {
+			return model.MetadataReadResult{}, &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonPasswordRequired, Message: "--rewrite, --repair, --object-streams and --linearized relinearize need the document key; only the XMP stream can be updated without a password"}
+		}
+		warnings = append(warnings, "info dictionary left unchanged: it is encrypted and no password opened the file; only the XMP stream was updated")
 	}
 
-	current, _, _ := readNativeMetadata(doc.Bytes())
+	sigWarnings, err := checkSignatures(doc, req.Options)
+	if err != nil {
+		return model.MetadataReadResult{}, err
+	}
+	warnings = append(warnings, sigWarnings...)
+
+	current, _, _ := readNativeMetadata(doc)
 	next := applyPatch(current, req.Set)
 	next = applyUnset(next, req.Unset, req.UnsetAll)
 
-	xmpPacket, err := xmp.Marshal(next)
+	// PDF/A documents keep their identification, and Info and XMP carry
+	// equivalent values with dates in each one's own syntax.
+	infoMeta, xmpMeta := next, next
+	id, pdfa := readPDFA(doc)
+	if pdfa {
+		problems := pdfaProblems(doc, id, next, req.Options)
+		if len(problems) > 0 && req.Options.PDFA {
+			return model.MetadataReadResult{}, &model.AppError{Code: model.ErrConflict, Message: fmt.Sprintf("write would break %s conformance: %s", id, strings.Join(problems, "; "))}
+		}
+		for _, p := range problems {
+			warnings = append(warnings, fmt.Sprintf("%s conformance broken: %s (use --pdfa to refuse)", id, p))
+		}
+		infoMeta, xmpMeta = pdfaViews(next)
+	}
+	// An existing packet is updated in place so properties pdfmeta does
+	// not manage survive; one that cannot be parsed is replaced, keeping
+	// the PDF/A identification.
+	var xmpPacket []byte
+	if packet, ok := metadataPacket(doc); ok {
+		if xmpPacket, err = xmp.Update(packet, xmpMeta); err != nil {
+			warnings = append(warnings, fmt.Sprintf("existing XMP packet replaced: %v", err))
+		}
+	}
+	if xmpPacket == nil {
+		if pdfa {
+			xmpPacket, err = xmp.MarshalPDFA(xmpMeta, id)
+		} else {
+			xmpPacket, err = xmp.Marshal(xmpMeta)
+		}
+	}
 	if err != nil {
 		return model.MetadataReadResult{}, &model.AppError{Code: model.ErrInternal, Message: "encode xmp packet", Cause: err}
 	}
 
-	updated, err := writeNativeIncremental(doc.Bytes(), next, xmpPacket)
+	// A rebuilt index has no xref chain to append to, so repair implies a
+	// full rewrite.
+	var write nativeWriter = writeNativeIncremental
+	if req.Options.Rewrite || req.Options.Repair {
+		write = writeNativeRewrite
+	}
+	if doc.Linearized() {
+		switch req.Options.Linearized {
+		case model.LinearizedRefuse:
+			return model.MetadataReadResult{}, &model.AppError{Code: model.ErrConflict, Message: "pdf is linearized; writing would invalidate fast web view (use --linearized relinearize or warn)"}
+		case model.LinearizedRelinearize:
+			write = writeNativeLinearized
+		default:
+			warnings = append(warnings, "pdf was linearized; the output is no longer optimized for fast web view (use --linearized relinearize to keep it)")
+		}
+	}
+	updated, err := write(doc, infoMeta, xmpPacket, req.Options)
 	if err != nil {
 		return model.MetadataReadResult{}, err
 	}
 
-	if err := filesafe.WriteAtomic(dst, updated, 0o644); err != nil {
-		return model.MetadataReadResult{}, &model.AppError{Code: model.ErrIO, Message: fmt.Sprintf("write %q", dst), Cause: err}
+	if err := writeOutput(dst, updated); err != nil {
+		return model.MetadataReadResult{}, err
 	}
 
 	return model.MetadataReadResult{
-		Encrypted:  false,
+		Encrypted:  doc.Encrypted(),
+		Linearized: req.Options.Linearized == model.LinearizedRelinearize && doc.Linearized(),
 		Metadata:   next,
-		InfoFound:  true,
+		InfoFound:  !doc.Encrypted() || doc.Unlocked(),
 		XMPFound:   true,
 		Normalized: false,
+		Warnings:   warnings,
+		Repair:     report,
+		Encryption: readEncryption(doc),
 	}, nil
 }
 
+// nativeWriter produces the complete output file for a metadata update.
+type nativeWriter func(doc *pdf.Document, meta model.Metadata, xmpPacket []byte, opts model.WriteOptions) (io.Reader, error)
+
+// writeOutput copies a writer's output atomically to dst. Streamed writers
+// serialize while they are read, so their errors surface here as they are.
+func writeOutput(dst string, r io.Reader) error {
+	if err := filesafe.WriteAtomicFromReader(dst, r, 0o644); err != nil {
+		var appErr *model.AppError
+		if errors.As(err, &appErr) {
+			return appErr
+		}
+		return &model.AppError{Code: model.ErrIO, Message: fmt.Sprintf("write %q", dst), Cause: err}
+	}
+	return nil
+}
+
 func ctxErr(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
@@ -114,14 +227,14 @@ func applyPatch(cur model.Metadata, patch model.MetadataPatch) model.Metadata {
 	if patch.Title != nil {
 		next.Title = *patch.Title
 	}
-	if patch.Author != nil {
-		next.Author = *patch.Author
+	if patch.Authors != nil {
+		next.Authors = listValue(patch.Authors)
 	}
 	if patch.Subject != nil {
 		next.Subject = *patch.Subject
 	}
 	if patch.Keywords != nil {
-		next.Keywords = *patch.Keywords
+		next.Keywords = listValue(patch.Keywords)
 	}
 	if patch.Creator != nil {
 		next.Creator = *patch.Creator
@@ -138,6 +251,14 @@ func applyPatch(cur model.Metadata, patch model.MetadataPatch) model.Metadata {
 	return next
 }
 
+// listValue copies a patch list, keeping empty lists nil.
+func listValue(l model.List) []string {
+	if len(l) == 0 {
+		return nil
+	}
+	return append([]string(nil), l...)
+}
+
 func applyUnset(cur model.Metadata, fields []model.Field, unsetAll bool) model.Metadata {
 	if unsetAll {
 		return model.Metadata{}
@@ -148,11 +269,11 @@ func applyUnset(cur model.Metadata, fields []model.Field, unsetAll bool) model.M
 		case model.FieldTitle:
 			next.Title = ""
 		case model.FieldAuthor:
-			next.Author = ""
+			next.Authors = nil
 		case model.FieldSubject:
 			next.Subject = ""
 		case model.FieldKeywords:
-			next.Keywords = ""
+			next.Keywords = nil
 		case model.FieldCreator:
 			next.Creator = ""
 		case model.FieldProducer:
@@ -166,14 +287,16 @@ func applyUnset(cur model.Metadata, fields []model.Field, unsetAll bool) model.M
 	return next
 }
 
-type objRef struct {
-	Obj int
-	Gen int
-}
-
-func readNativeMetadata(b []byte) (model.Metadata, bool, bool) {
-	rootRef, infoRef, ok := parseTrailerRefs(b)
-	if !ok {
+// readNativeMetadata merges the Info dictionary and the XMP packet: Info
+// wins for single values, XMP for the author and keyword lists. Without
+// the key of an encrypted document, Info is skipped and XMP is only read when
+// it is stored in clear.
+func readNativeMetadata(doc *pdf.Document) (model.Metadata, bool, bool) {
+	trailer, err := doc.Trailer()
+	if err != nil {
+		return model.Metadata{}, false, false
+	}
+	if _, ok := trailer.Ref("Root"); !ok {
 		return model.Metadata{}, false, false
 	}
 
@@ -181,239 +304,109 @@ func readNativeMetadata(b []byte) (model.Metadata, bool, bool) {
 	infoFound := false
 	xmpFound := false
 
-	if infoRef.Obj > 0 {
-		if body, ok := objectBody(b, infoRef.Obj, infoRef.Gen); ok {
-			if dict, ok := firstDict(body); ok {
-				meta = mergeMetadata(parseInfoDict(dict), meta)
-				infoFound = true
+	locked := doc.Encrypted() && !doc.Unlocked()
+	var rawUTF8 map[pdf.Name]string
+	if info, ok := resolveDict(doc, trailer.Get("Info")); ok && !locked {
+		meta = mergeMetadata(parseInfoDict(doc, info), meta)
+		rawUTF8 = unmarkedUTF8Strings(doc, info)
+		infoFound = true
+	}
+
+	if packet, ok := metadataPacket(doc); ok {
+		if x, err := xmp.Unmarshal(packet); err == nil {
+			meta = mergeMetadata(meta, x)
+			// Info holds a list as one joined string that cannot always
+			// be split back, so the XMP arrays win when present.
+			if len(x.Authors) > 0 {
+				meta.Authors = x.Authors
 			}
-		}
-	}
-
-	if rootRef.Obj > 0 {
-		if rootBody, ok := objectBody(b, rootRef.Obj, rootRef.Gen); ok {
-			if rootDict, ok := firstDict(rootBody); ok {
-				if mdRef, ok := parseNamedRef(rootDict, "Metadata"); ok {
-					if mdBody, ok := objectBody(b, mdRef.Obj, mdRef.Gen); ok {
-						if stream, ok := streamContent(mdBody); ok {
-							if x, err := xmp.Unmarshal(stream); err == nil {
-								meta = mergeMetadata(meta, x)
-								xmpFound = true
-							}
-						}
-					}
+			if len(x.Keywords) > 0 {
+				meta.Keywords = x.Keywords
+			}
+			// Info strings read as PDFDocEncoding, but earlier pdfmeta
+			// versions wrote raw UTF-8 without a byte order mark. When XMP
+			// agrees with the UTF-8 reading, that is what was meant.
+			for key, f := range map[pdf.Name]struct {
+				dst *string
+				xmp string
+			}{
+				"Title":    {&meta.Title, x.Title},
+				"Subject":  {&meta.Subject, x.Subject},
+				"Creator":  {&meta.Creator, x.Creator},
+				"Producer": {&meta.Producer, x.Producer},
+			} {
+				if u, ok := rawUTF8[key]; ok && u == f.xmp {
+					*f.dst = u
 				}
 			}
+			xmpFound = true
 		}
 	}
 
 	return meta, infoFound, xmpFound
 }
 
-func writeNativeIncremental(src []byte, meta model.Metadata, xmpPacket []byte) ([]byte, error) {
-	rootRef, _, ok := parseTrailerRefs(src)
-	if !ok {
-		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not parse trailer root reference"}
-	}
-	startXRef, ok := parseStartXRef(src)
-	if !ok {
-		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not parse startxref"}
-	}
-
-	maxObj := maxObjectNumber(src)
-	if maxObj < 1 {
-		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not detect object numbers"}
-	}
-
-	rootBody, ok := objectBody(src, rootRef.Obj, rootRef.Gen)
-	if !ok {
-		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "could not read catalog object"}
-	}
-	rootDict, ok := firstDict(rootBody)
-	if !ok {
-		return nil, &model.AppError{Code: model.ErrPDFMalformed, Message: "catalog dictionary missing"}
-	}
-
-	infoObj := maxObj + 1
-	metadataObj := maxObj + 2
-	catalogObj := maxObj + 3
-
-	newCatalogDict := upsertNamedRef(rootDict, "Metadata", objRef{Obj: metadataObj, Gen: 0})
-
-	infoObject := renderInfoObject(infoObj, meta)
-	metadataObject := renderMetadataObject(metadataObj, xmpPacket)
-	catalogObject := renderCatalogObject(catalogObj, newCatalogDict)
-
-	out := append([]byte(nil), src...)
-	if len(out) > 0 && out[len(out)-1] != '\n' {
-		out = append(out, '\n')
-	}
-
-	offInfo := len(out)
-	out = append(out, infoObject...)
-	offMetadata := len(out)
-	out = append(out, metadataObject...)
-	offCatalog := len(out)
-	out = append(out, catalogObject...)
-
-	xrefOffset := len(out)
-	xref := renderXRef(infoObj, []int{offInfo, offMetadata, offCatalog})
-	out = append(out, xref...)
-
-	trailer := renderTrailer(catalogObj+1, objRef{Obj: catalogObj, Gen: 0}, objRef{Obj: infoObj, Gen: 0}, startXRef)
-	out = append(out, trailer...)
-	out = append(out, []byte("startxref\n")...)
-	out = append(out, []byte(strconv.Itoa(xrefOffset)+"\n")...)
-	out = append(out, []byte("%%EOF\n")...)
-
-	return out, nil
-}
-
-func parseTrailerRefs(b []byte) (objRef, objRef, bool) {
-	trailer := lastTrailerDict(b)
-	if trailer == "" {
-		return objRef{}, objRef{}, false
-	}
-	root, ok := parseNamedRef(trailer, "Root")
-	if !ok {
-		return objRef{}, objRef{}, false
-	}
-	info, _ := parseNamedRef(trailer, "Info")
-	return root, info, true
-}
-
-func lastTrailerDict(b []byte) string {
-	s := string(b)
-	idx := strings.LastIndex(s, "trailer")
-	if idx < 0 {
-		return ""
+// metadataPacket returns the decoded catalog /Metadata stream, when it can
+// be read: encrypted documents need their key unless the stream is stored
+// in clear.
+func metadataPacket(doc *pdf.Document) ([]byte, bool) {
+	if doc.Encrypted() && !doc.Unlocked() && !clearMetadata(doc) {
+		return nil, false
 	}
-	tail := s[idx+len("trailer"):]
-	start := strings.Index(tail, "<<")
-	if start < 0 {
-		return ""
+	trailer, err := doc.Trailer()
+	if err != nil {
+		return nil, false
 	}
-	absStart := idx + len("trailer") + start
-	end, ok := matchDictEnd(s, absStart)
+	root, ok := resolveDict(doc, trailer.Get("Root"))
 	if !ok {
-		return ""
-	}
-	return s[absStart : end+2]
-}
-
-func matchDictEnd(s string, start int) (int, bool) {
-	depth := 0
-	for i := start; i < len(s)-1; i++ {
-		if s[i] == '<' && s[i+1] == '<' {
-			depth++
-			i++
-			continue
-		}
-		if s[i] == '>' && s[i+1] == '>' {
-			depth--
-			if depth == 0 {
-				return i, true
-			}
-			i++
-		}
-	}
-	return 0, false
-}
-
-func parseNamedRef(dict, name string) (objRef, bool) {
-	re := regexp.MustCompile(`/` + regexp.QuoteMeta(name) + `\s+(\d+)\s+(\d+)\s+R`)
-	m := re.FindStringSubmatch(dict)
-	if len(m) != 3 {
-		return objRef{}, false
-	}
-	o, _ := strconv.Atoi(m[1])
-	g, _ := strconv.Atoi(m[2])
-	return objRef{Obj: o, Gen: g}, true
-}
-
-func parseStartXRef(b []byte) (int, bool) {
-	s := string(b)
-	idx := strings.LastIndex(s, "startxref")
-	if idx < 0 {
-		return 0, false
-	}
-	rest := strings.TrimSpace(s[idx+len("startxref"):])
-	if rest == "" {
-		return 0, false
-	}
-	line := rest
-	if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
-		line = strings.TrimSpace(rest[:nl])
+		return nil, false
 	}
-	n, err := strconv.Atoi(line)
+	obj, err := doc.Resolve(root.Get("Metadata"))
 	if err != nil {
-		return 0, false
-	}
-	return n, true
-}
-
-func maxObjectNumber(b []byte) int {
-	re := regexp.MustCompile(`(?m)(\d+)\s+(\d+)\s+obj\b`)
-	all := re.FindAllSubmatch(b, -1)
-	max := 0
-	for _, m := range all {
-		n, err := strconv.Atoi(string(m[1]))
-		if err == nil && n > max {
-			max = n
-		}
+		return nil, false
 	}
-	return max
-}
-
-func objectBody(b []byte, obj, gen int) (string, bool) {
-	pattern := fmt.Sprintf(`(?s)(^|[\r\n])%d\s+%d\s+obj\b(.*?)\bendobj`, obj, gen)
-	re := regexp.MustCompile(pattern)
-	m := re.FindSubmatch(b)
-	if len(m) != 3 {
-		return "", false
+	stream, ok := obj.(pdf.Stream)
+	if !ok {
+		return nil, false
 	}
-	return string(m[2]), true
+	packet, err := doc.DecodeStream(stream)
+	return packet, err == nil
 }
 
-func firstDict(body string) (string, bool) {
-	start := strings.Index(body, "<<")
-	if start < 0 {
-		return "", false
-	}
-	end, ok := matchDictEnd(body, start)
-	if !ok {
-		return "", false
-	}
-	return body[start : end+2], true
+// clearMetadata reports whether an encrypted document stores its XMP
+// stream unencrypted (/EncryptMetadata false).
+func clearMetadata(doc *pdf.Document) bool {
+	sec, err := doc.Security()
+	return err == nil && !sec.EncryptMetadata
 }
 
-func streamContent(objBody string) ([]byte, bool) {
-	i := strings.Index(objBody, "stream")
-	if i < 0 {
+func resolveDict(doc *pdf.Document, obj pdf.Object) (pdf.Dict, bool) {
+	if obj == nil {
 		return nil, false
 	}
-	j := strings.Index(objBody[i+6:], "endstream")
-	if j < 0 {
+	v, err := doc.Resolve(obj)
+	if err != nil {
 		return nil, false
 	}
-	start := i + 6
-	for start < len(objBody) && (objBody[start] == '\n' || objBody[start] == '\r') {
-		start++
-	}
-	end := i + 6 + j
-	content := strings.TrimRight(objBody[start:end], "\r\n")
-	return []byte(content), true
+	dict, ok := v.(pdf.Dict)
+	return dict, ok
 }
 
-func parseInfoDict(dict string) model.Metadata {
-	get := func(key string) string {
-		return decodePDFString(findDictValue(dict, key))
+// parseInfoDict reads the standard Info entries. Values may be indirect
+// references to string objects; unresolvable ones read as empty.
+func parseInfoDict(doc *pdf.Document, dict pdf.Dict) model.Metadata {
+	get := func(key pdf.Name) string {
+		v, err := doc.Resolve(dict.Get(key))
+		if err != nil {
+			return ""
+		}
+		return decodePDFString(v)
 	}
 	return model.Metadata{
 		Title:        get("Title"),
-		Author:       get("Author"),
+		Authors:      model.SplitAuthors(get("Author")),
 		Subject:      get("Subject"),
-		Keywords:     get("Keywords"),
+		Keywords:     model.SplitKeywords(get("Keywords")),
 		Creator:      get("Creator"),
 		Producer:     get("Producer"),
 		CreationDate: get("CreationDate"),
@@ -421,44 +414,36 @@ func parseInfoDict(dict string) model.Metadata {
 	}
 }
 
-func findDictValue(dict, key string) string {
-	re := regexp.MustCompile(`/` + regexp.QuoteMeta(key) + `\s+(\((?:\\.|[^\\)])*\)|<[^>]*>|/[^\s/<>\[\]()]+)`)
-	m := re.FindStringSubmatch(dict)
-	if len(m) != 2 {
-		return ""
+// unmarkedUTF8Strings returns the single-valued Info strings without a
+// byte order mark that are valid UTF-8 with multi-byte characters, read as
+// UTF-8.
+func unmarkedUTF8Strings(doc *pdf.Document, dict pdf.Dict) map[pdf.Name]string {
+	out := map[pdf.Name]string{}
+	for _, key := range []pdf.Name{"Title", "Subject", "Creator", "Producer"} {
+		v, err := doc.Resolve(dict.Get(key))
+		if err != nil {
+			continue
+		}
+		str, ok := v.(pdf.String)
+		if !ok || bytes.HasPrefix(str.Bytes, []byte{0xEF, 0xBB, 0xBF}) || !utf8.Valid(str.Bytes) {
+			continue
+		}
+		if s := string(str.Bytes); strings.IndexFunc(s, func(r rune) bool { return r >= utf8.RuneSelf }) >= 0 {
+			out[key] = s
+		}
 	}
-	return m[1]
+	return out
 }
 
-func decodePDFString(raw string) string {
-	raw = strings.TrimSpace(raw)
-	if raw == "" {
+func decodePDFString(obj pdf.Object) string {
+	switch v := obj.(type) {
+	case pdf.String:
+		return pdf.DecodeText(v.Bytes)
+	case pdf.Name:
+		return string(v)
+	default:
 		return ""
 	}
-	if strings.HasPrefix(raw, "(") && strings.HasSuffix(raw, ")") && len(raw) >= 2 {
-		s := raw[1 : len(raw)-1]
-		replacer := strings.NewReplacer(`\\`, `\`, `\(`, `(`, `\)`, `)`, `\n`, "\n", `\r`, "\r", `\t`, "\t")
-		return replacer.Replace(s)
-	}
-	if strings.HasPrefix(raw, "<") && strings.HasSuffix(raw, ">") {
-		hex := raw[1 : len(raw)-1]
-		if len(hex)%2 == 1 {
-			hex += "0"
-		}
-		buf := make([]byte, 0, len(hex)/2)
-		for i := 0; i+1 < len(hex); i += 2 {
-			v, err := strconv.ParseUint(hex[i:i+2], 16, 8)
-			if err != nil {
-				return ""
-			}
-			buf = append(buf, byte(v))
-		}
-		return string(buf)
-	}
-	if strings.HasPrefix(raw, "/") {
-		return strings.TrimPrefix(raw, "/")
-	}
-	return raw
 }
 
 func mergeMetadata(primary model.Metadata, fallback model.Metadata) model.Metadata {
@@ -466,13 +451,13 @@ func mergeMetadata(primary model.Metadata, fallback model.Metadata) model.Metada
 	if out.Title == "" {
 		out.Title = fallback.Title
 	}
-	if out.Author == "" {
-		out.Author = fallback.Author
+	if len(out.Authors) == 0 {
+		out.Authors = fallback.Authors
 	}
 	if out.Subject == "" {
 		out.Subject = fallback.Subject
 	}
-	if out.Keywords == "" {
+	if len(out.Keywords) == 0 {
 		out.Keywords = fallback.Keywords
 	}
 	if out.Creator == "" {
@@ -490,85 +475,37 @@ func mergeMetadata(primary model.Metadata, fallback model.Metadata) model.Metada
 	return out
 }
 
-func upsertNamedRef(dict, key string, ref objRef) string {
-	replacement := fmt.Sprintf(`/%s %d %d R`, key, ref.Obj, ref.Gen)
-	re := regexp.MustCompile(`/` + regexp.QuoteMeta(key) + `\s+\d+\s+\d+\s+R`)
-	if re.MatchString(dict) {
-		return re.ReplaceAllString(dict, replacement)
-	}
-	idx := strings.LastIndex(dict, ">>")
-	if idx < 0 {
-		return dict
-	}
-	insert := "\n" + replacement + "\n"
-	return dict[:idx] + insert + dict[idx:]
-}
-
-func renderInfoObject(objNr int, m model.Metadata) []byte {
-	type kv struct{ k, v string }
-	entries := []kv{
+// renderInfoObject returns prev with its standard entries set to m; empty
+// values are removed. Other entries, such as /Trapped or custom keys, are
+// kept.
+func renderInfoObject(prev pdf.Dict, m model.Metadata) pdf.Dict {
+	entries := []struct {
+		key   pdf.Name
+		value string
+	}{
 		{"Title", m.Title},
-		{"Author", m.Author},
+		{"Author", model.JoinAuthors(m.Authors)},
 		{"Subject", m.Subject},
-		{"Keywords", m.Keywords},
+		{"Keywords", model.JoinKeywords(m.Keywords)},
 		{"Creator", m.Creator},
 		{"Producer", m.Producer},
 		{"CreationDate", m.CreationDate},
 		{"ModDate", m.ModDate},
 	}
-	sort.SliceStable(entries, func(i, j int) bool { return entries[i].k < entries[j].k })
-
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf("%d 0 obj\n<<", objNr))
+	dict := prev.Clone()
 	for _, e := range entries {
-		if strings.TrimSpace(e.v) == "" {
+		if strings.TrimSpace(e.value) == "" {
+			delete(dict, e.key)
 			continue
 		}
-		b.WriteString(fmt.Sprintf("\n/%s (%s)", e.k, escapePDFLiteral(e.v)))
+		dict[e.key] = pdf.EncodeText(e.value)
 	}
-	b.WriteString("\n>>\nendobj\n")
-	return []byte(b.String())
+	return dict
 }
 
-func renderMetadataObject(objNr int, packet []byte) []byte {
-	var b strings.Builder
-	b.WriteString(fmt.Sprintf("%d 0 obj\n", objNr))
-	b.WriteString(fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\n", len(packet)))
-	b.WriteString("stream\n")
-	b.Write(packet)
-	if !bytes.HasSuffix(packet, []byte("\n")) {
-		b.WriteString("\n")
-	}
-	b.WriteString("endstream\nendobj\n")
-	return []byte(b.String())
-}
-
-func renderCatalogObject(objNr int, dict string) []byte {
-	return []byte(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", objNr, strings.TrimSpace(dict)))
-}
-
-func renderXRef(startObj int, offsets []int) []byte {
-	var b strings.Builder
-	b.WriteString("xref\n")
-	b.WriteString(fmt.Sprintf("%d %d\n", startObj, len(offsets)))
-	for _, off := range offsets {
-		b.WriteString(fmt.Sprintf("%010d 00000 n \n", off))
+func renderMetadataObject(packet []byte) pdf.Stream {
+	return pdf.Stream{
+		Dict: pdf.Dict{"Type": pdf.Name("Metadata"), "Subtype": pdf.Name("XML")},
+		Data: packet,
 	}
-	return []byte(b.String())
-}
-
-func renderTrailer(size int, root objRef, info objRef, prev int) []byte {
-	return []byte(fmt.Sprintf("trailer\n<< /Size %d /Root %d %d R /Info %d %d R /Prev %d >>\n", size, root.Obj, root.Gen, info.Obj, info.Gen, prev))
-}
-
-func escapePDFLiteral(s string) string {
-	replacer := strings.NewReplacer(
-		`\\`, `\\\\`,
-		`(`, `\\(`,
-		`)`, `\\)`,
-		"\n", `\\n`,
-		"\r", `\\r`,
-		"\t", `\\t`,
-	)
-	return replacer.Replace(s)
 }
@@ -3,12 +3,17 @@ package metadata
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"testing"
 
 	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+	"pdfmeta/internal/xmp"
 )
 
 func fixturePath(name string) string {
@@ -31,7 +36,7 @@ func copyFixture(t *testing.T, name string) string {
 
 func TestReadPlainFixture(t *testing.T) {
 	store := NewStore()
-	res, err := store.Read(context.Background(), fixturePath("minimal.pdf"))
+	res, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("minimal.pdf")})
 	if err != nil {
 		t.Fatalf("Read: %v", err)
 	}
@@ -45,7 +50,7 @@ func TestReadPlainFixture(t *testing.T) {
 
 func TestReadEncryptedFixture(t *testing.T) {
 	store := NewStore()
-	res, err := store.Read(context.Background(), fixturePath("encrypted-marker.pdf"))
+	res, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("encrypted-marker.pdf")})
 	if err != nil {
 		t.Fatalf("Read: %v", err)
 	}
@@ -54,6 +59,365 @@ func TestReadEncryptedFixture(t *testing.T) {
 	}
 }
 
+func TestReadDecryptsWithPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{name: "encrypted-rc4-40.pdf"},
+		{name: "encrypted-aes-128.pdf", password: "owner"},
+		{name: "encrypted-aes-256.pdf", password: "user"},
+	}
+	for _, tc := range tests {
+		res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath(tc.name), Password: tc.password})
+		if err != nil {
+			t.Fatalf("%s: Read: %v", tc.name, err)
+		}
+		if !res.Encrypted || res.Metadata.Title != "Secret Title" || res.Metadata.Value(model.FieldAuthor) != "Secret Author" {
+			t.Fatalf("%s: unexpected result: %+v", tc.name, res)
+		}
+		if !res.XMPFound {
+			t.Fatalf("%s: expected decrypted XMP packet", tc.name)
+		}
+	}
+}
+
+func TestReadEncryptedNeedsPassword(t *testing.T) {
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("encrypted-aes-256.pdf")})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !res.Metadata.Equal(model.Metadata{}) || res.InfoFound || len(res.Warnings) != 1 {
+		t.Fatalf("expected no metadata and one warning, got %+v", res)
+	}
+
+	_, err = NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("encrypted-aes-256.pdf"), Password: "wrong"})
+	assertAppErrorCode(t, err, model.ErrPDFEncrypted)
+}
+
+func TestReadClearMetadataWithoutPassword(t *testing.T) {
+	// The second fixture also marks the clear stream with an Identity
+	// /Crypt filter.
+	for _, name := range []string{"encrypted-clear-metadata.pdf", "encrypted-crypt-filter-metadata.pdf"} {
+		res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath(name)})
+		if err != nil {
+			t.Fatalf("%s: Read: %v", name, err)
+		}
+		if res.Metadata.Title != "Clear Title" || res.Metadata.Value(model.FieldAuthor) != "" || !res.XMPFound || res.InfoFound || len(res.Warnings) != 1 {
+			t.Fatalf("%s: expected clear XMP only and one warning, got %+v", name, res)
+		}
+		res, err = NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath(name), Password: "user"})
+		if err != nil || !res.InfoFound || !res.XMPFound {
+			t.Fatalf("%s: Read with password=%+v, %v", name, res, err)
+		}
+	}
+}
+
+func writeTempPDF(t *testing.T, name string, content string) string {
+	t.Helper()
+	dst := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(dst, []byte(content), 0o644); err != nil {
+		t.Fatalf("write temp pdf: %v", err)
+	}
+	return dst
+}
+
+func TestReadInfoWithNestedSyntax(t *testing.T) {
+	path := writeTempPDF(t, "nested.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R /ViewerPreferences << /HideToolbar true >> >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n"+
+		"3 0 obj\n<< % producer comment /Title (fake)\n"+
+		"/Title (Report (final) endobj)\n"+
+		"/Extra << /Nested [1 2 << /Deep (x) >>] >>\n"+
+		"/Author\n  (Multi\n  Line)\n"+
+		"/Keywords <6B 65 79>\n"+
+		"/Subject (Caf\\351 \\(menu\\)\\\nlist)\n"+
+		">>\nendobj\n"+
+		"trailer\n<< /Root 1 0 R /Info 3 0 R /Size 4 >>\nstartxref\n0\n%%EOF\n")
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !res.InfoFound {
+		t.Fatalf("expected info metadata")
+	}
+	if res.Metadata.Title != "Report (final) endobj" {
+		t.Fatalf("Title=%q", res.Metadata.Title)
+	}
+	if res.Metadata.Value(model.FieldAuthor) != "Multi\n  Line" {
+		t.Fatalf("Author=%q", res.Metadata.Authors)
+	}
+	if res.Metadata.Value(model.FieldKeywords) != "key" {
+		t.Fatalf("Keywords=%q", res.Metadata.Keywords)
+	}
+	if res.Metadata.Subject != "Café (menu)list" {
+		t.Fatalf("Subject=%q", res.Metadata.Subject)
+	}
+}
+
+func TestReadResolvesIndirectValues(t *testing.T) {
+	packet, err := xmp.Marshal(model.Metadata{Subject: "From XMP"})
+	if err != nil {
+		t.Fatalf("xmp.Marshal: %v", err)
+	}
+	path := writeTempPDF(t, "indirect.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R /Metadata 7 0 R >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n"+
+		"3 0 obj\n<< /Title 4 0 R /Author 5 0 R /Keywords 9 0 R >>\nendobj\n"+
+		"4 0 obj\n(Indirect Title)\nendobj\n"+
+		"5 0 obj\n6 0 R\nendobj\n"+
+		"6 0 obj\n<496E6469726563742041757468>\nendobj\n"+
+		"7 0 obj\n<< /Type /Metadata /Subtype /XML /Length 8 0 R >>\nstream\n"+string(packet)+"\nendstream\nendobj\n"+
+		fmt.Sprintf("8 0 obj\n%d\nendobj\n", len(packet))+
+		"trailer\n<< /Root 1 0 R /Info 3 0 R /Size 9 >>\nstartxref\n0\n%%EOF\n")
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := model.Metadata{Title: "Indirect Title", Authors: []string{"Indirect Auth"}, Subject: "From XMP"}
+	if !res.Metadata.Equal(want) || !res.InfoFound || !res.XMPFound {
+		t.Fatalf("Read()=%#v want %#v", res, want)
+	}
+
+	// The rewritten Info object must carry the resolved values.
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Keywords: model.List{"k"}})
+	res, err = NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read after write: %v", err)
+	}
+	want.Keywords = []string{"k"}
+	if !res.Metadata.Equal(want) {
+		t.Fatalf("Read() after write=%#v want %#v", res.Metadata, want)
+	}
+}
+
+func TestWritePreservesNestedCatalogEntries(t *testing.T) {
+	in := writeTempPDF(t, "catalog.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R /Names << /Dests << /Kids [4 0 R] >> >> /Lang (en) >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n"+
+		"trailer\n<< /Root 1 0 R /Size 3 >>\nstartxref\n0\n%%EOF\n")
+	out := filepath.Join(t.TempDir(), "out.pdf")
+
+	title := "T"
+	if _, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath:  in,
+		OutputPath: out,
+		Set:        model.MetadataPatch{Title: &title},
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("read output: %v", err)
+	}
+	if !bytes.Contains(b, []byte("/Names << /Dests << /Kids [4 0 R] >> >>")) {
+		t.Fatalf("expected nested catalog entries to be preserved:\n%s", b)
+	}
+	if !bytes.Contains(b, []byte("/Lang (en)")) {
+		t.Fatalf("expected catalog /Lang to be preserved")
+	}
+}
+
+func TestReadIncrementallyUpdatedFixture(t *testing.T) {
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("incremental-updates.pdf")})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != "Revision 3" || res.Metadata.Value(model.FieldAuthor) != "Second Author" {
+		t.Fatalf("expected newest revision metadata, got %#v", res.Metadata)
+	}
+}
+
+func TestReadXRefStreamFixture(t *testing.T) {
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("xref-stream.pdf")})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !res.InfoFound {
+		t.Fatalf("expected info metadata in xref stream fixture")
+	}
+	if res.Metadata.Title != "XRef Stream Title" || res.Metadata.Producer != "fixture" {
+		t.Fatalf("unexpected metadata: %#v", res.Metadata)
+	}
+}
+
+func TestReadAndWriteObjectStreamFixture(t *testing.T) {
+	store := NewStore()
+	path := copyFixture(t, "object-streams.pdf")
+
+	res, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !res.InfoFound || res.Metadata.Title != "Object Stream Title" || res.Metadata.Value(model.FieldAuthor) != "Packed Author" {
+		t.Fatalf("unexpected metadata from object stream: %#v", res)
+	}
+
+	title := "Rewritten"
+	if _, err := store.Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Set:       model.MetadataPatch{Title: &title},
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	doc, err := pdf.Open(path)
+	if err != nil {
+		t.Fatalf("pdf.Open: %v", err)
+	}
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	root, err := doc.Resolve(trailer.Get("Root"))
+	if err != nil {
+		t.Fatalf("Resolve(Root): %v", err)
+	}
+	if _, ok := root.(pdf.Dict).Ref("Pages"); !ok {
+		t.Fatalf("rewritten catalog lost /Pages: %#v", root)
+	}
+
+	res, err = store.Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read after write: %v", err)
+	}
+	if res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != "Packed Author" || !res.XMPFound {
+		t.Fatalf("unexpected metadata after write: %#v", res)
+	}
+}
+
+func TestReadAndWriteCompressedXMPFixture(t *testing.T) {
+	store := NewStore()
+	path := copyFixture(t, "flate-metadata.pdf")
+
+	res, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !res.XMPFound || res.InfoFound {
+		t.Fatalf("expected xmp-only metadata, got %#v", res)
+	}
+	if res.Metadata.Title != "Compressed XMP Title" || res.Metadata.Value(model.FieldAuthor) != "XMP Author" {
+		t.Fatalf("unexpected metadata from compressed xmp: %#v", res.Metadata)
+	}
+
+	subject := "Added"
+	if _, err := store.Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Set:       model.MetadataPatch{Subject: &subject},
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	res, err = store.Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read after write: %v", err)
+	}
+	if res.Metadata.Title != "Compressed XMP Title" || res.Metadata.Subject != subject {
+		t.Fatalf("write lost compressed xmp values: %#v", res.Metadata)
+	}
+}
+
+// writeXMPFile writes a file whose catalog /Metadata stream holds packet
+// and, unless info is empty, whose Info dictionary is info.
+func writeXMPFile(t *testing.T, packet, info string) string {
+	t.Helper()
+	objs := []string{
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R /Metadata 3 0 R >>\nendobj\n",
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n",
+		fmt.Sprintf("3 0 obj\n<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream\nendobj\n", len(packet)+1, packet),
+	}
+	trailer := "<< /Size 4 /Root 1 0 R >>"
+	if info != "" {
+		objs = append(objs, "4 0 obj\n"+info+"\nendobj\n")
+		trailer = "<< /Size 5 /Root 1 0 R /Info 4 0 R >>"
+	}
+	body := "%PDF-1.7\n"
+	xref := fmt.Sprintf("xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, o := range objs {
+		xref += fmt.Sprintf("%010d 00000 n \n", len(body))
+		body += o
+	}
+	return writeTempPDF(t, "xmp.pdf", body+xref+fmt.Sprintf("trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer, len(body)))
+}
+
+func TestWriteKeepsUnmanagedXMP(t *testing.T) {
+	packet := "<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n" +
+		`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/" xmlns:prism="http://prismstandard.org/namespaces/basic/2.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<xmpMM:DocumentID>uuid:1234</xmpMM:DocumentID><prism:doi>10.1000/182</prism:doi>` +
+		`<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Old</rdf:li></rdf:Alt></dc:title>` +
+		"</rdf:Description></rdf:RDF></x:xmpmeta>\n" + strings.Repeat(" ", 200) + "\n<?xpacket end=\"w\"?>"
+	path := writeXMPFile(t, packet, "")
+	title := "New"
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+
+	doc, _ := openTrailer(t, path)
+	defer doc.Close()
+	got, ok := metadataPacket(doc)
+	if !ok {
+		t.Fatalf("metadata stream missing after write")
+	}
+	for _, keep := range []string{"<xmpMM:DocumentID>uuid:1234</xmpMM:DocumentID>", "<prism:doi>10.1000/182</prism:doi>", strings.Repeat(" ", 200), `<?xpacket end="w"?>`} {
+		if !strings.Contains(string(got), keep) {
+			t.Fatalf("write dropped %q:\n%s", keep, got)
+		}
+	}
+	if x, err := xmp.Unmarshal(got); err != nil || x.Title != title {
+		t.Fatalf("Unmarshal=%+v, %v", x, err)
+	}
+}
+
+func TestWriteReplacesMalformedXMP(t *testing.T) {
+	path := writeXMPFile(t, `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF></x:xmpmeta>`, "")
+	title := "New"
+	res, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{InputPath: path, InPlace: true, Set: model.MetadataPatch{Title: &title}})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(res.Warnings) != 1 || !strings.Contains(res.Warnings[0], "XMP packet replaced") {
+		t.Fatalf("warnings=%q", res.Warnings)
+	}
+	read, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil || read.Metadata.Title != title {
+		t.Fatalf("Read=%+v, %v", read.Metadata, err)
+	}
+}
+
+func TestRepeatedWritesKeepXRefChainIntact(t *testing.T) {
+	store := NewStore()
+	path := copyFixture(t, "incremental-updates.pdf")
+
+	for i, title := range []string{"Edit 1", "Edit 2", "Edit 3"} {
+		title := title
+		if _, err := store.Write(context.Background(), model.MetadataWriteRequest{
+			InputPath: path,
+			InPlace:   true,
+			Set:       model.MetadataPatch{Title: &title},
+		}); err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	doc, err := pdf.Open(path)
+	if err != nil {
+		t.Fatalf("pdf.Open: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("xref chain broken after writes: %v", err)
+	}
+	res, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if res.Metadata.Title != "Edit 3" || res.Metadata.Value(model.FieldAuthor) != "Second Author" {
+		t.Fatalf("unexpected metadata after writes: %#v", res.Metadata)
+	}
+}
+
 func TestWriteAndReadRoundTrip(t *testing.T) {
 	store := NewStore()
 	in := copyFixture(t, "minimal.pdf")
@@ -65,8 +429,8 @@ func TestWriteAndReadRoundTrip(t *testing.T) {
 		InputPath:  in,
 		OutputPath: out,
 		Set: model.MetadataPatch{
-			Title:  &title,
-			Author: &author,
+			Title:   &title,
+			Authors: model.List{author},
 		},
 	})
 	if err != nil {
@@ -75,18 +439,18 @@ func TestWriteAndReadRoundTrip(t *testing.T) {
 	if !res.InfoFound {
 		t.Fatalf("expected info metadata result")
 	}
-	if res.Metadata.Title != title || res.Metadata.Author != author {
+	if res.Metadata.Title != title || res.Metadata.Value(model.FieldAuthor) != author {
 		t.Fatalf("unexpected metadata after write: %#v", res.Metadata)
 	}
 
-	readBack, err := store.Read(context.Background(), out)
+	readBack, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: out})
 	if err != nil {
 		t.Fatalf("Read(out): %v", err)
 	}
 	if !readBack.InfoFound {
 		t.Fatalf("expected info metadata in written output")
 	}
-	if readBack.Metadata.Title != title || readBack.Metadata.Author != author {
+	if readBack.Metadata.Title != title || readBack.Metadata.Value(model.FieldAuthor) != author {
 		t.Fatalf("unexpected metadata readback: %#v", readBack.Metadata)
 	}
 	if !readBack.XMPFound {
@@ -94,6 +458,153 @@ func TestWriteAndReadRoundTrip(t *testing.T) {
 	}
 }
 
+func TestWriteEncodesInfoTextStrings(t *testing.T) {
+	path := copyFixture(t, "incremental-updates.pdf")
+	title, author := "報告書 2024", "Jürgen Müller"
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title, Authors: model.List{author}})
+
+	doc, trailer := openTrailer(t, path)
+	defer doc.Close()
+	info, ok := resolveDict(doc, trailer.Get("Info"))
+	if !ok {
+		t.Fatalf("Info dictionary missing")
+	}
+	if s, _ := info.Get("Author").(pdf.String); !bytes.Equal(s.Bytes, []byte("J\xfcrgen M\xfcller")) || s.Hex {
+		t.Fatalf("Author=%+v, want a PDFDocEncoding literal", info.Get("Author"))
+	}
+	if s, _ := info.Get("Title").(pdf.String); !bytes.HasPrefix(s.Bytes, []byte{0xFE, 0xFF}) || !s.Hex {
+		t.Fatalf("Title=%+v, want a UTF-16BE hex string", info.Get("Title"))
+	}
+	if got := parseInfoDict(doc, info); got.Title != title || got.Value(model.FieldAuthor) != author {
+		t.Fatalf("Info read back as %+v", got)
+	}
+}
+
+func TestWriteKeepsInfoAndXMPListsInSync(t *testing.T) {
+	path := copyFixture(t, "incremental-updates.pdf")
+	authors, keywords := []string{"Doe, J.", "Roe, R."}, []string{"pdf", "metadata"}
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Authors: authors, Keywords: keywords})
+
+	doc, trailer := openTrailer(t, path)
+	defer doc.Close()
+	info, _ := resolveDict(doc, trailer.Get("Info"))
+	for key, want := range map[pdf.Name]string{"Author": "Doe, J.; Roe, R.", "Keywords": "pdf, metadata"} {
+		if s, _ := info.Get(key).(pdf.String); string(s.Bytes) != want {
+			t.Fatalf("%s=%+v want %q", key, info.Get(key), want)
+		}
+	}
+	packet, ok := metadataPacket(doc)
+	if !ok {
+		t.Fatalf("metadata stream missing after write")
+	}
+	for _, want := range []string{
+		"<dc:creator><rdf:Seq><rdf:li>Doe, J.</rdf:li><rdf:li>Roe, R.</rdf:li></rdf:Seq></dc:creator>",
+		"<dc:subject><rdf:Bag><rdf:li>pdf</rdf:li><rdf:li>metadata</rdf:li></rdf:Bag></dc:subject>",
+	} {
+		if !bytes.Contains(packet, []byte(want)) {
+			t.Fatalf("missing %s in\n%s", want, packet)
+		}
+	}
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !slices.Equal(res.Metadata.Authors, authors) || !slices.Equal(res.Metadata.Keywords, keywords) {
+		t.Fatalf("Read()=%#v", res.Metadata)
+	}
+}
+
+func TestWriteKeepsXMPListsThroughUnrelatedSet(t *testing.T) {
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+		`<dc:creator><rdf:Seq><rdf:li>Alice</rdf:li><rdf:li>Bob</rdf:li></rdf:Seq></dc:creator>` +
+		`<dc:subject><rdf:Bag><rdf:li>a; b</rdf:li><rdf:li>c</rdf:li></rdf:Bag></dc:subject>` +
+		`</rdf:Description></rdf:RDF></x:xmpmeta>`
+	path := writeXMPFile(t, packet, "<< /Author (Alice, Bob) /Keywords (a, b, c) >>")
+	title := "New"
+	writeInPlace(t, path, model.WriteOptions{}, model.MetadataPatch{Title: &title})
+
+	doc, trailer := openTrailer(t, path)
+	defer doc.Close()
+	got, ok := metadataPacket(doc)
+	if !ok {
+		t.Fatalf("metadata stream missing after write")
+	}
+	if !bytes.Contains(got, []byte("<dc:creator><rdf:Seq><rdf:li>Alice</rdf:li><rdf:li>Bob</rdf:li></rdf:Seq></dc:creator>")) {
+		t.Fatalf("dc:creator list lost:\n%s", got)
+	}
+	if !bytes.Contains(got, []byte("<dc:subject><rdf:Bag><rdf:li>a; b</rdf:li><rdf:li>c</rdf:li></rdf:Bag></dc:subject>")) {
+		t.Fatalf("dc:subject list lost:\n%s", got)
+	}
+	info, _ := resolveDict(doc, trailer.Get("Info"))
+	if s, _ := info.Get("Author").(pdf.String); string(s.Bytes) != "Alice; Bob" {
+		t.Fatalf("Author=%+v", info.Get("Author"))
+	}
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !slices.Equal(res.Metadata.Authors, []string{"Alice", "Bob"}) || !slices.Equal(res.Metadata.Keywords, []string{"a; b", "c"}) {
+		t.Fatalf("Read()=%#v", res.Metadata)
+	}
+}
+
+func TestWriteKeepsOtherInfoEntries(t *testing.T) {
+	path := writeXMPFile(t, `<x:xmpmeta xmlns:x="adobe:ns:meta/"/>`, "<< /Title (Old) /Author (Ann) /Trapped /False /Custom (keep me) >>")
+	title := "New"
+	if _, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{
+		InputPath: path,
+		InPlace:   true,
+		Set:       model.MetadataPatch{Title: &title},
+		Unset:     []model.Field{model.FieldAuthor},
+	}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	doc, trailer := openTrailer(t, path)
+	defer doc.Close()
+	info, _ := resolveDict(doc, trailer.Get("Info"))
+	if s, _ := info.Get("Title").(pdf.String); string(s.Bytes) != title {
+		t.Fatalf("Title=%+v", info.Get("Title"))
+	}
+	if info.Get("Author") != nil {
+		t.Fatalf("Author not removed: %+v", info.Get("Author"))
+	}
+	if info.Get("Trapped") != pdf.Name("False") {
+		t.Fatalf("Trapped=%+v", info.Get("Trapped"))
+	}
+	if s, _ := info.Get("Custom").(pdf.String); string(s.Bytes) != "keep me" {
+		t.Fatalf("Custom=%+v", info.Get("Custom"))
+	}
+}
+
+// TestReadBaselineUTF8Info reads a file written by an earlier pdfmeta, which
+// stored Info values as raw UTF-8 literals, and checks that a later write
+// does not turn them into PDFDocEncoding mojibake.
+func TestReadBaselineUTF8Info(t *testing.T) {
+	path := copyFixture(t, "utf8-info-baseline.pdf")
+	want := model.Metadata{Title: "Café Jürgen", Authors: []string{"Jürgen Müller"}, Subject: "文書"}
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !res.Metadata.Equal(want) {
+		t.Fatalf("Read=%#v want %#v", res.Metadata, want)
+	}
+
+	creator := "tool"
+	if _, err := NewStore().Write(context.Background(), model.MetadataWriteRequest{InputPath: path, InPlace: true, Set: model.MetadataPatch{Creator: &creator}}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	doc, trailer := openTrailer(t, path)
+	defer doc.Close()
+	info, _ := resolveDict(doc, trailer.Get("Info"))
+	if got := parseInfoDict(doc, info); got.Title != want.Title || got.Subject != want.Subject {
+		t.Fatalf("rewritten Info=%#v", got)
+	}
+}
+
 func TestWriteCreatesNativeInfoAndMetadataRefs(t *testing.T) {
 	store := NewStore()
 	in := copyFixture(t, "minimal.pdf")
@@ -149,7 +660,7 @@ func TestWriteUnsetInPlace(t *testing.T) {
 		t.Fatalf("unset write: %v", err)
 	}
 
-	res, err := store.Read(context.Background(), in)
+	res, err := store.Read(context.Background(), model.MetadataReadRequest{InputPath: in})
 	if err != nil {
 		t.Fatalf("Read(in): %v", err)
 	}
@@ -189,7 +700,7 @@ func TestReadCanceledContext(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
 
-	_, err := store.Read(ctx, fixturePath("minimal.pdf"))
+	_, err := store.Read(ctx, model.MetadataReadRequest{InputPath: fixturePath("minimal.pdf")})
 	assertAppErrorCode(t, err, model.ErrInternal)
 }
 
@@ -0,0 +1,54 @@
+package metadata
+
+import (
+	"pdfmeta/internal/model"
+	"pdfmeta/internal/pdf"
+)
+
+// sigFlagsSignaturesExist is bit 1 of the AcroForm /SigFlags entry.
+const sigFlagsSignaturesExist = 1
+
+// readStructure summarizes the catalog-level features show reports. Missing
+// or unresolvable entries read as absent.
+func readStructure(doc *pdf.Document) *model.Structure {
+	s := &model.Structure{
+		HeaderVersion: doc.Version(),
+		Pages:         doc.PageCount(),
+		FileSize:      doc.Size(),
+		Revisions:     len(doc.Revisions()),
+	}
+	trailer, err := doc.Trailer()
+	if err != nil {
+		return s
+	}
+	catalog, ok := resolveDict(doc, trailer.Get("Root"))
+	if !ok {
+		return s
+	}
+	if v, ok := catalog.Get("Version").(pdf.Name); ok {
+		s.CatalogVersion = string(v)
+	}
+	if mark, ok := resolveDict(doc, catalog.Get("MarkInfo")); ok {
+		marked, _ := doc.Resolve(mark.Get("Marked"))
+		b, _ := marked.(pdf.Bool)
+		s.Tagged = bool(b)
+	}
+	if names, ok := resolveDict(doc, catalog.Get("Names")); ok {
+		_, s.EmbeddedFiles = resolveDict(doc, names.Get("EmbeddedFiles"))
+	}
+	if form, ok := resolveDict(doc, catalog.Get("AcroForm")); ok {
+		fields, _ := doc.Resolve(form.Get("Fields"))
+		arr, _ := fields.(pdf.Array)
+		_, xfa := form["XFA"]
+		s.Forms = len(arr) > 0 || xfa
+		flags, _ := form.Int("SigFlags")
+		s.Signed = flags&sigFlagsSignaturesExist != 0
+	}
+	s.DocMDP, _ = doc.DocMDP()
+	s.Signatures = readSignatures(doc)
+	s.Signed = s.Signed || len(s.Signatures) > 0
+	if id, ok := readPDFA(doc); ok {
+		s.PDFA = &id
+	}
+	return s
+}
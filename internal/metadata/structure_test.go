@@ -0,0 +1,60 @@
+package metadata
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+func TestReadReportsStructure(t *testing.T) {
+	path := writeTempPDF(t, "features.pdf", "%PDF-1.4\n"+
+		"1 0 obj\n<< /Type /Catalog /Version /1.7 /Pages 2 0 R /MarkInfo << /Marked true >>"+
+		" /Names << /EmbeddedFiles << /Names [(a.txt) 5 0 R] >> >> /AcroForm 6 0 R >>\nendobj\n"+
+		"2 0 obj\n<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>\nendobj\n"+
+		"3 0 obj\n<< /Type /Page /Parent 2 0 R >>\nendobj\n"+
+		"4 0 obj\n<< /Type /Page /Parent 2 0 R >>\nendobj\n"+
+		"5 0 obj\n<< /Type /Filespec /F (a.txt) >>\nendobj\n"+
+		"6 0 obj\n<< /Fields [7 0 R] >>\nendobj\n"+
+		"7 0 obj\n<< /T (parent) /Kids [8 0 R] >>\nendobj\n"+
+		"8 0 obj\n<< /FT /Sig /T (sig) /V 9 0 R >>\nendobj\n"+
+		"9 0 obj\n<< /Type /Sig /ByteRange [0 1 2 3] >>\nendobj\n"+
+		"trailer\n<< /Root 1 0 R /Size 10 >>\nstartxref\n0\n%%EOF\n")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: path})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	want := model.Structure{
+		HeaderVersion:  "1.4",
+		CatalogVersion: "1.7",
+		Pages:          2,
+		FileSize:       info.Size(),
+		Revisions:      1,
+		Tagged:         true,
+		Signed:         true,
+		EmbeddedFiles:  true,
+		Forms:          true,
+		Signatures:     []model.Signature{{Field: "parent.sig", ByteRange: []int64{0, 1, 2, 3}, Covered: 5}},
+	}
+	if res.Structure == nil || !reflect.DeepEqual(*res.Structure, want) {
+		t.Fatalf("Structure=%+v want %+v", res.Structure, want)
+	}
+}
+
+func TestReadStructurePlainFile(t *testing.T) {
+	res, err := NewStore().Read(context.Background(), model.MetadataReadRequest{InputPath: fixturePath("incremental-updates.pdf")})
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	st := res.Structure
+	if st == nil || st.Revisions != 3 || st.Tagged || st.Signed || st.EmbeddedFiles || st.Forms || st.CatalogVersion != "" {
+		t.Fatalf("unexpected structure %+v", st)
+	}
+}
@@ -20,9 +20,20 @@ const (
 	ErrInternal     ErrorCode = "internal"
 )
 
-// AppError carries a stable code plus wrapped cause.
+// Reasons refine ErrPDFEncrypted failures for scripted callers.
+const (
+	ReasonPasswordRequired      = "password_required"
+	ReasonIncorrectPassword     = "incorrect_password"
+	ReasonUnsupportedEncryption = "unsupported_encryption"
+	ReasonDecryptFailed         = "decrypt_failed"
+	ReasonRepairUnsupported     = "repair_unsupported"
+)
+
+// AppError carries a stable code plus wrapped cause. Reason, when set, is a
+// stable machine-readable refinement of Code.
 type AppError struct {
 	Code    ErrorCode
+	Reason  string
 	Message string
 	Cause   error
 }
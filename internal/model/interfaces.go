@@ -5,6 +5,9 @@ import "context"
 // Service defines application-level metadata operations.
 type Service interface {
 	Show(context.Context, ShowRequest) (ShowResult, error)
+	History(context.Context, HistoryRequest) (HistoryResult, error)
+	Revert(context.Context, RevertRequest) (RevertResult, error)
+	Repair(context.Context, RepairRequest) (ShowResult, error)
 	Set(context.Context, SetRequest) (ShowResult, error)
 	Unset(context.Context, UnsetRequest) (ShowResult, error)
 	Batch(context.Context, BatchRequest) (BatchResult, error)
@@ -17,8 +20,11 @@ type Service interface {
 
 // MetadataStore handles PDF-backed metadata read/write.
 type MetadataStore interface {
-	Read(context.Context, string) (MetadataReadResult, error)
+	Read(context.Context, MetadataReadRequest) (MetadataReadResult, error)
 	Write(context.Context, MetadataWriteRequest) (MetadataReadResult, error)
+	History(context.Context, string) ([]Revision, error)
+	Revert(context.Context, MetadataRevertRequest) (RevertResult, error)
+	Repair(context.Context, MetadataRepairRequest) (MetadataReadResult, error)
 }
 
 // TemplateStore handles persistent template management.
@@ -29,13 +35,25 @@ type TemplateStore interface {
 	Delete(context.Context, string) error
 }
 
+// MetadataReadRequest drives metadata reads. Password opens encrypted PDFs;
+// when empty, the empty user password is tried.
+type MetadataReadRequest struct {
+	InputPath string
+	Password  string
+}
+
 // MetadataReadResult captures read state from Info/XMP sections.
 type MetadataReadResult struct {
 	Encrypted  bool
+	Linearized bool
 	Metadata   Metadata
 	InfoFound  bool
 	XMPFound   bool
 	Normalized bool
+	Warnings   []string
+	Repair     *RepairReport
+	Structure  *Structure
+	Encryption *Encryption
 }
 
 // MetadataWriteRequest drives unified metadata writes to Info/XMP.
@@ -44,11 +62,28 @@ type MetadataWriteRequest struct {
 	OutputPath string
 	InPlace    bool
 	Strict     bool
+	Options    WriteOptions
 	Set        MetadataPatch
 	Unset      []Field
 	UnsetAll   bool
 }
 
+// MetadataRevertRequest drives truncation of a PDF to an earlier revision.
+type MetadataRevertRequest struct {
+	InputPath  string
+	OutputPath string
+	InPlace    bool
+	To         int
+	Steps      int
+}
+
+// MetadataRepairRequest drives a scan-based rebuild of a damaged PDF.
+type MetadataRepairRequest struct {
+	InputPath  string
+	OutputPath string
+	InPlace    bool
+}
+
 // BatchRequest coordinates operation execution across many files.
 type BatchRequest struct {
 	ManifestPath    string
@@ -1,5 +1,11 @@
 package model
 
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+)
+
 // Field identifies a supported metadata key.
 type Field string
 
@@ -26,26 +32,159 @@ var AllFields = []Field{
 	FieldModDate,
 }
 
-// Metadata stores normalized Info/XMP-compatible values.
+// Metadata stores normalized Info/XMP-compatible values. Authors and
+// keywords are ordered lists; empty lists are nil.
 type Metadata struct {
-	Title        string `json:"title,omitempty"`
-	Author       string `json:"author,omitempty"`
-	Subject      string `json:"subject,omitempty"`
-	Keywords     string `json:"keywords,omitempty"`
-	Creator      string `json:"creator,omitempty"`
-	Producer     string `json:"producer,omitempty"`
-	CreationDate string `json:"creationDate,omitempty"`
-	ModDate      string `json:"modDate,omitempty"`
+	Title        string   `json:"title,omitempty"`
+	Authors      []string `json:"author,omitempty"`
+	Subject      string   `json:"subject,omitempty"`
+	Keywords     []string `json:"keywords,omitempty"`
+	Creator      string   `json:"creator,omitempty"`
+	Producer     string   `json:"producer,omitempty"`
+	CreationDate string   `json:"creationDate,omitempty"`
+	ModDate      string   `json:"modDate,omitempty"`
 }
 
-// MetadataPatch represents partial changes where nil means untouched.
+// MetadataPatch represents partial changes where nil means untouched. An
+// empty, non-nil list clears the field.
 type MetadataPatch struct {
 	Title        *string `json:"title,omitempty"`
-	Author       *string `json:"author,omitempty"`
+	Authors      List    `json:"author,omitempty"`
 	Subject      *string `json:"subject,omitempty"`
-	Keywords     *string `json:"keywords,omitempty"`
+	Keywords     List    `json:"keywords,omitempty"`
 	Creator      *string `json:"creator,omitempty"`
 	Producer     *string `json:"producer,omitempty"`
 	CreationDate *string `json:"creationDate,omitempty"`
 	ModDate      *string `json:"modDate,omitempty"`
 }
+
+// UnmarshalJSON reads list fields given as a single string, as templates
+// and manifests wrote them before fields became lists, the way the Info
+// dictionary joins them: authors split on semicolons, keywords on commas or
+// semicolons. Array items are kept as they are.
+func (p *MetadataPatch) UnmarshalJSON(b []byte) error {
+	type plain MetadataPatch
+	if err := json.Unmarshal(b, (*plain)(p)); err != nil {
+		return err
+	}
+	var raw struct {
+		Authors  json.RawMessage `json:"author"`
+		Keywords json.RawMessage `json:"keywords"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	if isJSONString(raw.Authors) {
+		p.Authors = SplitList(p.Authors[0], SplitAuthors)
+	}
+	if isJSONString(raw.Keywords) {
+		p.Keywords = SplitList(p.Keywords[0], SplitKeywords)
+	}
+	return nil
+}
+
+func isJSONString(b json.RawMessage) bool {
+	return len(b) > 0 && b[0] == '"'
+}
+
+// List is a patch value for a list field. It is a JSON array, but a single
+// string is read as a one-item list.
+type List []string
+
+// SplitList reads one legacy string holding several entries with split. A
+// blank string gives an empty list, which clears the field.
+func SplitList(s string, split func(string) []string) List {
+	if items := split(s); items != nil {
+		return List(items)
+	}
+	return List{}
+}
+
+func (l *List) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*l = List{s}
+		return nil
+	}
+	var items []string
+	if err := json.Unmarshal(b, &items); err != nil {
+		return err
+	}
+	*l = List(items)
+	if *l == nil {
+		*l = List{}
+	}
+	return nil
+}
+
+// The Info dictionary stores each list as one string: authors separated by
+// semicolons, since names may contain commas, and keywords by commas.
+const (
+	authorSeparator  = "; "
+	keywordSeparator = ", "
+)
+
+// JoinAuthors renders authors as an Info /Author string.
+func JoinAuthors(authors []string) string {
+	return strings.Join(authors, authorSeparator)
+}
+
+// SplitAuthors parses an Info /Author string into authors.
+func SplitAuthors(s string) []string {
+	return splitList(s, ";")
+}
+
+// JoinKeywords renders keywords as an Info /Keywords or pdf:Keywords string.
+func JoinKeywords(keywords []string) string {
+	return strings.Join(keywords, keywordSeparator)
+}
+
+// SplitKeywords parses a keywords string separated by commas or semicolons.
+func SplitKeywords(s string) []string {
+	return splitList(s, ",;")
+}
+
+func splitList(s, separators string) []string {
+	var out []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Equal reports whether m and o hold the same values, lists in the same
+// order.
+func (m Metadata) Equal(o Metadata) bool {
+	return m.Title == o.Title && slices.Equal(m.Authors, o.Authors) && m.Subject == o.Subject &&
+		slices.Equal(m.Keywords, o.Keywords) && m.Creator == o.Creator && m.Producer == o.Producer &&
+		m.CreationDate == o.CreationDate && m.ModDate == o.ModDate
+}
+
+// Value returns the metadata value stored for f, or "" for unknown fields.
+// Lists are joined as the Info dictionary stores them.
+func (m Metadata) Value(f Field) string {
+	switch f {
+	case FieldTitle:
+		return m.Title
+	case FieldAuthor:
+		return JoinAuthors(m.Authors)
+	case FieldSubject:
+		return m.Subject
+	case FieldKeywords:
+		return JoinKeywords(m.Keywords)
+	case FieldCreator:
+		return m.Creator
+	case FieldProducer:
+		return m.Producer
+	case FieldCreationDate:
+		return m.CreationDate
+	case FieldModDate:
+		return m.ModDate
+	}
+	return ""
+}
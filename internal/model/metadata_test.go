@@ -0,0 +1,68 @@
+package model
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestMetadataPatchListJSON(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		in   string
+		want List
+	}{
+		{name: "array", in: `{"author":["Ann","Bo"]}`, want: List{"Ann", "Bo"}},
+		{name: "array items kept", in: `{"author":["Smith; Jones & Co."]}`, want: List{"Smith; Jones & Co."}},
+		{name: "legacy string", in: `{"author":"Ann"}`, want: List{"Ann"}},
+		{name: "legacy string split", in: `{"author":"Doe, J.; Roe, R."}`, want: List{"Doe, J.", "Roe, R."}},
+		{name: "legacy blank string clears", in: `{"author":" "}`, want: List{}},
+		{name: "empty array clears", in: `{"author":[]}`, want: List{}},
+		{name: "absent", in: `{}`, want: nil},
+		{name: "null", in: `{"author":null}`, want: nil},
+	}
+
+	for _, tc := range cases {
+		var patch MetadataPatch
+		if err := json.Unmarshal([]byte(tc.in), &patch); err != nil {
+			t.Fatalf("%s: Unmarshal: %v", tc.name, err)
+		}
+		if !slices.Equal(patch.Authors, tc.want) || (patch.Authors == nil) != (tc.want == nil) {
+			t.Fatalf("%s: Authors=%#v want %#v", tc.name, patch.Authors, tc.want)
+		}
+	}
+}
+
+func TestMetadataPatchLegacyKeywords(t *testing.T) {
+	t.Parallel()
+
+	var patch MetadataPatch
+	if err := json.Unmarshal([]byte(`{"title":"T","keywords":"a, b;c"}`), &patch); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !slices.Equal(patch.Keywords, List{"a", "b", "c"}) || patch.Title == nil || *patch.Title != "T" {
+		t.Fatalf("patch=%#v", patch)
+	}
+}
+
+func TestSplitAndJoinLists(t *testing.T) {
+	t.Parallel()
+
+	if got := SplitAuthors("Doe, J.; Roe, R.;; "); !slices.Equal(got, []string{"Doe, J.", "Roe, R."}) {
+		t.Fatalf("SplitAuthors=%q", got)
+	}
+	if got := SplitKeywords("a, b;c ,"); !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Fatalf("SplitKeywords=%q", got)
+	}
+	if got := SplitKeywords(" "); got != nil {
+		t.Fatalf("SplitKeywords(blank)=%#v want nil", got)
+	}
+	if got := JoinAuthors([]string{"Doe, J.", "Roe, R."}); got != "Doe, J.; Roe, R." {
+		t.Fatalf("JoinAuthors=%q", got)
+	}
+	if got := JoinKeywords([]string{"a", "b"}); got != "a, b" {
+		t.Fatalf("JoinKeywords=%q", got)
+	}
+}
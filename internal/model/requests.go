@@ -1,5 +1,10 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+)
+
 // IOOptions controls write destination behavior.
 type IOOptions struct {
 	InputPath  string `json:"inputPath"`
@@ -13,35 +18,227 @@ type ExecOptions struct {
 	JSON   bool `json:"json"`
 }
 
+// LinearizedPolicy selects how a write treats a linearized ("fast web view")
+// PDF, whose hint tables any appended update invalidates.
+type LinearizedPolicy string
+
+const (
+	LinearizedWarn        LinearizedPolicy = "warn"
+	LinearizedRefuse      LinearizedPolicy = "refuse"
+	LinearizedRelinearize LinearizedPolicy = "relinearize"
+)
+
+// WriteOptions controls how metadata updates are serialized into the PDF.
+// An empty Linearized policy means LinearizedWarn.
+type WriteOptions struct {
+	ObjectStreams bool             `json:"objectStreams,omitempty"`
+	Rewrite       bool             `json:"rewrite,omitempty"`
+	Linearized    LinearizedPolicy `json:"linearized,omitempty"`
+	Repair        bool             `json:"repair,omitempty"`
+	// Force writes certified documents whose DocMDP permissions forbid
+	// changes.
+	Force bool `json:"force,omitempty"`
+	// PDFA refuses writes that would break the PDF/A conformance the
+	// input declares, instead of warning about them.
+	PDFA bool `json:"pdfa,omitempty"`
+	// Password opens an encrypted input; empty tries the empty user
+	// password.
+	Password string `json:"-"`
+}
+
+// RepairReport describes what was reconstructed when a file's
+// cross-reference data was rebuilt by scanning its objects.
+type RepairReport struct {
+	XRefError  string `json:"xrefError,omitempty"`
+	Objects    int    `json:"objects"`
+	Root       string `json:"root"`
+	RootSource string `json:"rootSource"`
+	Info       string `json:"info,omitempty"`
+	InfoSource string `json:"infoSource,omitempty"`
+}
+
 // ShowRequest reads metadata from a single PDF.
 type ShowRequest struct {
 	InputPath string `json:"inputPath"`
 	JSON      bool   `json:"json"`
+	Password  string `json:"-"`
 }
 
 // ShowResult is the display model for read operations.
 type ShowResult struct {
-	InputPath  string   `json:"inputPath"`
-	Encrypted  bool     `json:"encrypted"`
-	Metadata   Metadata `json:"metadata"`
-	InfoFound  bool     `json:"infoFound"`
-	XMPFound   bool     `json:"xmpFound"`
-	Normalized bool     `json:"normalized"`
+	InputPath  string        `json:"inputPath"`
+	Encrypted  bool          `json:"encrypted"`
+	Linearized bool          `json:"linearized"`
+	Metadata   Metadata      `json:"metadata"`
+	InfoFound  bool          `json:"infoFound"`
+	XMPFound   bool          `json:"xmpFound"`
+	Normalized bool          `json:"normalized"`
+	Warnings   []string      `json:"warnings,omitempty"`
+	Repair     *RepairReport `json:"repair,omitempty"`
+	Structure  *Structure    `json:"structure,omitempty"`
+	Encryption *Encryption   `json:"encryption,omitempty"`
+}
+
+// Structure summarizes the document beyond its metadata. It is reported by
+// show only.
+type Structure struct {
+	HeaderVersion  string `json:"headerVersion"`
+	CatalogVersion string `json:"catalogVersion,omitempty"`
+	Pages          int    `json:"pages"`
+	FileSize       int64  `json:"fileSize"`
+	Revisions      int    `json:"revisions"`
+	Tagged         bool   `json:"tagged"`
+	Signed         bool   `json:"signed"`
+	EmbeddedFiles  bool   `json:"embeddedFiles"`
+	Forms          bool   `json:"forms"`
+	// DocMDP is the permission level (1-3) of a certification signature,
+	// or 0 when the document is not certified.
+	DocMDP     int         `json:"docMDP,omitempty"`
+	Signatures []Signature `json:"signatures,omitempty"`
+	// PDFA is the PDF/A identification declared in the XMP packet.
+	PDFA *PDFA `json:"pdfa,omitempty"`
+}
+
+// PDFA is a PDF/A identification (pdfaid:part and pdfaid:conformance).
+type PDFA struct {
+	Part        int    `json:"part"`
+	Conformance string `json:"conformance,omitempty"`
+}
+
+// String formats the identification as it is usually written, such as
+// "PDF/A-2b".
+func (p PDFA) String() string {
+	return fmt.Sprintf("PDF/A-%d%s", p.Part, strings.ToLower(p.Conformance))
+}
+
+// Signature is one signed signature field.
+type Signature struct {
+	Field     string  `json:"field"`
+	SubFilter string  `json:"subFilter,omitempty"`
+	ByteRange []int64 `json:"byteRange,omitempty"`
+	// Covered is the number of leading file bytes the byte ranges span;
+	// anything after that was appended once the signature was applied.
+	Covered     int64  `json:"covered"`
+	Name        string `json:"name,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Location    string `json:"location,omitempty"`
+	SigningTime string `json:"signingTime,omitempty"`
+	Certifying  bool   `json:"certifying,omitempty"`
+}
+
+// Access levels an encrypted document was opened with.
+const (
+	AccessOwner  = "owner"
+	AccessUser   = "user"
+	AccessLocked = "locked"
+)
+
+// Encryption describes the /Encrypt dictionary of an encrypted PDF.
+type Encryption struct {
+	Filter    string `json:"filter"`
+	SubFilter string `json:"subFilter,omitempty"`
+	V         int    `json:"v"`
+	R         int    `json:"r"`
+	// KeyLength is in bits.
+	KeyLength       int           `json:"keyLength"`
+	StmF            string        `json:"stmF,omitempty"`
+	StrF            string        `json:"strF,omitempty"`
+	CryptFilters    []CryptFilter `json:"cryptFilters,omitempty"`
+	EncryptMetadata bool          `json:"encryptMetadata"`
+	Permissions     Permissions   `json:"permissions"`
+	// Access is AccessOwner, AccessUser or AccessLocked. Owner access
+	// lifts the permission restrictions.
+	Access string `json:"access"`
+}
+
+// CryptFilter is one named crypt filter of a /V 4 or 5 handler.
+type CryptFilter struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	Length int    `json:"length,omitempty"`
+}
+
+// Permissions are the user access permissions decoded from /P.
+type Permissions struct {
+	P         int32 `json:"p"`
+	Print     bool  `json:"print"`
+	Modify    bool  `json:"modify"`
+	Copy      bool  `json:"copy"`
+	Annotate  bool  `json:"annotate"`
+	FillForms bool  `json:"fillForms"`
+	Assemble  bool  `json:"assemble"`
+}
+
+// HistoryRequest lists the incremental revisions of a single PDF.
+type HistoryRequest struct {
+	InputPath string `json:"inputPath"`
+	JSON      bool   `json:"json"`
+}
+
+// FieldChange is a metadata value that differs from the previous revision.
+type FieldChange struct {
+	Field Field  `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// Revision describes one saved state of a PDF: the byte range appended by
+// its update and the metadata in effect once that update is applied.
+type Revision struct {
+	Index       int           `json:"index"`
+	StartOffset int64         `json:"startOffset"`
+	EndOffset   int64         `json:"endOffset"`
+	Size        int64         `json:"size"`
+	XRefOffset  int64         `json:"xrefOffset"`
+	Metadata    Metadata      `json:"metadata"`
+	InfoFound   bool          `json:"infoFound"`
+	XMPFound    bool          `json:"xmpFound"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+}
+
+// HistoryResult lists revisions oldest first.
+type HistoryResult struct {
+	InputPath string     `json:"inputPath"`
+	Revisions []Revision `json:"revisions"`
+}
+
+// RevertRequest truncates a PDF back to an earlier revision. Exactly one of
+// To (a 1-based revision index) or Steps (revisions to drop) is set.
+type RevertRequest struct {
+	IO    IOOptions   `json:"io"`
+	Exec  ExecOptions `json:"exec"`
+	To    int         `json:"to,omitempty"`
+	Steps int         `json:"steps,omitempty"`
+}
+
+// RevertResult reports the revision a file was rolled back to.
+type RevertResult struct {
+	InputPath string   `json:"inputPath"`
+	Revision  Revision `json:"revision"`
+	Dropped   int      `json:"dropped"`
+}
+
+// RepairRequest rebuilds a PDF whose cross-reference data is damaged.
+type RepairRequest struct {
+	IO   IOOptions   `json:"io"`
+	Exec ExecOptions `json:"exec"`
 }
 
 // SetRequest applies partial metadata updates.
 type SetRequest struct {
 	IO      IOOptions     `json:"io"`
 	Exec    ExecOptions   `json:"exec"`
+	Write   WriteOptions  `json:"write"`
 	Changes MetadataPatch `json:"changes"`
 }
 
 // UnsetRequest removes selected metadata fields.
 type UnsetRequest struct {
-	IO     IOOptions   `json:"io"`
-	Exec   ExecOptions `json:"exec"`
-	Fields []Field     `json:"fields"`
-	All    bool        `json:"all"`
+	IO     IOOptions    `json:"io"`
+	Exec   ExecOptions  `json:"exec"`
+	Write  WriteOptions `json:"write"`
+	Fields []Field      `json:"fields"`
+	All    bool         `json:"all"`
 }
 
 // TemplateSaveRequest persists a reusable metadata template.
@@ -54,9 +251,10 @@ type TemplateSaveRequest struct {
 
 // TemplateApplyRequest applies a named template to a PDF.
 type TemplateApplyRequest struct {
-	Name string      `json:"name"`
-	IO   IOOptions   `json:"io"`
-	Exec ExecOptions `json:"exec"`
+	Name  string       `json:"name"`
+	IO    IOOptions    `json:"io"`
+	Exec  ExecOptions  `json:"exec"`
+	Write WriteOptions `json:"write"`
 }
 
 // TemplateRecord is the persisted template model.
@@ -18,6 +18,8 @@ const (
 // Formatter renders service responses and errors for CLI output.
 type Formatter interface {
 	Show(model.ShowResult) ([]byte, error)
+	History(model.HistoryResult) ([]byte, error)
+	Revert(model.RevertResult) ([]byte, error)
 	Batch(model.BatchResult) ([]byte, error)
 	Template(model.TemplateRecord) ([]byte, error)
 	TemplateList([]model.TemplateRecord) ([]byte, error)
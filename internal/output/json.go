@@ -8,6 +8,14 @@ func (jsonFormatter) Show(result model.ShowResult) ([]byte, error) {
 	return jsonBytes(result)
 }
 
+func (jsonFormatter) History(result model.HistoryResult) ([]byte, error) {
+	return jsonBytes(result)
+}
+
+func (jsonFormatter) Revert(result model.RevertResult) ([]byte, error) {
+	return jsonBytes(result)
+}
+
 func (jsonFormatter) Batch(result model.BatchResult) ([]byte, error) {
 	return jsonBytes(result)
 }
@@ -22,11 +30,12 @@ func (jsonFormatter) TemplateList(records []model.TemplateRecord) ([]byte, error
 
 func (jsonFormatter) Err(err error) ([]byte, error) {
 	type payload struct {
-		Error string          `json:"error"`
-		Code  model.ErrorCode `json:"code,omitempty"`
+		Error  string          `json:"error"`
+		Code   model.ErrorCode `json:"code,omitempty"`
+		Reason string          `json:"reason,omitempty"`
 	}
 	if ae, ok := err.(*model.AppError); ok {
-		return jsonBytes(payload{Error: ae.Error(), Code: ae.Code})
+		return jsonBytes(payload{Error: ae.Error(), Code: ae.Code, Reason: ae.Reason})
 	}
 	return jsonBytes(payload{Error: err.Error()})
 }
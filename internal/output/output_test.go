@@ -44,6 +44,201 @@ func TestJSONFormatterShow(t *testing.T) {
 	}
 }
 
+func TestFormatterHistory(t *testing.T) {
+	t.Parallel()
+	result := model.HistoryResult{
+		InputPath: "in.pdf",
+		Revisions: []model.Revision{
+			{Index: 1, StartOffset: 0, EndOffset: 100, Size: 100, XRefOffset: 60, Metadata: model.Metadata{Title: "Old"}, InfoFound: true},
+			{Index: 2, StartOffset: 100, EndOffset: 150, Size: 50, XRefOffset: 120, Metadata: model.Metadata{Title: "New"}, InfoFound: true,
+				Changes: []model.FieldChange{{Field: model.FieldTitle, From: "Old", To: "New"}}},
+		},
+	}
+
+	text, _ := NewFormatter(FormatText)
+	out, err := text.History(result)
+	if err != nil {
+		t.Fatalf("text History error: %v", err)
+	}
+	for _, want := range []string{"Revisions: 2", "Revision 2: bytes 100-150 (50 bytes), xref at 120", "    title: New", `    title: "Old" -> "New"`} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("text History output missing %q: %q", want, out)
+		}
+	}
+
+	json, _ := NewFormatter(FormatJSON)
+	out, err = json.History(result)
+	if err != nil {
+		t.Fatalf("json History error: %v", err)
+	}
+	if got := string(out); !strings.Contains(got, `"field": "title"`) || !strings.Contains(got, `"startOffset": 100`) {
+		t.Fatalf("json History output mismatch: %q", got)
+	}
+}
+
+func TestTextFormatterRevert(t *testing.T) {
+	t.Parallel()
+	f, _ := NewFormatter(FormatText)
+	out, err := f.Revert(model.RevertResult{
+		InputPath: "out.pdf",
+		Revision:  model.Revision{Index: 2, EndOffset: 516, Metadata: model.Metadata{Title: "Revision 2"}},
+		Dropped:   1,
+	})
+	if err != nil {
+		t.Fatalf("Revert error: %v", err)
+	}
+	for _, want := range []string{"Revision: 2", "Dropped: 1", "FileSize: 516", "  title: Revision 2"} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("Revert output missing %q: %q", want, out)
+		}
+	}
+}
+
+func TestTextFormatterShowWarnings(t *testing.T) {
+	t.Parallel()
+	f, _ := NewFormatter(FormatText)
+	out, err := f.Show(model.ShowResult{InputPath: "in.pdf", Linearized: true, Warnings: []string{"stale hints"}})
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	got := string(out)
+	if !strings.Contains(got, "Linearized: true\n") || !strings.HasSuffix(got, "Warning: stale hints\n") {
+		t.Fatalf("Show output mismatch: %q", got)
+	}
+}
+
+func TestTextFormatterShowRepair(t *testing.T) {
+	t.Parallel()
+	f, _ := NewFormatter(FormatText)
+	out, err := f.Show(model.ShowResult{InputPath: "in.pdf", Repair: &model.RepairReport{
+		XRefError:  "startxref not found",
+		Objects:    3,
+		Root:       "1 0 R",
+		RootSource: "trailer",
+	}})
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	want := "Repair:\n  XRefError: startxref not found\n  Objects: 3\n  Root: 1 0 R (trailer)\n  Info: none\n"
+	if !strings.HasSuffix(string(out), want) {
+		t.Fatalf("Show output mismatch: %q", out)
+	}
+}
+
+func TestFormatterShowStructure(t *testing.T) {
+	t.Parallel()
+	result := model.ShowResult{InputPath: "in.pdf", Structure: &model.Structure{
+		HeaderVersion:  "1.4",
+		CatalogVersion: "1.7",
+		Pages:          12,
+		FileSize:       2048,
+		Revisions:      2,
+		Tagged:         true,
+		Forms:          true,
+	}}
+
+	text, _ := NewFormatter(FormatText)
+	out, err := text.Show(result)
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	want := "Structure:\n  HeaderVersion: 1.4\n  CatalogVersion: 1.7\n  Pages: 12\n  FileSize: 2048\n  Revisions: 2\n" +
+		"  Tagged: true\n  Signed: false\n  EmbeddedFiles: false\n  Forms: true\n"
+	if !strings.HasSuffix(string(out), want) {
+		t.Fatalf("text Show output mismatch: %q", out)
+	}
+
+	js, _ := NewFormatter(FormatJSON)
+	out, err = js.Show(result)
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	for _, want := range []string{`"catalogVersion": "1.7"`, `"pages": 12`, `"revisions": 2`, `"tagged": true`, `"embeddedFiles": false`} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("JSON Show output missing %s: %q", want, out)
+		}
+	}
+}
+
+func TestFormatterShowSignatures(t *testing.T) {
+	t.Parallel()
+	result := model.ShowResult{InputPath: "in.pdf", Structure: &model.Structure{
+		HeaderVersion: "1.7",
+		Signed:        true,
+		PDFA:          &model.PDFA{Part: 2, Conformance: "B"},
+		DocMDP:        2,
+		Signatures: []model.Signature{{
+			Field:      "Signature1",
+			SubFilter:  "adbe.pkcs7.detached",
+			ByteRange:  []int64{0, 100, 200, 50},
+			Covered:    250,
+			Name:       "Jane",
+			Certifying: true,
+		}},
+	}}
+
+	text, _ := NewFormatter(FormatText)
+	out, err := text.Show(result)
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	want := "  Forms: false\n  PDFA: PDF/A-2b\n  DocMDP: 2\n  Signature: Signature1\n    SubFilter: adbe.pkcs7.detached\n" +
+		"    ByteRange: [0 100 200 50]\n    Covered: 250\n    Name: Jane\n    Certifying: true\n"
+	if !strings.HasSuffix(string(out), want) {
+		t.Fatalf("text Show output mismatch: %q", out)
+	}
+
+	js, _ := NewFormatter(FormatJSON)
+	out, err = js.Show(result)
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	for _, want := range []string{`"docMDP": 2`, `"part": 2`, `"field": "Signature1"`, `"covered": 250`, `"certifying": true`} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("JSON Show output missing %s: %q", want, out)
+		}
+	}
+}
+
+func TestFormatterShowEncryption(t *testing.T) {
+	t.Parallel()
+	result := model.ShowResult{InputPath: "in.pdf", Encrypted: true, Encryption: &model.Encryption{
+		Filter:          "Standard",
+		V:               4,
+		R:               4,
+		KeyLength:       128,
+		StmF:            "StdCF",
+		StrF:            "StdCF",
+		CryptFilters:    []model.CryptFilter{{Name: "StdCF", Method: "AESV2", Length: 128}},
+		EncryptMetadata: true,
+		Permissions:     model.Permissions{P: -3900, Print: true},
+		Access:          model.AccessUser,
+	}}
+
+	text, _ := NewFormatter(FormatText)
+	out, err := text.Show(result)
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	want := "Encryption:\n  Filter: Standard\n  V: 4\n  R: 4\n  KeyLength: 128\n  StmF: StdCF\n  StrF: StdCF\n" +
+		"  CryptFilter: StdCF AESV2 128\n  EncryptMetadata: true\n  Access: user\n  Permissions: -3900\n" +
+		"    Print: true\n    Modify: false\n    Copy: false\n    Annotate: false\n    FillForms: false\n    Assemble: false\n"
+	if !strings.HasSuffix(string(out), want) {
+		t.Fatalf("text Show output mismatch: %q", out)
+	}
+
+	js, _ := NewFormatter(FormatJSON)
+	out, err = js.Show(result)
+	if err != nil {
+		t.Fatalf("Show error: %v", err)
+	}
+	for _, want := range []string{`"keyLength": 128`, `"method": "AESV2"`, `"access": "user"`, `"p": -3900`, `"print": true`, `"fillForms": false`} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("JSON Show output missing %s: %q", want, out)
+		}
+	}
+}
+
 func TestTextFormatterTemplateList(t *testing.T) {
 	t.Parallel()
 	f, _ := NewFormatter(FormatText)
@@ -86,6 +281,14 @@ func TestFormatterErr(t *testing.T) {
 		t.Fatalf("json Err output mismatch: %q", got)
 	}
 
+	reasonErr := &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonPasswordRequired, Message: "pdf requires a password"}
+	if out, _ := text.Err(reasonErr); !strings.Contains(string(out), "error[pdf_encrypted/password_required]: pdf requires a password") {
+		t.Fatalf("text Err reason output mismatch: %q", out)
+	}
+	if out, _ := json.Err(reasonErr); !strings.Contains(string(out), `"reason": "password_required"`) {
+		t.Fatalf("json Err reason output mismatch: %q", out)
+	}
+
 	plainOut, err := json.Err(errors.New("boom"))
 	if err != nil {
 		t.Fatalf("json Err plain error: %v", err)
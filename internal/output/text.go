@@ -13,19 +13,163 @@ func (textFormatter) Show(result model.ShowResult) ([]byte, error) {
 	lines := []string{
 		fmt.Sprintf("Input: %s", result.InputPath),
 		fmt.Sprintf("Encrypted: %t", result.Encrypted),
+		fmt.Sprintf("Linearized: %t", result.Linearized),
 		fmt.Sprintf("InfoPresent: %t", result.InfoFound),
 		fmt.Sprintf("XMPPresent: %t", result.XMPFound),
 		fmt.Sprintf("Normalized: %t", result.Normalized),
 		"Metadata:",
 		fmt.Sprintf("  Title: %s", result.Metadata.Title),
-		fmt.Sprintf("  Author: %s", result.Metadata.Author),
+		fmt.Sprintf("  Author: %s", model.JoinAuthors(result.Metadata.Authors)),
 		fmt.Sprintf("  Subject: %s", result.Metadata.Subject),
-		fmt.Sprintf("  Keywords: %s", result.Metadata.Keywords),
+		fmt.Sprintf("  Keywords: %s", model.JoinKeywords(result.Metadata.Keywords)),
 		fmt.Sprintf("  Creator: %s", result.Metadata.Creator),
 		fmt.Sprintf("  Producer: %s", result.Metadata.Producer),
 		fmt.Sprintf("  CreationDate: %s", result.Metadata.CreationDate),
 		fmt.Sprintf("  ModDate: %s", result.Metadata.ModDate),
 	}
+	if st := result.Structure; st != nil {
+		lines = append(lines,
+			"Structure:",
+			fmt.Sprintf("  HeaderVersion: %s", st.HeaderVersion),
+		)
+		if st.CatalogVersion != "" {
+			lines = append(lines, fmt.Sprintf("  CatalogVersion: %s", st.CatalogVersion))
+		}
+		lines = append(lines,
+			fmt.Sprintf("  Pages: %d", st.Pages),
+			fmt.Sprintf("  FileSize: %d", st.FileSize),
+			fmt.Sprintf("  Revisions: %d", st.Revisions),
+			fmt.Sprintf("  Tagged: %t", st.Tagged),
+			fmt.Sprintf("  Signed: %t", st.Signed),
+			fmt.Sprintf("  EmbeddedFiles: %t", st.EmbeddedFiles),
+			fmt.Sprintf("  Forms: %t", st.Forms),
+		)
+		if st.PDFA != nil {
+			lines = append(lines, fmt.Sprintf("  PDFA: %s", st.PDFA))
+		}
+		if st.DocMDP != 0 {
+			lines = append(lines, fmt.Sprintf("  DocMDP: %d", st.DocMDP))
+		}
+		for _, sig := range st.Signatures {
+			lines = append(lines, fmt.Sprintf("  Signature: %s", sig.Field))
+			if sig.SubFilter != "" {
+				lines = append(lines, fmt.Sprintf("    SubFilter: %s", sig.SubFilter))
+			}
+			lines = append(lines, fmt.Sprintf("    ByteRange: %v", sig.ByteRange), fmt.Sprintf("    Covered: %d", sig.Covered))
+			for _, kv := range [][2]string{{"Name", sig.Name}, {"Reason", sig.Reason}, {"Location", sig.Location}, {"SigningTime", sig.SigningTime}} {
+				if kv[1] != "" {
+					lines = append(lines, fmt.Sprintf("    %s: %s", kv[0], kv[1]))
+				}
+			}
+			if sig.Certifying {
+				lines = append(lines, "    Certifying: true")
+			}
+		}
+	}
+	if e := result.Encryption; e != nil {
+		lines = append(lines,
+			"Encryption:",
+			fmt.Sprintf("  Filter: %s", e.Filter),
+		)
+		if e.SubFilter != "" {
+			lines = append(lines, fmt.Sprintf("  SubFilter: %s", e.SubFilter))
+		}
+		lines = append(lines,
+			fmt.Sprintf("  V: %d", e.V),
+			fmt.Sprintf("  R: %d", e.R),
+			fmt.Sprintf("  KeyLength: %d", e.KeyLength),
+		)
+		if e.StmF != "" || e.StrF != "" {
+			lines = append(lines, fmt.Sprintf("  StmF: %s", e.StmF), fmt.Sprintf("  StrF: %s", e.StrF))
+		}
+		for _, cf := range e.CryptFilters {
+			line := fmt.Sprintf("  CryptFilter: %s %s", cf.Name, cf.Method)
+			if cf.Length > 0 {
+				line += fmt.Sprintf(" %d", cf.Length)
+			}
+			lines = append(lines, line)
+		}
+		p := e.Permissions
+		lines = append(lines,
+			fmt.Sprintf("  EncryptMetadata: %t", e.EncryptMetadata),
+			fmt.Sprintf("  Access: %s", e.Access),
+			fmt.Sprintf("  Permissions: %d", p.P),
+			fmt.Sprintf("    Print: %t", p.Print),
+			fmt.Sprintf("    Modify: %t", p.Modify),
+			fmt.Sprintf("    Copy: %t", p.Copy),
+			fmt.Sprintf("    Annotate: %t", p.Annotate),
+			fmt.Sprintf("    FillForms: %t", p.FillForms),
+			fmt.Sprintf("    Assemble: %t", p.Assemble),
+		)
+	}
+	if r := result.Repair; r != nil {
+		lines = append(lines, "Repair:")
+		if r.XRefError != "" {
+			lines = append(lines, fmt.Sprintf("  XRefError: %s", r.XRefError))
+		}
+		lines = append(lines,
+			fmt.Sprintf("  Objects: %d", r.Objects),
+			fmt.Sprintf("  Root: %s (%s)", r.Root, r.RootSource),
+		)
+		if r.Info != "" {
+			lines = append(lines, fmt.Sprintf("  Info: %s (%s)", r.Info, r.InfoSource))
+		} else {
+			lines = append(lines, "  Info: none")
+		}
+	}
+	for _, w := range result.Warnings {
+		lines = append(lines, "Warning: "+w)
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func (textFormatter) History(result model.HistoryResult) ([]byte, error) {
+	lines := []string{
+		fmt.Sprintf("Input: %s", result.InputPath),
+		fmt.Sprintf("Revisions: %d", len(result.Revisions)),
+	}
+	for _, rev := range result.Revisions {
+		lines = append(lines,
+			fmt.Sprintf("Revision %d: bytes %d-%d (%d bytes), xref at %d", rev.Index, rev.StartOffset, rev.EndOffset, rev.Size, rev.XRefOffset),
+			fmt.Sprintf("  InfoPresent: %t", rev.InfoFound),
+			fmt.Sprintf("  XMPPresent: %t", rev.XMPFound),
+			"  Metadata:",
+		)
+		for _, f := range model.AllFields {
+			if v := rev.Metadata.Value(f); v != "" {
+				lines = append(lines, fmt.Sprintf("    %s: %s", f, v))
+			}
+		}
+		if rev.Index > 1 {
+			if len(rev.Changes) == 0 {
+				lines = append(lines, "  Changes: none")
+				continue
+			}
+			lines = append(lines, "  Changes:")
+			for _, c := range rev.Changes {
+				lines = append(lines, fmt.Sprintf("    %s: %q -> %q", c.Field, c.From, c.To))
+			}
+		}
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+func (textFormatter) Revert(result model.RevertResult) ([]byte, error) {
+	rev := result.Revision
+	lines := []string{
+		fmt.Sprintf("Input: %s", result.InputPath),
+		fmt.Sprintf("Revision: %d", rev.Index),
+		fmt.Sprintf("Dropped: %d", result.Dropped),
+		fmt.Sprintf("FileSize: %d", rev.EndOffset),
+		fmt.Sprintf("InfoPresent: %t", rev.InfoFound),
+		fmt.Sprintf("XMPPresent: %t", rev.XMPFound),
+		"Metadata:",
+	}
+	for _, f := range model.AllFields {
+		if v := rev.Metadata.Value(f); v != "" {
+			lines = append(lines, fmt.Sprintf("  %s: %s", f, v))
+		}
+	}
 	return []byte(strings.Join(lines, "\n") + "\n"), nil
 }
 
@@ -58,14 +202,14 @@ func (textFormatter) Template(record model.TemplateRecord) ([]byte, error) {
 	if record.Metadata.Title != nil {
 		lines = append(lines, fmt.Sprintf("  Title: %s", *record.Metadata.Title))
 	}
-	if record.Metadata.Author != nil {
-		lines = append(lines, fmt.Sprintf("  Author: %s", *record.Metadata.Author))
+	if record.Metadata.Authors != nil {
+		lines = append(lines, fmt.Sprintf("  Author: %s", model.JoinAuthors(record.Metadata.Authors)))
 	}
 	if record.Metadata.Subject != nil {
 		lines = append(lines, fmt.Sprintf("  Subject: %s", *record.Metadata.Subject))
 	}
 	if record.Metadata.Keywords != nil {
-		lines = append(lines, fmt.Sprintf("  Keywords: %s", *record.Metadata.Keywords))
+		lines = append(lines, fmt.Sprintf("  Keywords: %s", model.JoinKeywords(record.Metadata.Keywords)))
 	}
 	if record.Metadata.Creator != nil {
 		lines = append(lines, fmt.Sprintf("  Creator: %s", *record.Metadata.Creator))
@@ -95,6 +239,9 @@ func (t textFormatter) TemplateList(records []model.TemplateRecord) ([]byte, err
 
 func (textFormatter) Err(err error) ([]byte, error) {
 	if ae, ok := err.(*model.AppError); ok {
+		if ae.Reason != "" {
+			return []byte(fmt.Sprintf("error[%s/%s]: %s\n", ae.Code, ae.Reason, ae.Error())), nil
+		}
 		return []byte(fmt.Sprintf("error[%s]: %s\n", ae.Code, ae.Error())), nil
 	}
 	return []byte("error: " + err.Error() + "\n"), nil
@@ -0,0 +1,171 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// testObject is one indirect object written by buildPDF.
+type testObject struct {
+	num  int
+	gen  int
+	body string
+}
+
+// testRevision is one body+xref+trailer section written by buildPDF.
+// packed objects are only supported by buildXRefStreamPDF, which stores them
+// in a single /Type /ObjStm.
+type testRevision struct {
+	objects []testObject
+	packed  []testObject
+	free    []int
+	trailer string
+}
+
+// buildPDF writes revisions with correct classic xref tables and /Prev links.
+func buildPDF(revs ...testRevision) []byte {
+	var b strings.Builder
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	prev := -1
+	size := 1
+	for _, rev := range revs {
+		offsets := map[int]int{}
+		gens := map[int]int{}
+		for _, obj := range rev.objects {
+			offsets[obj.num] = b.Len()
+			gens[obj.num] = obj.gen
+			fmt.Fprintf(&b, "%d %d obj\n%s\nendobj\n", obj.num, obj.gen, obj.body)
+			if obj.num+1 > size {
+				size = obj.num + 1
+			}
+		}
+		nums := make([]int, 0, len(offsets)+len(rev.free))
+		for num := range offsets {
+			nums = append(nums, num)
+		}
+		nums = append(nums, rev.free...)
+		sort.Ints(nums)
+
+		xrefOff := b.Len()
+		b.WriteString("xref\n")
+		if prev < 0 {
+			b.WriteString("0 1\n0000000000 65535 f \n")
+		}
+		for _, num := range nums {
+			if off, ok := offsets[num]; ok {
+				fmt.Fprintf(&b, "%d 1\n%010d %05d n \n", num, off, gens[num])
+			} else {
+				fmt.Fprintf(&b, "%d 1\n0000000000 00001 f \n", num)
+			}
+		}
+		fmt.Fprintf(&b, "trailer\n<< /Size %d %s", size, rev.trailer)
+		if prev >= 0 {
+			fmt.Fprintf(&b, " /Prev %d", prev)
+		}
+		fmt.Fprintf(&b, " >>\nstartxref\n%d\n%%%%EOF\n", xrefOff)
+		prev = xrefOff
+	}
+	return []byte(b.String())
+}
+
+// buildXRefStreamPDF writes revisions whose cross-reference sections are
+// FlateDecode /Type /XRef streams with a PNG Up predictor, as PDF 1.5+
+// producers emit them.
+func buildXRefStreamPDF(revs ...testRevision) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+	prev := -1
+	size := 1
+	for _, rev := range revs {
+		rows := map[int][]byte{}
+		for _, obj := range rev.objects {
+			rows[obj.num] = xrefRow(1, b.Len(), obj.gen)
+			fmt.Fprintf(&b, "%d %d obj\n%s\nendobj\n", obj.num, obj.gen, obj.body)
+			if obj.num+1 > size {
+				size = obj.num + 1
+			}
+		}
+		for _, num := range rev.free {
+			rows[num] = xrefRow(0, 0, 1)
+		}
+		if len(rev.packed) > 0 {
+			for _, obj := range rev.packed {
+				if obj.num+1 > size {
+					size = obj.num + 1
+				}
+			}
+			stmNum := size
+			size++
+			rows[stmNum] = xrefRow(1, b.Len(), 0)
+			var header, body strings.Builder
+			for i, obj := range rev.packed {
+				rows[obj.num] = xrefRow(2, stmNum, i)
+				fmt.Fprintf(&header, "%d %d ", obj.num, body.Len())
+				body.WriteString(obj.body)
+				body.WriteString("\n")
+			}
+			data := deflate([]byte(header.String() + body.String()))
+			fmt.Fprintf(&b, "%d 0 obj\n<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n",
+				stmNum, len(rev.packed), header.Len(), len(data))
+			b.Write(data)
+			b.WriteString("\nendstream\nendobj\n")
+		}
+		xrefNum := size
+		size++
+		xrefOff := b.Len()
+		rows[xrefNum] = xrefRow(1, xrefOff, 0)
+
+		nums := make([]int, 0, len(rows))
+		for num := range rows {
+			nums = append(nums, num)
+		}
+		sort.Ints(nums)
+		var raw []byte
+		var index []string
+		for _, num := range nums {
+			raw = append(raw, rows[num]...)
+			index = append(index, fmt.Sprintf("%d 1", num))
+		}
+		data := deflate(pngUpEncode(raw, 7))
+
+		fmt.Fprintf(&b, "%d 0 obj\n<< /Type /XRef /Size %d /W [1 4 2] /Index [%s] /Filter /FlateDecode /DecodeParms << /Predictor 12 /Columns 7 >> /Length %d %s",
+			xrefNum, size, strings.Join(index, " "), len(data), rev.trailer)
+		if prev >= 0 {
+			fmt.Fprintf(&b, " /Prev %d", prev)
+		}
+		b.WriteString(" >>\nstream\n")
+		b.Write(data)
+		fmt.Fprintf(&b, "\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOff)
+		prev = xrefOff
+	}
+	return b.Bytes()
+}
+
+func xrefRow(typ, f2, f3 int) []byte {
+	return []byte{byte(typ), byte(f2 >> 24), byte(f2 >> 16), byte(f2 >> 8), byte(f2), byte(f3 >> 8), byte(f3)}
+}
+
+func pngUpEncode(raw []byte, columns int) []byte {
+	var out []byte
+	prev := make([]byte, columns)
+	for off := 0; off < len(raw); off += columns {
+		row := raw[off : off+columns]
+		out = append(out, 2)
+		for i, c := range row {
+			out = append(out, c-prev[i])
+		}
+		prev = row
+	}
+	return out
+}
+
+func deflate(b []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, _ = zw.Write(b)
+	_ = zw.Close()
+	return buf.Bytes()
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 
+	"pdfmeta/internal/crypt"
 	"pdfmeta/internal/model"
 )
 
@@ -15,15 +18,47 @@ const (
 )
 
 // Document represents a parsed PDF envelope used by metadata readers/writers.
+// Documents returned by Open read from a memory-mapped file where the platform
+// supports it and must be closed.
 type Document struct {
 	path         string
 	content      []byte
+	release      func() error
 	headerOffset int
 	version      string
 	encrypted    bool
+	objects      map[int]objectLoc
+	xref         map[int]xrefEntry
+	xrefSections []xrefSection
+	xrefShift    int
+	xrefErr      error
+	trailer      Dict
+
+	// security decrypts objects once Unlock succeeds.
+	security   *crypt.Handler
+	encryptRef Ref
+
+	objStreams    map[int]*objectStream
+	loadingObjStm map[int]bool
 }
 
-// Open loads a PDF from disk and parses envelope metadata needed by callers.
+// objectLoc records where an indirect object header starts, or, for
+// compressed objects, which object stream holds it and at what position.
+// Compressed objects found by scanning also carry the stream's offset, so
+// they sort with the revision that wrote them.
+type objectLoc struct {
+	ref        Ref
+	offset     int
+	compressed bool
+	stream     int
+	index      int
+}
+
+// Open maps a PDF from disk and parses envelope metadata needed by callers.
+// Objects are parsed on demand through the cross-reference index, so only the
+// pages of the file that are actually read are loaded. Close releases the
+// mapping; objects and stream data obtained from the document must not be
+// used afterwards.
 func Open(path string) (*Document, error) {
 	if path == "" {
 		return nil, &model.AppError{
@@ -32,7 +67,7 @@ func Open(path string) (*Document, error) {
 		}
 	}
 
-	b, err := os.ReadFile(path)
+	b, release, err := loadFile(path)
 	if err != nil {
 		code := model.ErrIO
 		if errors.Is(err, os.ErrNotExist) {
@@ -45,15 +80,47 @@ func Open(path string) (*Document, error) {
 		}
 	}
 
-	doc, err := ParseBytes(path, b)
+	doc, err := parseContent(path, b)
 	if err != nil {
+		if release != nil {
+			_ = release()
+		}
 		return nil, err
 	}
+	doc.release = release
 	return doc, nil
 }
 
-// ParseBytes parses a PDF envelope from in-memory bytes.
+// loadFile maps path read-only, falling back to reading it into memory when
+// the file cannot be mapped (for example a pipe).
+func loadFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Mode().IsRegular() && info.Size() > 0 {
+		if b, release, err := mapFile(f, info.Size()); err == nil {
+			return b, release, nil
+		}
+	}
+	b, err := io.ReadAll(f)
+	return b, nil, err
+}
+
+// ParseBytes parses a PDF envelope from in-memory bytes. The document keeps
+// its own copy of b.
 func ParseBytes(path string, b []byte) (*Document, error) {
+	return parseContent(path, append([]byte(nil), b...))
+}
+
+// parseContent parses b without copying it; the document reads from b for
+// its whole lifetime.
+func parseContent(path string, b []byte) (*Document, error) {
 	if len(b) == 0 {
 		return nil, &model.AppError{
 			Code:    model.ErrPDFMalformed,
@@ -71,10 +138,18 @@ func ParseBytes(path string, b []byte) (*Document, error) {
 
 	doc := &Document{
 		path:         path,
-		content:      append([]byte(nil), b...),
+		content:      b,
 		headerOffset: headerOffset,
 		version:      parseVersionAt(b, headerOffset),
-		encrypted:    hasEncryptMarkerInTrailer(b),
+
+		objStreams:    map[int]*objectStream{},
+		loadingObjStm: map[int]bool{},
+	}
+	doc.loadXRef()
+	if trailer, err := doc.Trailer(); err == nil {
+		_, doc.encrypted = trailer["Encrypt"]
+	} else {
+		doc.encrypted = hasEncryptMarkerInTrailer(b)
 	}
 	return doc, nil
 }
@@ -104,6 +179,8 @@ func (d *Document) Encrypted() bool {
 	return d != nil && d.encrypted
 }
 
+// Bytes returns a copy of the whole file. Prefer ReadAt or Size for large
+// files.
 func (d *Document) Bytes() []byte {
 	if d == nil {
 		return nil
@@ -111,6 +188,376 @@ func (d *Document) Bytes() []byte {
 	return append([]byte(nil), d.content...)
 }
 
+// Size returns the file length in bytes.
+func (d *Document) Size() int64 {
+	if d == nil {
+		return 0
+	}
+	return int64(len(d.content))
+}
+
+// ReadAt implements io.ReaderAt over the original file bytes, so callers can
+// stream them with io.NewSectionReader instead of copying.
+func (d *Document) ReadAt(p []byte, off int64) (int, error) {
+	if d == nil || off < 0 {
+		return 0, errors.New("pdf: invalid offset")
+	}
+	if off >= int64(len(d.content)) {
+		return 0, io.EOF
+	}
+	n := copy(p, d.content[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Close releases the file mapping, if any. It is safe to call more than once
+// and on documents that do not own a mapping.
+func (d *Document) Close() error {
+	if d == nil || d.release == nil {
+		return nil
+	}
+	release := d.release
+	d.release = nil
+	d.content = nil
+	d.objects = nil
+	return release()
+}
+
+// XRefError reports why the startxref/Prev chain could not be fully parsed.
+// It returns nil when every cross-reference section was read successfully.
+func (d *Document) XRefError() error {
+	if d == nil {
+		return nil
+	}
+	return d.xrefErr
+}
+
+// UsesXRefStreams reports whether the newest cross-reference section is a
+// /Type /XRef stream, so that incremental updates can match its style.
+func (d *Document) UsesXRefStreams() bool {
+	return d != nil && len(d.xrefSections) > 0 && d.xrefSections[0].stream
+}
+
+// Trailer returns the trailer of the newest cross-reference section. When the
+// cross-reference chain is unusable it falls back to the dictionary following
+// the last trailer keyword in the file.
+func (d *Document) Trailer() (Dict, error) {
+	if d == nil {
+		return nil, malformed("trailer not found", nil)
+	}
+	if d.trailer != nil {
+		return d.trailer, nil
+	}
+	b := d.content
+	end := len(b)
+	for end > 0 {
+		idx := bytes.LastIndex(b[:end], []byte("trailer"))
+		if idx < 0 {
+			break
+		}
+		p := newParser(b, idx+len("trailer"))
+		if obj, err := p.parseObject(); err == nil {
+			if dict, ok := obj.(Dict); ok {
+				return dict, nil
+			}
+		}
+		end = idx
+	}
+	return nil, malformed("trailer not found", nil)
+}
+
+// Object loads the indirect object identified by ref, whether it is stored at
+// the top level or inside an object stream.
+func (d *Document) Object(ref Ref) (Object, error) {
+	loc, ok := d.lookup(ref)
+	if !ok {
+		return nil, malformed(fmt.Sprintf("object %d %d not found", ref.Num, ref.Gen), nil)
+	}
+	if loc.compressed {
+		return d.compressedObject(loc)
+	}
+	_, obj, _, err := d.objectParser(loc.offset).parseIndirectObject()
+	if err != nil {
+		return nil, malformed(fmt.Sprintf("parse object %d %d", ref.Num, ref.Gen), err)
+	}
+	return d.decryptObject(ref, obj)
+}
+
+// DecodeStream returns the stream's data with its /Filter chain applied.
+// Indirect /Filter and /DecodeParms values are resolved first.
+func (d *Document) DecodeStream(s Stream) ([]byte, error) {
+	dict := s.Dict.Clone()
+	for _, key := range []Name{"Filter", "DecodeParms"} {
+		v, ok := dict[key]
+		if !ok {
+			continue
+		}
+		resolved, err := d.resolveDeep(v)
+		if err != nil {
+			return nil, err
+		}
+		dict[key] = resolved
+	}
+	data, err := decodeStream(Stream{Dict: dict, Data: s.Data})
+	if err != nil {
+		return nil, malformed("decode stream", err)
+	}
+	return data, nil
+}
+
+// resolveDeep resolves obj and, for arrays, each element one level down.
+func (d *Document) resolveDeep(obj Object) (Object, error) {
+	v, err := d.Resolve(obj)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := v.(Array)
+	if !ok {
+		return v, nil
+	}
+	out := make(Array, len(arr))
+	for i, e := range arr {
+		if out[i], err = d.Resolve(e); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// objectParser returns a parser at off that can resolve indirect stream
+// lengths through the document.
+func (d *Document) objectParser(off int) *parser {
+	p := newParser(d.content, off)
+	p.length = d.resolveLength
+	return p
+}
+
+// resolveLength looks up an indirect /Length, following further references.
+// Length objects are parsed without a resolver so a self-referencing length
+// cannot recurse, and the chain is capped like Resolve.
+func (d *Document) resolveLength(ref Ref) (int64, bool) {
+	for i := 0; i < maxNestingDepth; i++ {
+		loc, ok := d.lookup(ref)
+		if !ok {
+			return 0, false
+		}
+		var obj Object
+		var err error
+		if loc.compressed {
+			obj, err = d.compressedObject(loc)
+		} else {
+			_, obj, _, err = newParser(d.content, loc.offset).parseIndirectObject()
+		}
+		if err != nil {
+			return 0, false
+		}
+		switch v := obj.(type) {
+		case Integer:
+			return int64(v), true
+		case Ref:
+			ref = v
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// Resolve follows indirect references until a direct object is reached.
+// References to missing objects resolve to Null, as the PDF spec requires.
+func (d *Document) Resolve(obj Object) (Object, error) {
+	for i := 0; i < maxNestingDepth; i++ {
+		ref, ok := obj.(Ref)
+		if !ok {
+			return obj, nil
+		}
+		if _, ok := d.lookup(ref); !ok {
+			return Null{}, nil
+		}
+		next, err := d.Object(ref)
+		if err != nil {
+			return nil, err
+		}
+		obj = next
+	}
+	return nil, malformed("reference chain too deep", nil)
+}
+
+// MaxObjectNumber returns the highest object number defined in the file.
+func (d *Document) MaxObjectNumber() int {
+	if d == nil {
+		return 0
+	}
+	max := 0
+	if d.xrefErr == nil && len(d.xref) > 0 {
+		for num := range d.xref {
+			if num > max {
+				max = num
+			}
+		}
+		if size, ok := d.trailer.Int("Size"); ok && int(size)-1 > max {
+			max = int(size) - 1
+		}
+		return max
+	}
+	for num := range d.index() {
+		if num > max {
+			max = num
+		}
+	}
+	for num := range d.xref {
+		if num > max {
+			max = num
+		}
+	}
+	return max
+}
+
+// StartXRef returns the offset recorded after the last startxref keyword.
+func (d *Document) StartXRef() (int64, bool) {
+	if d == nil {
+		return 0, false
+	}
+	return parseStartXRef(d.content)
+}
+
+// lookup finds where ref is stored. The cross-reference index is authoritative;
+// the header scan is only consulted when the chain is broken or an entry
+// points at the wrong place.
+func (d *Document) lookup(ref Ref) (objectLoc, bool) {
+	if d == nil {
+		return objectLoc{}, false
+	}
+	if e, ok := d.xref[ref.Num]; ok {
+		if e.typ == xrefCompressed {
+			if ref.Gen != 0 {
+				return objectLoc{}, false
+			}
+			return objectLoc{ref: ref, compressed: true, stream: e.stream, index: e.index}, true
+		}
+		if e.typ != xrefInUse || e.gen != ref.Gen {
+			return objectLoc{}, false
+		}
+		off := e.offset + int64(d.xrefShift)
+		if d.headerAt(off, ref) {
+			return objectLoc{ref: ref, offset: int(off)}, true
+		}
+	} else if d.xrefErr == nil && len(d.xref) > 0 {
+		return objectLoc{}, false
+	}
+	loc, ok := d.index()[ref.Num]
+	if !ok || loc.ref.Gen != ref.Gen {
+		return objectLoc{}, false
+	}
+	return loc, true
+}
+
+func (d *Document) headerAt(off int64, ref Ref) bool {
+	if off < 0 || off >= int64(len(d.content)) {
+		return false
+	}
+	got, err := newParser(d.content, int(off)).parseObjectHeader()
+	return err == nil && got == ref
+}
+
+func (d *Document) index() map[int]objectLoc {
+	if d.objects == nil {
+		d.objects = scanObjects(d.content)
+	}
+	return d.objects
+}
+
+// scanObjects walks the file for "N G obj" headers. Later definitions of an
+// object number replace earlier ones, matching incremental update semantics.
+// Objects packed into an /ObjStm are registered at the stream's position.
+func scanObjects(b []byte) map[int]objectLoc {
+	locs := map[int]objectLoc{}
+	pos := 0
+	for pos < len(b) {
+		idx := bytes.Index(b[pos:], []byte("obj"))
+		if idx < 0 {
+			break
+		}
+		idx += pos
+		if start, ok := objectHeaderStart(b, idx); ok {
+			p := newParser(b, start)
+			if ref, obj, end, err := p.parseIndirectObject(); err == nil && end > idx {
+				locs[ref.Num] = objectLoc{ref: ref, offset: start}
+				if s, ok := obj.(Stream); ok && s.Dict.TypeName() == "ObjStm" {
+					if stm, err := parseObjectStream(s); err == nil {
+						for i, num := range stm.nums {
+							locs[num] = objectLoc{ref: Ref{Num: num}, offset: start, compressed: true, stream: ref.Num, index: i}
+						}
+					}
+				}
+				pos = end
+				continue
+			}
+		}
+		pos = idx + len("obj")
+	}
+	return locs
+}
+
+// objectHeaderStart checks that the "obj" keyword at idx is preceded by two
+// unsigned integers and returns the offset of the first one.
+func objectHeaderStart(b []byte, idx int) (int, bool) {
+	if next := idx + len("obj"); next < len(b) && isRegular(b[next]) {
+		return 0, false
+	}
+	i := idx
+	digitsBack := func() bool {
+		n := 0
+		for i > 0 && isWhitespace(b[i-1]) {
+			i--
+			n++
+		}
+		if n == 0 {
+			return false
+		}
+		d := 0
+		for i > 0 && b[i-1] >= '0' && b[i-1] <= '9' {
+			i--
+			d++
+		}
+		return d > 0
+	}
+	if !digitsBack() || !digitsBack() {
+		return 0, false
+	}
+	if i > 0 && isRegular(b[i-1]) {
+		return 0, false
+	}
+	return i, true
+}
+
+func parseStartXRef(b []byte) (int64, bool) {
+	idx := bytes.LastIndex(b, []byte("startxref"))
+	if idx < 0 {
+		return 0, false
+	}
+	l := newLexer(b, idx+len("startxref"))
+	tok, err := l.next()
+	if err != nil || tok.kind != tokInteger {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(string(tok.value), 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+func malformed(message string, cause error) error {
+	return &model.AppError{
+		Code:    model.ErrPDFMalformed,
+		Message: message,
+		Cause:   cause,
+	}
+}
+
 func findHeaderOffset(b []byte) int {
 	limit := len(b)
 	if limit > headerScanLimit {
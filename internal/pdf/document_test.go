@@ -1,6 +1,8 @@
 package pdf
 
 import (
+	"bytes"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -98,6 +100,73 @@ func TestBytesReturnsCopy(t *testing.T) {
 	}
 }
 
+func TestOpenReadAtMatchesFile(t *testing.T) {
+	want, err := os.ReadFile(fixturePath("incremental-updates.pdf"))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	doc, err := Open(fixturePath("incremental-updates.pdf"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer doc.Close()
+
+	if doc.Size() != int64(len(want)) {
+		t.Fatalf("Size()=%d want %d", doc.Size(), len(want))
+	}
+	got, err := io.ReadAll(io.NewSectionReader(doc, 0, doc.Size()))
+	if err != nil || !bytes.Equal(got, want) {
+		t.Fatalf("streamed content differs from file (err=%v)", err)
+	}
+	tail := make([]byte, 10)
+	if n, err := doc.ReadAt(tail, doc.Size()-4); n != 4 || err != io.EOF || !bytes.Equal(tail[:n], want[len(want)-4:]) {
+		t.Fatalf("ReadAt past end = %d, %v", n, err)
+	}
+
+	// Revision views share the mapping and stay usable until Close.
+	revs := doc.Revisions()
+	first, err := doc.AtRevision(revs[0])
+	if err != nil {
+		t.Fatalf("AtRevision: %v", err)
+	}
+	if _, err := first.Trailer(); err != nil {
+		t.Fatalf("revision trailer: %v", err)
+	}
+	if first.Size() != revs[0].End {
+		t.Fatalf("revision Size()=%d want %d", first.Size(), revs[0].End)
+	}
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	doc, err := Open(fixturePath("minimal.pdf"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	if doc.Size() != 0 {
+		t.Fatalf("closed document still reports %d bytes", doc.Size())
+	}
+}
+
+func TestParseBytesKeepsOwnCopy(t *testing.T) {
+	b := readFixture(t, "minimal.pdf")
+	doc, err := ParseBytes("minimal.pdf", b)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	for i := range b {
+		b[i] = 0
+	}
+	if _, err := doc.Trailer(); err != nil {
+		t.Fatalf("document depends on caller's buffer: %v", err)
+	}
+}
+
 func TestParseBytesPathRoundTrip(t *testing.T) {
 	doc, err := ParseBytes("foo.pdf", readFixture(t, "minimal.pdf"))
 	if err != nil {
@@ -0,0 +1,327 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// maxDecodedStreamSize caps inflated stream output to guard against
+// decompression bombs.
+const maxDecodedStreamSize = 256 << 20
+
+// decodeStream applies the stream's /Filter chain to its raw data.
+func decodeStream(s Stream) ([]byte, error) {
+	filters, params, err := streamFilters(s.Dict)
+	if err != nil {
+		return nil, err
+	}
+	data := s.Data
+	for i, f := range filters {
+		data, err = applyFilter(f, params[i], data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f, err)
+		}
+	}
+	return data, nil
+}
+
+// streamFilters normalizes /Filter and /DecodeParms into parallel slices.
+func streamFilters(dict Dict) ([]Name, []Dict, error) {
+	var filters []Name
+	switch f := dict.Get("Filter").(type) {
+	case nil:
+		return nil, nil, nil
+	case Name:
+		filters = []Name{f}
+	case Array:
+		for _, e := range f {
+			n, ok := e.(Name)
+			if !ok {
+				return nil, nil, errors.New("/Filter array must contain names")
+			}
+			filters = append(filters, n)
+		}
+	default:
+		return nil, nil, errors.New("/Filter must be a name or array")
+	}
+
+	params := make([]Dict, len(filters))
+	switch p := dict.Get("DecodeParms").(type) {
+	case Dict:
+		if len(params) > 0 {
+			params[0] = p
+		}
+	case Array:
+		for i := 0; i < len(p) && i < len(params); i++ {
+			if d, ok := p[i].(Dict); ok {
+				params[i] = d
+			}
+		}
+	}
+	return filters, params, nil
+}
+
+func applyFilter(name Name, params Dict, data []byte) ([]byte, error) {
+	switch name {
+	case "FlateDecode", "Fl":
+		out, err := inflate(data)
+		if err != nil {
+			return nil, err
+		}
+		return applyPredictor(params, out)
+	case "ASCIIHexDecode", "AHx":
+		return asciiHexDecode(data)
+	case "ASCII85Decode", "A85":
+		return ascii85Decode(data)
+	case "Crypt":
+		// An Identity crypt filter exempts the stream from encryption, as
+		// in clear-text metadata streams; the data passes through.
+		if !identityCrypt(params) {
+			return nil, errors.New("unsupported crypt filter")
+		}
+		return data, nil
+	default:
+		return nil, errors.New("unsupported filter")
+	}
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, maxDecodedStreamSize+1))
+	if len(out) > maxDecodedStreamSize {
+		return nil, errors.New("decoded stream too large")
+	}
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	// Truncated zlib streams are common; keep whatever inflated cleanly.
+	return out, nil
+}
+
+// asciiHexDecode decodes hex digit pairs up to the '>' end marker. Whitespace
+// is ignored and an odd final digit is padded with 0.
+func asciiHexDecode(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data)/2)
+	var hi byte
+	half := false
+	for _, c := range data {
+		if c == '>' {
+			break
+		}
+		if isWhitespace(c) {
+			continue
+		}
+		v, ok := hexDigit(c)
+		if !ok {
+			return nil, fmt.Errorf("invalid hex digit %q", c)
+		}
+		if half {
+			out = append(out, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		out = append(out, hi<<4)
+	}
+	return out, nil
+}
+
+// ascii85Decode decodes base-85 groups up to the "~>" end marker, including
+// the 'z' shorthand for four zero bytes and a short final group.
+func ascii85Decode(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data)*4/5)
+	var group [5]byte
+	n := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '~':
+			i = len(data)
+			continue
+		case isWhitespace(c):
+			continue
+		case c == 'z' && n == 0:
+			out = append(out, 0, 0, 0, 0)
+			continue
+		case c < '!' || c > 'u':
+			return nil, fmt.Errorf("invalid ASCII85 character %q", c)
+		}
+		group[n] = c - '!'
+		n++
+		if n == 5 {
+			var err error
+			if out, err = appendA85Group(out, group, 4); err != nil {
+				return nil, err
+			}
+			n = 0
+		}
+	}
+	switch n {
+	case 0:
+		return out, nil
+	case 1:
+		return nil, errors.New("ASCII85 data ends with a single character")
+	default:
+		for i := n; i < 5; i++ {
+			group[i] = 'u' - '!'
+		}
+		return appendA85Group(out, group, n-1)
+	}
+}
+
+func appendA85Group(out []byte, group [5]byte, keep int) ([]byte, error) {
+	var v uint64
+	for _, d := range group {
+		v = v*85 + uint64(d)
+	}
+	if v > 0xFFFFFFFF {
+		return nil, errors.New("ASCII85 group out of range")
+	}
+	b := [4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	return append(out, b[:keep]...), nil
+}
+
+// applyPredictor reverses TIFF (2) and PNG (10-15) row predictors.
+func applyPredictor(params Dict, data []byte) ([]byte, error) {
+	predictor, _ := params.Int("Predictor")
+	if predictor <= 1 {
+		return data, nil
+	}
+	colors := intParam(params, "Colors", 1)
+	bpc := intParam(params, "BitsPerComponent", 8)
+	columns := intParam(params, "Columns", 1)
+	if colors < 1 || bpc < 1 || columns < 1 {
+		return nil, errors.New("invalid predictor parameters")
+	}
+	bpp := (colors*bpc + 7) / 8
+	rowLen := (colors*bpc*columns + 7) / 8
+
+	switch {
+	case predictor == 2:
+		return tiffUnpredict(data, rowLen, colors, bpc)
+	case predictor >= 10:
+		return pngUnpredict(data, rowLen, bpp)
+	default:
+		return nil, fmt.Errorf("unsupported predictor %d", predictor)
+	}
+}
+
+func intParam(params Dict, key Name, def int) int {
+	if v, ok := params.Int(key); ok {
+		return int(v)
+	}
+	return def
+}
+
+// tiffUnpredict reverses TIFF predictor 2 (horizontal differencing) for 8 and
+// 16 bits per component.
+func tiffUnpredict(data []byte, rowLen, colors, bpc int) ([]byte, error) {
+	if bpc != 8 && bpc != 16 {
+		return nil, fmt.Errorf("unsupported TIFF predictor bits per component %d", bpc)
+	}
+	out := append([]byte(nil), data...)
+	for off := 0; off < len(out); off += rowLen {
+		row := out[off:]
+		if len(row) > rowLen {
+			row = row[:rowLen]
+		}
+		if bpc == 8 {
+			for i := colors; i < len(row); i++ {
+				row[i] += row[i-colors]
+			}
+			continue
+		}
+		stride := 2 * colors
+		for i := stride; i+1 < len(row); i += 2 {
+			v := uint16(row[i])<<8 | uint16(row[i+1])
+			v += uint16(row[i-stride])<<8 | uint16(row[i-stride+1])
+			row[i], row[i+1] = byte(v>>8), byte(v)
+		}
+	}
+	return out, nil
+}
+
+func pngUnpredict(data []byte, rowLen, bpp int) ([]byte, error) {
+	stride := rowLen + 1
+	out := make([]byte, 0, len(data)/stride*rowLen)
+	prev := make([]byte, rowLen)
+	for off := 0; off+1 < len(data); off += stride {
+		end := off + stride
+		if end > len(data) {
+			end = len(data)
+		}
+		ft := data[off]
+		row := append([]byte(nil), data[off+1:end]...)
+		for len(row) < rowLen {
+			row = append(row, 0)
+		}
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left = row[i-bpp]
+				upLeft = prev[i-bpp]
+			}
+			up := prev[i]
+			switch ft {
+			case 0:
+			case 1:
+				row[i] += left
+			case 2:
+				row[i] += up
+			case 3:
+				row[i] += byte((int(left) + int(up)) / 2)
+			case 4:
+				row[i] += paeth(left, up, upLeft)
+			default:
+				return nil, fmt.Errorf("invalid PNG filter type %d", ft)
+			}
+		}
+		out = append(out, row...)
+		prev = row
+	}
+	return out, nil
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// FlateEncode compresses the stream data with FlateDecode. Streams that
+// already carry a /Filter are returned unchanged.
+func FlateEncode(s Stream) Stream {
+	if _, ok := s.Dict["Filter"]; ok {
+		return s
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, _ = zw.Write(s.Data)
+	_ = zw.Close()
+	dict := s.Dict.Clone()
+	dict["Filter"] = Name("FlateDecode")
+	return Stream{Dict: dict, Data: buf.Bytes()}
+}
@@ -0,0 +1,195 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStreamFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		dict string
+		data []byte
+		want string
+	}{
+		{name: "no filter", dict: "", data: []byte("plain"), want: "plain"},
+		{name: "asciihex", dict: "/Filter /ASCIIHexDecode", data: []byte("48 65 6c\n6C 6f>ignored"), want: "Hello"},
+		{name: "asciihex odd digit", dict: "/Filter /AHx", data: []byte("414>"), want: "A@"},
+		{name: "ascii85", dict: "/Filter /ASCII85Decode", data: []byte("87cURD_*#4\nDfTZ)~>"), want: "Hello, World"},
+		{name: "ascii85 z shorthand", dict: "/Filter /A85", data: []byte("z@:E^~>"), want: "\x00\x00\x00\x00abc"},
+		{name: "flate", dict: "/Filter /FlateDecode", data: deflate([]byte("inflated")), want: "inflated"},
+		{
+			name: "asciihex then flate",
+			dict: "/Filter [/ASCIIHexDecode /FlateDecode]",
+			data: []byte(fmt.Sprintf("%X>", deflate([]byte("chained")))),
+			want: "chained",
+		},
+		{name: "identity crypt", dict: "/Filter [/Crypt] /DecodeParms [<< /Name /Identity >>]", data: []byte("clear"), want: "clear"},
+		{name: "crypt without name", dict: "/Filter /Crypt", data: []byte("clear"), want: "clear"},
+		{
+			name: "flate with tiff predictor",
+			dict: "/Filter /FlateDecode /DecodeParms << /Predictor 2 /Colors 1 /Columns 3 >>",
+			data: deflate([]byte{1, 1, 1, 4, 2, 2}),
+			want: "\x01\x02\x03\x04\x06\x08",
+		},
+		{
+			name: "flate with 16-bit tiff predictor",
+			dict: "/Filter /FlateDecode /DecodeParms << /Predictor 2 /BitsPerComponent 16 /Columns 2 >>",
+			data: deflate([]byte{0x01, 0xFF, 0x00, 0x02}),
+			want: "\x01\xff\x02\x01",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			obj, err := ParseObject([]byte("<< " + tc.dict + " >>"))
+			if err != nil {
+				t.Fatalf("ParseObject: %v", err)
+			}
+			got, err := decodeStream(Stream{Dict: obj.(Dict), Data: tc.data})
+			if err != nil {
+				t.Fatalf("decodeStream: %v", err)
+			}
+			if string(got) != tc.want {
+				t.Fatalf("decodeStream()=%q want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDecodeStreamErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		dict string
+		data string
+		want string
+	}{
+		{name: "unsupported filter", dict: "/Filter /DCTDecode", data: "x", want: "unsupported filter"},
+		{name: "bad hex digit", dict: "/Filter /ASCIIHexDecode", data: "4G>", want: "invalid hex digit"},
+		{name: "bad ascii85 char", dict: "/Filter /ASCII85Decode", data: "87c{~>", want: "invalid ASCII85"},
+		{name: "ascii85 single trailing char", dict: "/Filter /ASCII85Decode", data: "87cURD~>", want: "single character"},
+		{name: "ascii85 overflow", dict: "/Filter /ASCII85Decode", data: "uuuuu~>", want: "out of range"},
+		{name: "bad filter type", dict: "/Filter (Flate)", data: "x", want: "name or array"},
+		{name: "named crypt filter", dict: "/Filter /Crypt /DecodeParms << /Name /StdCF >>", data: "x", want: "unsupported crypt filter"},
+		{name: "unsupported predictor", dict: "/Filter /FlateDecode /DecodeParms << /Predictor 3 >>", data: "x", want: "unsupported predictor 3"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			obj, err := ParseObject([]byte("<< " + tc.dict + " >>"))
+			if err != nil {
+				t.Fatalf("ParseObject: %v", err)
+			}
+			data := []byte(tc.data)
+			if strings.Contains(tc.dict, "Predictor") {
+				data = deflate(data)
+			}
+			_, err = decodeStream(Stream{Dict: obj.(Dict), Data: data})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("decodeStream() error=%v want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestIndirectStreamLength(t *testing.T) {
+	t.Parallel()
+
+	// The payload contains "endstream", so only the indirect /Length finds
+	// the real end of the data.
+	payload := "abc\nendstream\ndef"
+	src := buildPDF(testRevision{
+		objects: []testObject{
+			{num: 1, body: "<< /Type /Catalog /Metadata 2 0 R >>"},
+			{num: 2, body: "<< /Length 3 0 R >>\nstream\n" + payload + "\nendstream"},
+			{num: 3, body: fmt.Sprint(len(payload))},
+		},
+		trailer: "/Root 1 0 R",
+	})
+	doc, err := ParseBytes("length.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if got := string(obj.(Stream).Data); got != payload {
+		t.Fatalf("stream data=%q want %q", got, payload)
+	}
+}
+
+func TestChainedIndirectStreamLength(t *testing.T) {
+	t.Parallel()
+
+	payload := "abc\nendstream\ndef"
+	src := buildPDF(testRevision{
+		objects: []testObject{
+			{num: 1, body: "<< /Type /Catalog /Metadata 2 0 R >>"},
+			{num: 2, body: "<< /Length 3 0 R >>\nstream\n" + payload + "\nendstream"},
+			{num: 3, body: "4 0 R"},
+			{num: 4, body: fmt.Sprint(len(payload))},
+			{num: 5, body: "<< /Length 5 0 R >>\nstream\nxyz\nendstream"},
+		},
+		trailer: "/Root 1 0 R",
+	})
+	doc, err := ParseBytes("length-chain.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if got := string(obj.(Stream).Data); got != payload {
+		t.Fatalf("stream data=%q want %q", got, payload)
+	}
+	// A length that refers to itself falls back to the endstream scan.
+	obj, err = doc.Object(Ref{Num: 5})
+	if err != nil {
+		t.Fatalf("Object(5): %v", err)
+	}
+	if got := string(obj.(Stream).Data); got != "xyz" {
+		t.Fatalf("stream data=%q want %q", got, "xyz")
+	}
+}
+
+func TestDocumentDecodeStreamResolvesIndirectParams(t *testing.T) {
+	t.Parallel()
+
+	src := buildPDF(testRevision{
+		objects: []testObject{
+			{num: 1, body: "<< /Type /Catalog >>"},
+			{num: 2, body: "/ASCIIHexDecode"},
+			{num: 3, body: "<< /Filter [2 0 R] >>"},
+		},
+		trailer: "/Root 1 0 R",
+	})
+	doc, err := ParseBytes("params.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 3})
+	if err != nil {
+		t.Fatalf("Object(3): %v", err)
+	}
+	got, err := doc.DecodeStream(Stream{Dict: obj.(Dict), Data: []byte("6F6B>")})
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	if string(got) != "ok" {
+		t.Fatalf("DecodeStream()=%q want ok", got)
+	}
+	if _, ok := obj.(Dict).Get("Filter").(Array)[0].(Ref); !ok {
+		t.Fatalf("DecodeStream must not modify the stream dictionary")
+	}
+}
@@ -32,6 +32,18 @@ func TestFixtureFilesExistAndNonEmpty(t *testing.T) {
 		"leading-junk-before-header.pdf",
 		"truncated-no-eof.pdf",
 		"malformed-trailer.pdf",
+		"incremental-updates.pdf",
+		"xref-stream.pdf",
+		"object-streams.pdf",
+		"flate-metadata.pdf",
+		"linearized.pdf",
+		"encrypted-rc4-40.pdf",
+		"encrypted-rc4-128.pdf",
+		"encrypted-aes-128.pdf",
+		"encrypted-aes-256-r5.pdf",
+		"encrypted-aes-256.pdf",
+		"encrypted-crypt-filter-metadata.pdf",
+		"utf8-info-baseline.pdf",
 		"invalid.txt",
 	}
 	for _, name := range names {
@@ -82,6 +94,8 @@ func TestFixtureSignalTable(t *testing.T) {
 		{name: "leading-junk-before-header.pdf", looksPDF: true, hasEOF: true, hasEncrypt: false},
 		{name: "truncated-no-eof.pdf", looksPDF: true, hasEOF: false, hasEncrypt: false},
 		{name: "malformed-trailer.pdf", looksPDF: true, hasEOF: true, hasEncrypt: false},
+		{name: "incremental-updates.pdf", looksPDF: true, hasEOF: true, hasEncrypt: false},
+		{name: "xref-stream.pdf", looksPDF: true, hasEOF: true, hasEncrypt: false},
 		{name: "invalid.txt", looksPDF: false, hasEOF: false, hasEncrypt: false},
 	}
 
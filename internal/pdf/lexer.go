@@ -0,0 +1,300 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokInteger
+	tokReal
+	tokName
+	tokString
+	tokHexString
+	tokKeyword
+	tokArrayOpen
+	tokArrayClose
+	tokDictOpen
+	tokDictClose
+)
+
+// token is a single lexical element. For strings and names, value holds the
+// decoded bytes; for numbers and keywords it holds the raw text.
+type token struct {
+	kind  tokenKind
+	pos   int
+	end   int
+	value []byte
+}
+
+var errUnexpectedEOF = errors.New("unexpected end of data")
+
+// lexer splits PDF syntax into tokens. Comments are skipped.
+type lexer struct {
+	buf []byte
+	pos int
+}
+
+func newLexer(buf []byte, pos int) *lexer {
+	return &lexer{buf: buf, pos: pos}
+}
+
+func isWhitespace(c byte) bool {
+	switch c {
+	case 0x00, 0x09, 0x0A, 0x0C, 0x0D, 0x20:
+		return true
+	default:
+		return false
+	}
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	default:
+		return false
+	}
+}
+
+func isRegular(c byte) bool {
+	return !isWhitespace(c) && !isDelimiter(c)
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		if isWhitespace(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.buf) && l.buf[l.pos] != '\n' && l.buf[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		return
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	start := l.pos
+	if l.pos >= len(l.buf) {
+		return token{kind: tokEOF, pos: start, end: start}, nil
+	}
+
+	c := l.buf[l.pos]
+	switch c {
+	case '[':
+		l.pos++
+		return token{kind: tokArrayOpen, pos: start, end: l.pos}, nil
+	case ']':
+		l.pos++
+		return token{kind: tokArrayClose, pos: start, end: l.pos}, nil
+	case '<':
+		if l.pos+1 < len(l.buf) && l.buf[l.pos+1] == '<' {
+			l.pos += 2
+			return token{kind: tokDictOpen, pos: start, end: l.pos}, nil
+		}
+		return l.hexString()
+	case '>':
+		if l.pos+1 < len(l.buf) && l.buf[l.pos+1] == '>' {
+			l.pos += 2
+			return token{kind: tokDictClose, pos: start, end: l.pos}, nil
+		}
+		return token{}, fmt.Errorf("unexpected '>' at offset %d", start)
+	case '(':
+		return l.literalString()
+	case '/':
+		return l.name()
+	case ')', '{', '}':
+		return token{}, fmt.Errorf("unexpected %q at offset %d", c, start)
+	}
+
+	for l.pos < len(l.buf) && isRegular(l.buf[l.pos]) {
+		l.pos++
+	}
+	text := l.buf[start:l.pos]
+	return token{kind: classifyRegular(text), pos: start, end: l.pos, value: text}, nil
+}
+
+func classifyRegular(text []byte) tokenKind {
+	digits, dots := 0, 0
+	for i, c := range text {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			dots++
+		case (c == '+' || c == '-') && i == 0:
+		default:
+			return tokKeyword
+		}
+	}
+	if digits == 0 || dots > 1 {
+		return tokKeyword
+	}
+	if dots == 1 {
+		return tokReal
+	}
+	return tokInteger
+}
+
+func (l *lexer) name() (token, error) {
+	start := l.pos
+	l.pos++
+	var out []byte
+	for l.pos < len(l.buf) && isRegular(l.buf[l.pos]) {
+		c := l.buf[l.pos]
+		if c == '#' && l.pos+2 <= len(l.buf)-1 {
+			if v, ok := hexPair(l.buf[l.pos+1], l.buf[l.pos+2]); ok {
+				out = append(out, v)
+				l.pos += 3
+				continue
+			}
+		}
+		out = append(out, c)
+		l.pos++
+	}
+	return token{kind: tokName, pos: start, end: l.pos, value: out}, nil
+}
+
+// literalString reads a (...) string: balanced parentheses need no escape,
+// backslash escapes include \ddd octal codes and line continuations, and an
+// unescaped end-of-line marker (CR, LF or CRLF) reads as a single LF.
+func (l *lexer) literalString() (token, error) {
+	start := l.pos
+	l.pos++
+	depth := 1
+	out := []byte{}
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		switch c {
+		case '\\':
+			l.pos++
+			if l.pos >= len(l.buf) {
+				return token{}, errUnexpectedEOF
+			}
+			out = l.literalEscape(out)
+			continue
+		case '\r':
+			out = append(out, '\n')
+			l.pos++
+			if l.pos < len(l.buf) && l.buf[l.pos] == '\n' {
+				l.pos++
+			}
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				l.pos++
+				return token{kind: tokString, pos: start, end: l.pos, value: out}, nil
+			}
+		}
+		out = append(out, c)
+		l.pos++
+	}
+	return token{}, errUnexpectedEOF
+}
+
+// literalEscape decodes the escape sequence after a backslash at l.pos and
+// appends its value to out. A backslash before an end-of-line marker joins
+// the lines; before any other unknown character it is dropped.
+func (l *lexer) literalEscape(out []byte) []byte {
+	e := l.buf[l.pos]
+	l.pos++
+	switch e {
+	case 'n':
+		return append(out, '\n')
+	case 'r':
+		return append(out, '\r')
+	case 't':
+		return append(out, '\t')
+	case 'b':
+		return append(out, '\b')
+	case 'f':
+		return append(out, '\f')
+	case '\r':
+		if l.pos < len(l.buf) && l.buf[l.pos] == '\n' {
+			l.pos++
+		}
+		return out
+	case '\n':
+		return out
+	}
+	if e < '0' || e > '7' {
+		return append(out, e)
+	}
+	// Up to three octal digits; overflow beyond a byte is ignored.
+	v := int(e - '0')
+	for i := 0; i < 2 && l.pos < len(l.buf) && l.buf[l.pos] >= '0' && l.buf[l.pos] <= '7'; i++ {
+		v = v*8 + int(l.buf[l.pos]-'0')
+		l.pos++
+	}
+	return append(out, byte(v))
+}
+
+func (l *lexer) hexString() (token, error) {
+	start := l.pos
+	l.pos++
+	out := []byte{}
+	var hi byte
+	half := false
+	for l.pos < len(l.buf) {
+		c := l.buf[l.pos]
+		l.pos++
+		if c == '>' {
+			if half {
+				out = append(out, hi<<4)
+			}
+			return token{kind: tokHexString, pos: start, end: l.pos, value: out}, nil
+		}
+		if isWhitespace(c) {
+			continue
+		}
+		v, ok := hexDigit(c)
+		if !ok {
+			return token{}, fmt.Errorf("invalid hex digit %q at offset %d", c, l.pos-1)
+		}
+		if half {
+			out = append(out, hi<<4|v)
+			half = false
+		} else {
+			hi = v
+			half = true
+		}
+	}
+	return token{}, errUnexpectedEOF
+}
+
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	default:
+		return 0, false
+	}
+}
+
+func hexPair(a, b byte) (byte, bool) {
+	hi, ok := hexDigit(a)
+	if !ok {
+		return 0, false
+	}
+	lo, ok := hexDigit(b)
+	if !ok {
+		return 0, false
+	}
+	return hi<<4 | lo, true
+}
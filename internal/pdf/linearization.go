@@ -0,0 +1,54 @@
+package pdf
+
+// Linearization holds the values of a linearization parameter dictionary
+// ("fast web view"), which must be the first object in the file.
+type Linearization struct {
+	// Length is /L, the file length the linearization was computed for.
+	Length int64
+	// FirstPage is /O, the object number of the first page.
+	FirstPage int
+	// Pages is /N, the number of pages.
+	Pages int
+}
+
+// Linearization returns the linearization parameter dictionary when the
+// first object after the header carries a /Linearized key. The dictionary
+// must start within the first 1024 bytes of the file.
+func (d *Document) Linearization() (Linearization, bool) {
+	if d == nil || d.headerOffset < 0 {
+		return Linearization{}, false
+	}
+	p := newParser(d.content, d.headerOffset)
+	tok, err := p.peek(0)
+	if err != nil || tok.pos-d.headerOffset > headerScanLimit {
+		return Linearization{}, false
+	}
+	_, obj, _, err := p.parseIndirectObject()
+	if err != nil {
+		return Linearization{}, false
+	}
+	dict, ok := obj.(Dict)
+	if !ok {
+		return Linearization{}, false
+	}
+	if _, ok := dict["Linearized"]; !ok {
+		return Linearization{}, false
+	}
+	var lin Linearization
+	lin.Length, _ = dict.Int("L")
+	if o, ok := dict.Int("O"); ok {
+		lin.FirstPage = int(o)
+	}
+	if n, ok := dict.Int("N"); ok {
+		lin.Pages = int(n)
+	}
+	return lin, true
+}
+
+// Linearized reports whether the file is linearized and the linearization
+// still covers the whole file. Any incremental update appended afterwards
+// changes the length and leaves the hint tables stale.
+func (d *Document) Linearized() bool {
+	lin, ok := d.Linearization()
+	return ok && lin.Length == int64(len(d.content))
+}
@@ -0,0 +1,71 @@
+package pdf
+
+import "testing"
+
+func TestLinearizationDetected(t *testing.T) {
+	t.Parallel()
+
+	b := readFixture(t, "linearized.pdf")
+	doc, err := ParseBytes("linearized.pdf", b)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+	lin, ok := doc.Linearization()
+	if !ok {
+		t.Fatalf("expected linearization dictionary")
+	}
+	if lin.Length != int64(len(b)) || lin.FirstPage != 8 || lin.Pages != 2 {
+		t.Fatalf("Linearization()=%+v", lin)
+	}
+	if !doc.Linearized() {
+		t.Fatalf("Linearized()=false want true")
+	}
+	if got := len(doc.Revisions()); got != 1 {
+		t.Fatalf("Revisions()=%d want 1; the first-page %%%%EOF is not a revision", got)
+	}
+
+	// Any appended update makes /L stale.
+	updated, err := ParseBytes("linearized.pdf", append(append([]byte(nil), b...), "% update\n"...))
+	if err != nil {
+		t.Fatalf("ParseBytes(updated): %v", err)
+	}
+	if _, ok := updated.Linearization(); !ok || updated.Linearized() {
+		t.Fatalf("updated file should keep the dictionary but not count as linearized")
+	}
+}
+
+func TestLinearizationAbsent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		src  []byte
+	}{
+		{name: "plain fixture", src: readFixture(t, "incremental-updates.pdf")},
+		{name: "not first object", src: buildPDF(
+			testRevision{
+				objects: []testObject{
+					{num: 1, body: "<< /Type /Catalog >>"},
+					{num: 2, body: "<< /Linearized 1 /L 10 >>"},
+				},
+				trailer: "/Root 1 0 R",
+			},
+		)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := ParseBytes(tc.name, tc.src)
+			if err != nil {
+				t.Fatalf("ParseBytes: %v", err)
+			}
+			if _, ok := doc.Linearization(); ok || doc.Linearized() {
+				t.Fatalf("unexpected linearization in %s", tc.name)
+			}
+		})
+	}
+}
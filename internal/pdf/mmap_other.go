@@ -0,0 +1,13 @@
+//go:build !unix
+
+package pdf
+
+import (
+	"errors"
+	"os"
+)
+
+// mapFile is unsupported here; Open reads the file into memory instead.
+func mapFile(*os.File, int64) ([]byte, func() error, error) {
+	return nil, nil, errors.New("memory mapping not supported")
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package pdf
+
+import (
+	"errors"
+	"math"
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of f read-only. The mapping outlives f.
+func mapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	if size <= 0 || size > math.MaxInt {
+		return nil, nil, errors.New("file size cannot be mapped")
+	}
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, func() error { return syscall.Munmap(b) }, nil
+}
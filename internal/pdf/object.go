@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Object is any value produced by the PDF object parser: Null, Bool, Integer,
+// Real, Name, String, Array, Dict, Stream or Ref.
+type Object any
+
+// Null is the PDF null object.
+type Null struct{}
+
+// Bool is a PDF boolean.
+type Bool bool
+
+// Integer is a PDF integer number.
+type Integer int64
+
+// Real is a PDF real number.
+type Real float64
+
+// Name is a PDF name without the leading slash, with #xx escapes decoded.
+type Name string
+
+// String is a PDF string. Hex records whether the source used <...> syntax and
+// is honored when the string is serialized again.
+type String struct {
+	Bytes []byte
+	Hex   bool
+}
+
+// Array is a PDF array.
+type Array []Object
+
+// Dict is a PDF dictionary keyed by name.
+type Dict map[Name]Object
+
+// Stream is a stream object: its dictionary plus the raw (still encoded) data.
+type Stream struct {
+	Dict Dict
+	Data []byte
+}
+
+// Ref is an indirect reference "Num Gen R".
+type Ref struct {
+	Num int
+	Gen int
+}
+
+// String formats r the way it appears in PDF syntax.
+func (r Ref) String() string {
+	return fmt.Sprintf("%d %d R", r.Num, r.Gen)
+}
+
+// Get returns the value stored under key, or nil when absent.
+func (d Dict) Get(key Name) Object {
+	if d == nil {
+		return nil
+	}
+	return d[key]
+}
+
+// Name returns the name value stored under key.
+func (d Dict) Name(key Name) (Name, bool) {
+	n, ok := d.Get(key).(Name)
+	return n, ok
+}
+
+// Int returns the integer value stored under key.
+func (d Dict) Int(key Name) (int64, bool) {
+	n, ok := d.Get(key).(Integer)
+	return int64(n), ok
+}
+
+// Ref returns the indirect reference stored under key.
+func (d Dict) Ref(key Name) (Ref, bool) {
+	r, ok := d.Get(key).(Ref)
+	return r, ok
+}
+
+// Dict returns the direct dictionary stored under key.
+func (d Dict) Dict(key Name) (Dict, bool) {
+	v, ok := d.Get(key).(Dict)
+	return v, ok
+}
+
+// Clone returns a shallow copy of d.
+func (d Dict) Clone() Dict {
+	out := make(Dict, len(d))
+	for k, v := range d {
+		out[k] = v
+	}
+	return out
+}
+
+// Keys returns the dictionary keys in sorted order.
+func (d Dict) Keys() []Name {
+	keys := make([]Name, 0, len(d))
+	for k := range d {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// TypeName returns the /Type entry of the dictionary, if any.
+func (d Dict) TypeName() Name {
+	n, _ := d.Name("Type")
+	return n
+}
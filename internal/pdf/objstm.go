@@ -0,0 +1,152 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// objectStream is a decoded /Type /ObjStm stream with its offset header.
+type objectStream struct {
+	data    []byte
+	first   int
+	nums    []int
+	offsets []int
+}
+
+// compressedObject parses the object that loc places inside an object stream.
+// Objects in object streams always have generation 0.
+func (d *Document) compressedObject(loc objectLoc) (Object, error) {
+	ref, streamNum, index := loc.ref, loc.stream, loc.index
+	stm, err := d.objectStream(streamNum)
+	if err != nil {
+		return nil, malformed(fmt.Sprintf("object %d %d: object stream %d", ref.Num, ref.Gen, streamNum), err)
+	}
+	if index < 0 || index >= len(stm.nums) || stm.nums[index] != ref.Num {
+		// The xref index is only a hint; fall back to searching the header.
+		index = -1
+		for i, n := range stm.nums {
+			if n == ref.Num {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, malformed(fmt.Sprintf("object %d %d not found in object stream %d", ref.Num, ref.Gen, streamNum), nil)
+		}
+	}
+	return stm.object(index)
+}
+
+func (d *Document) objectStream(num int) (*objectStream, error) {
+	if stm, ok := d.objStreams[num]; ok {
+		return stm, nil
+	}
+	if d.loadingObjStm[num] {
+		return nil, errors.New("object stream refers to itself")
+	}
+	d.loadingObjStm[num] = true
+	defer delete(d.loadingObjStm, num)
+
+	loc, ok := d.lookup(Ref{Num: num})
+	if !ok || loc.compressed {
+		return nil, errors.New("object stream not found")
+	}
+	_, obj, _, err := d.objectParser(loc.offset).parseIndirectObject()
+	if err != nil {
+		return nil, err
+	}
+	// Objects inside the stream are covered by the stream's encryption.
+	if obj, err = d.decryptObject(loc.ref, obj); err != nil {
+		return nil, err
+	}
+	s, ok := obj.(Stream)
+	if !ok {
+		return nil, errors.New("object stream is not a stream")
+	}
+	stm, err := parseObjectStream(s)
+	if err != nil {
+		return nil, err
+	}
+	d.objStreams[num] = stm
+	return stm, nil
+}
+
+// parseObjectStream inflates s and reads its "num offset" header pairs.
+func parseObjectStream(s Stream) (*objectStream, error) {
+	if s.Dict.TypeName() != "ObjStm" {
+		return nil, errors.New("stream is not /Type /ObjStm")
+	}
+	n, ok := s.Dict.Int("N")
+	if !ok || n < 0 || n > maxXRefEntries {
+		return nil, errors.New("object stream /N is missing or invalid")
+	}
+	first, ok := s.Dict.Int("First")
+	if !ok || first < 0 {
+		return nil, errors.New("object stream /First is missing or invalid")
+	}
+	data, err := decodeStream(s)
+	if err != nil {
+		return nil, fmt.Errorf("decode object stream: %w", err)
+	}
+	if int(first) > len(data) {
+		return nil, errors.New("object stream /First is beyond the data")
+	}
+
+	stm := &objectStream{data: data, first: int(first)}
+	l := newLexer(data[:first], 0)
+	for i := int64(0); i < n; i++ {
+		numTok, err1 := l.next()
+		offTok, err2 := l.next()
+		if err1 != nil || err2 != nil || numTok.kind != tokInteger || offTok.kind != tokInteger {
+			return nil, errors.New("object stream header is truncated")
+		}
+		num, err1 := strconv.Atoi(string(numTok.value))
+		off, err2 := strconv.Atoi(string(offTok.value))
+		if err1 != nil || err2 != nil || num < 0 || off < 0 || stm.first+off > len(data) {
+			return nil, errors.New("object stream header is invalid")
+		}
+		stm.nums = append(stm.nums, num)
+		stm.offsets = append(stm.offsets, off)
+	}
+	return stm, nil
+}
+
+func (stm *objectStream) object(index int) (Object, error) {
+	p := newParser(stm.data, stm.first+stm.offsets[index])
+	obj, err := p.parseObject()
+	if err != nil {
+		return nil, malformed(fmt.Sprintf("parse object %d in object stream", stm.nums[index]), err)
+	}
+	return obj, nil
+}
+
+// NewObjectStream packs objs into a FlateDecode /Type /ObjStm stream; objs[i]
+// is stored as object refs[i] at position i. Streams cannot be packed and
+// packed objects must have generation 0.
+func NewObjectStream(refs []Ref, objs []Object) (Stream, error) {
+	if len(refs) != len(objs) {
+		return Stream{}, errors.New("object stream refs and objects differ in length")
+	}
+	var header, body []byte
+	for i, obj := range objs {
+		if _, ok := obj.(Stream); ok {
+			return Stream{}, fmt.Errorf("object %d is a stream and cannot be packed", refs[i].Num)
+		}
+		if refs[i].Gen != 0 {
+			return Stream{}, fmt.Errorf("object %d has generation %d and cannot be packed", refs[i].Num, refs[i].Gen)
+		}
+		header = strconv.AppendInt(header, int64(refs[i].Num), 10)
+		header = append(header, ' ')
+		header = strconv.AppendInt(header, int64(len(body)), 10)
+		header = append(header, ' ')
+		body = AppendObject(body, obj)
+		body = append(body, '\n')
+	}
+	dict := Dict{
+		"Type":  Name("ObjStm"),
+		"N":     Integer(len(objs)),
+		"First": Integer(len(header)),
+	}
+	return FlateEncode(Stream{Dict: dict, Data: append(header, body...)}), nil
+}
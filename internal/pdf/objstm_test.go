@@ -0,0 +1,184 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestObjectStreamMembersResolve(t *testing.T) {
+	t.Parallel()
+
+	src := buildXRefStreamPDF(testRevision{
+		objects: []testObject{{num: 2, body: "<< /Type /Pages /Kids [] /Count 0 >>"}},
+		packed: []testObject{
+			{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+			{num: 3, body: "<< /Title (packed) /Author [(a) (b)] >>"},
+		},
+		trailer: "/Root 1 0 R /Info 3 0 R",
+	})
+	doc, err := ParseBytes("objstm.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	root, err := doc.Resolve(trailer.Get("Root"))
+	if err != nil {
+		t.Fatalf("Resolve(Root): %v", err)
+	}
+	if d, ok := root.(Dict); !ok || d.TypeName() != "Catalog" {
+		t.Fatalf("unexpected root %#v", root)
+	}
+	info, err := doc.Resolve(trailer.Get("Info"))
+	if err != nil {
+		t.Fatalf("Resolve(Info): %v", err)
+	}
+	if got := string(info.(Dict).Get("Title").(String).Bytes); got != "packed" {
+		t.Fatalf("Title=%q want packed", got)
+	}
+
+	// Objects in object streams always have generation 0.
+	if _, err := doc.Object(Ref{Num: 3, Gen: 1}); err == nil {
+		t.Fatalf("expected lookup of 3 1 R to fail")
+	}
+}
+
+func TestObjectStreamMemberReplacedByLaterRevision(t *testing.T) {
+	t.Parallel()
+
+	src := buildXRefStreamPDF(
+		testRevision{
+			packed: []testObject{
+				{num: 1, body: "<< /Type /Catalog >>"},
+				{num: 2, body: "<< /Title (old) >>"},
+			},
+			trailer: "/Root 1 0 R /Info 2 0 R",
+		},
+		testRevision{
+			objects: []testObject{{num: 2, body: "<< /Title (new) >>"}},
+			trailer: "/Root 1 0 R /Info 2 0 R",
+		},
+	)
+	doc, err := ParseBytes("objstm-update.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if got := string(obj.(Dict).Get("Title").(String).Bytes); got != "new" {
+		t.Fatalf("Title=%q want new", got)
+	}
+	if _, err := doc.Object(Ref{Num: 1}); err != nil {
+		t.Fatalf("Object(1): %v", err)
+	}
+}
+
+func TestObjectStreamScanFallback(t *testing.T) {
+	t.Parallel()
+
+	src := buildXRefStreamPDF(testRevision{
+		packed: []testObject{
+			{num: 1, body: "<< /Type /Catalog >>"},
+			{num: 2, body: "<< /Title (scanned) >>"},
+		},
+		trailer: "/Root 1 0 R /Info 2 0 R",
+	})
+	// Break startxref so only the header scan can find the objects.
+	idx := bytes.LastIndex(src, []byte("startxref"))
+	src = append(src[:idx:idx], []byte("startxref\n0\n%%EOF\n")...)
+
+	doc, err := ParseBytes("objstm-broken.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if doc.XRefError() == nil {
+		t.Fatalf("expected xref error")
+	}
+	obj, err := doc.Resolve(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Resolve(2): %v", err)
+	}
+	d, ok := obj.(Dict)
+	if !ok || string(d.Get("Title").(String).Bytes) != "scanned" {
+		t.Fatalf("Resolve(2)=%#v want Info dict", obj)
+	}
+}
+
+func TestParseObjectStreamErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		dict string
+		data string
+		want string
+	}{
+		{name: "wrong type", dict: "/Type /XRef /N 1 /First 4", data: "1 0 null", want: "not /Type /ObjStm"},
+		{name: "missing N", dict: "/Type /ObjStm /First 4", data: "1 0 null", want: "/N"},
+		{name: "missing First", dict: "/Type /ObjStm /N 1", data: "1 0 null", want: "/First"},
+		{name: "First beyond data", dict: "/Type /ObjStm /N 1 /First 99", data: "1 0 null", want: "beyond"},
+		{name: "truncated header", dict: "/Type /ObjStm /N 2 /First 4", data: "1 0 null", want: "truncated"},
+		{name: "offset beyond data", dict: "/Type /ObjStm /N 1 /First 5", data: "1 90 null", want: "invalid"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			obj, err := ParseObject([]byte("<< " + tc.dict + " >>"))
+			if err != nil {
+				t.Fatalf("ParseObject: %v", err)
+			}
+			_, err = parseObjectStream(Stream{Dict: obj.(Dict), Data: []byte(tc.data)})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("parseObjectStream() error=%v want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewObjectStreamRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	refs := []Ref{{Num: 7}, {Num: 3}}
+	objs := []Object{Dict{"Title": String{Bytes: []byte("x")}}, Array{Integer(1), Name("N")}}
+	s, err := NewObjectStream(refs, objs)
+	if err != nil {
+		t.Fatalf("NewObjectStream: %v", err)
+	}
+	if f, _ := s.Dict.Name("Filter"); f != "FlateDecode" {
+		t.Fatalf("expected FlateDecode object stream, got %#v", s.Dict)
+	}
+	stm, err := parseObjectStream(s)
+	if err != nil {
+		t.Fatalf("parseObjectStream: %v", err)
+	}
+	for i, ref := range refs {
+		if stm.nums[i] != ref.Num {
+			t.Fatalf("nums[%d]=%d want %d", i, stm.nums[i], ref.Num)
+		}
+		got, err := stm.object(i)
+		if err != nil {
+			t.Fatalf("object(%d): %v", i, err)
+		}
+		if string(AppendObject(nil, got)) != string(AppendObject(nil, objs[i])) {
+			t.Fatalf("object(%d)=%#v want %#v", i, got, objs[i])
+		}
+	}
+
+	if _, err := NewObjectStream([]Ref{{Num: 1}}, []Object{Stream{Dict: Dict{}}}); err == nil {
+		t.Fatalf("expected streams to be rejected")
+	}
+	if _, err := NewObjectStream([]Ref{{Num: 1, Gen: 2}}, []Object{Null{}}); err == nil {
+		t.Fatalf("expected non-zero generation to be rejected")
+	}
+}
@@ -0,0 +1,52 @@
+package pdf
+
+// PageCount counts the leaf /Page objects reachable from the catalog's page
+// tree. Nodes are visited once, so a /Kids cycle cannot loop; unresolvable
+// kids are skipped. A missing or broken page tree counts as zero pages.
+func (d *Document) PageCount() int {
+	trailer, err := d.Trailer()
+	if err != nil {
+		return 0
+	}
+	root, err := d.Resolve(trailer.Get("Root"))
+	if err != nil {
+		return 0
+	}
+	catalog, ok := root.(Dict)
+	if !ok {
+		return 0
+	}
+
+	count := 0
+	seen := map[Ref]bool{}
+	var walk func(obj Object, depth int)
+	walk = func(obj Object, depth int) {
+		if depth > maxNestingDepth {
+			return
+		}
+		if ref, ok := obj.(Ref); ok {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		v, err := d.Resolve(obj)
+		if err != nil {
+			return
+		}
+		node, ok := v.(Dict)
+		if !ok {
+			return
+		}
+		kids, err := d.Resolve(node.Get("Kids"))
+		if arr, ok := kids.(Array); ok && err == nil && node.TypeName() != "Page" {
+			for _, kid := range arr {
+				walk(kid, depth+1)
+			}
+			return
+		}
+		count++
+	}
+	walk(catalog.Get("Pages"), 0)
+	return count
+}
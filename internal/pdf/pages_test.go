@@ -0,0 +1,54 @@
+package pdf
+
+import "testing"
+
+func TestPageCount(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		objects []testObject
+		want    int
+	}{
+		{
+			name: "nested",
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+				{num: 2, body: "<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 3 >>"},
+				{num: 3, body: "<< /Type /Page /Parent 2 0 R >>"},
+				{num: 4, body: "<< /Type /Pages /Kids 7 0 R /Count 2 >>"},
+				{num: 5, body: "<< /Type /Page /Parent 4 0 R >>"},
+				{num: 6, body: "<< /Type /Page /Parent 4 0 R >>"},
+				{num: 7, body: "[5 0 R 6 0 R 9 0 R]"},
+			},
+			want: 3,
+		},
+		{
+			name: "cycle",
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+				{num: 2, body: "<< /Type /Pages /Kids [3 0 R 2 0 R] /Count 1 >>"},
+				{num: 3, body: "<< /Type /Page /Parent 2 0 R >>"},
+			},
+			want: 1,
+		},
+		{
+			name:    "no page tree",
+			objects: []testObject{{num: 1, body: "<< /Type /Catalog >>"}},
+			want:    0,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			doc, err := ParseBytes(tc.name+".pdf", buildPDF(testRevision{objects: tc.objects, trailer: "/Root 1 0 R"}))
+			if err != nil {
+				t.Fatalf("ParseBytes: %v", err)
+			}
+			if got := doc.PageCount(); got != tc.want {
+				t.Fatalf("PageCount()=%d want %d", got, tc.want)
+			}
+		})
+	}
+}
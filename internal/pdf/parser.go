@@ -0,0 +1,317 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+const maxNestingDepth = 256
+
+// parser builds objects from lexer tokens. It keeps a small lookahead queue so
+// "N G R" references can be recognized. length, when set, resolves indirect
+// stream /Length values.
+type parser struct {
+	lex    *lexer
+	queue  []token
+	length func(Ref) (int64, bool)
+}
+
+func newParser(buf []byte, pos int) *parser {
+	return &parser{lex: newLexer(buf, pos)}
+}
+
+func (p *parser) peek(n int) (token, error) {
+	for len(p.queue) <= n {
+		tok, err := p.lex.next()
+		if err != nil {
+			return token{}, err
+		}
+		p.queue = append(p.queue, tok)
+	}
+	return p.queue[n], nil
+}
+
+func (p *parser) next() (token, error) {
+	tok, err := p.peek(0)
+	if err != nil {
+		return token{}, err
+	}
+	p.queue = p.queue[1:]
+	return tok, nil
+}
+
+// offset reports the buffer position just past the last consumed token.
+func (p *parser) offset() int {
+	if len(p.queue) > 0 {
+		return p.queue[0].pos
+	}
+	return p.lex.pos
+}
+
+// seek discards lookahead and continues lexing at pos.
+func (p *parser) seek(pos int) {
+	p.queue = p.queue[:0]
+	p.lex.pos = pos
+}
+
+func (p *parser) parseObject() (Object, error) {
+	return p.parseObjectDepth(0)
+}
+
+func (p *parser) parseObjectDepth(depth int) (Object, error) {
+	if depth > maxNestingDepth {
+		return nil, fmt.Errorf("objects nested deeper than %d levels", maxNestingDepth)
+	}
+	tok, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	switch tok.kind {
+	case tokEOF:
+		return nil, errUnexpectedEOF
+	case tokInteger:
+		n, err := strconv.ParseInt(string(tok.value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q at offset %d", tok.value, tok.pos)
+		}
+		if ref, ok := p.tryRef(n); ok {
+			return ref, nil
+		}
+		return Integer(n), nil
+	case tokReal:
+		f, err := strconv.ParseFloat(string(tok.value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid real %q at offset %d", tok.value, tok.pos)
+		}
+		return Real(f), nil
+	case tokName:
+		return Name(tok.value), nil
+	case tokString:
+		return String{Bytes: tok.value}, nil
+	case tokHexString:
+		return String{Bytes: tok.value, Hex: true}, nil
+	case tokArrayOpen:
+		arr := Array{}
+		for {
+			t, err := p.peek(0)
+			if err != nil {
+				return nil, err
+			}
+			if t.kind == tokArrayClose {
+				_, _ = p.next()
+				return arr, nil
+			}
+			if t.kind == tokEOF {
+				return nil, errUnexpectedEOF
+			}
+			v, err := p.parseObjectDepth(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+	case tokDictOpen:
+		dict := Dict{}
+		for {
+			t, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if t.kind == tokDictClose {
+				return dict, nil
+			}
+			if t.kind == tokEOF {
+				return nil, errUnexpectedEOF
+			}
+			if t.kind != tokName {
+				return nil, fmt.Errorf("dictionary key at offset %d is not a name", t.pos)
+			}
+			next, err := p.peek(0)
+			if err != nil {
+				return nil, err
+			}
+			if next.kind == tokDictClose {
+				// A key without a value is tolerated and treated as null.
+				continue
+			}
+			v, err := p.parseObjectDepth(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			if _, isNull := v.(Null); !isNull {
+				dict[Name(t.value)] = v
+			}
+		}
+	case tokKeyword:
+		switch string(tok.value) {
+		case "true":
+			return Bool(true), nil
+		case "false":
+			return Bool(false), nil
+		case "null":
+			return Null{}, nil
+		}
+		return nil, fmt.Errorf("unexpected keyword %q at offset %d", tok.value, tok.pos)
+	default:
+		return nil, fmt.Errorf("unexpected token at offset %d", tok.pos)
+	}
+}
+
+// tryRef consumes "G R" after an integer when present.
+func (p *parser) tryRef(num int64) (Ref, bool) {
+	gen, err := p.peek(0)
+	if err != nil || gen.kind != tokInteger {
+		return Ref{}, false
+	}
+	r, err := p.peek(1)
+	if err != nil || r.kind != tokKeyword || string(r.value) != "R" {
+		return Ref{}, false
+	}
+	g, err := strconv.Atoi(string(gen.value))
+	if err != nil || num < 0 || g < 0 {
+		return Ref{}, false
+	}
+	p.queue = p.queue[2:]
+	return Ref{Num: int(num), Gen: g}, true
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok.kind != tokKeyword || string(tok.value) != kw {
+		return fmt.Errorf("expected %q at offset %d", kw, tok.pos)
+	}
+	return nil
+}
+
+// parseObjectHeader reads "N G obj".
+func (p *parser) parseObjectHeader() (Ref, error) {
+	numTok, err := p.next()
+	if err != nil {
+		return Ref{}, err
+	}
+	genTok, err := p.next()
+	if err != nil {
+		return Ref{}, err
+	}
+	if numTok.kind != tokInteger || genTok.kind != tokInteger {
+		return Ref{}, fmt.Errorf("missing object header at offset %d", numTok.pos)
+	}
+	num, err1 := strconv.Atoi(string(numTok.value))
+	gen, err2 := strconv.Atoi(string(genTok.value))
+	if err1 != nil || err2 != nil || num < 0 || gen < 0 {
+		return Ref{}, fmt.Errorf("invalid object header at offset %d", numTok.pos)
+	}
+	if err := p.expectKeyword("obj"); err != nil {
+		return Ref{}, err
+	}
+	return Ref{Num: num, Gen: gen}, nil
+}
+
+// parseIndirectObject parses "N G obj ... endobj" starting at the current
+// position and returns the object reference, value and end offset.
+func (p *parser) parseIndirectObject() (Ref, Object, int, error) {
+	ref, err := p.parseObjectHeader()
+	if err != nil {
+		return Ref{}, nil, 0, err
+	}
+	value, err := p.parseObject()
+	if err != nil {
+		return Ref{}, nil, 0, fmt.Errorf("object %d %d: %w", ref.Num, ref.Gen, err)
+	}
+
+	if dict, ok := value.(Dict); ok {
+		tok, err := p.peek(0)
+		if err == nil && tok.kind == tokKeyword && string(tok.value) == "stream" {
+			data, end, err := p.streamData(dict, tok.end)
+			if err != nil {
+				return Ref{}, nil, 0, fmt.Errorf("object %d %d: %w", ref.Num, ref.Gen, err)
+			}
+			value = Stream{Dict: dict, Data: data}
+			p.seek(end)
+		}
+	}
+
+	tok, err := p.peek(0)
+	if err == nil && tok.kind == tokKeyword && string(tok.value) == "endobj" {
+		_, _ = p.next()
+	}
+	// A missing endobj is tolerated; many writers get it slightly wrong.
+	return ref, value, p.offset(), nil
+}
+
+// streamData locates stream bytes starting right after the "stream" keyword
+// and returns them with the offset just past "endstream".
+func (p *parser) streamData(dict Dict, afterKeyword int) ([]byte, int, error) {
+	buf := p.lex.buf
+	start := afterKeyword
+	if start < len(buf) && buf[start] == '\r' {
+		start++
+	}
+	if start < len(buf) && buf[start] == '\n' {
+		start++
+	}
+
+	if n, ok := p.streamLength(dict); ok && n >= 0 && int64(start)+n <= int64(len(buf)) {
+		end := start + int(n)
+		if endstreamAt(buf, end) {
+			kw := bytes.Index(buf[end:], []byte("endstream"))
+			return buf[start:end], end + kw + len("endstream"), nil
+		}
+	}
+
+	idx := bytes.Index(buf[start:], []byte("endstream"))
+	if idx < 0 {
+		return nil, 0, fmt.Errorf("stream at offset %d has no endstream", start)
+	}
+	end := start + idx
+	data := trimTrailingEOL(buf[start:end])
+	return data, end + len("endstream"), nil
+}
+
+func (p *parser) streamLength(dict Dict) (int64, bool) {
+	switch v := dict.Get("Length").(type) {
+	case Integer:
+		return int64(v), true
+	case Ref:
+		if p.length != nil {
+			return p.length(v)
+		}
+	}
+	return 0, false
+}
+
+// endstreamAt reports whether the endstream keyword follows pos, allowing for
+// an optional end-of-line marker.
+func endstreamAt(buf []byte, pos int) bool {
+	for i := 0; i < 2 && pos < len(buf) && (buf[pos] == '\r' || buf[pos] == '\n'); i++ {
+		pos++
+	}
+	for pos < len(buf) && isWhitespace(buf[pos]) {
+		pos++
+	}
+	return bytes.HasPrefix(buf[pos:], []byte("endstream"))
+}
+
+func trimTrailingEOL(b []byte) []byte {
+	if bytes.HasSuffix(b, []byte("\r\n")) {
+		return b[:len(b)-2]
+	}
+	if bytes.HasSuffix(b, []byte("\n")) || bytes.HasSuffix(b, []byte("\r")) {
+		return b[:len(b)-1]
+	}
+	return b
+}
+
+// ParseObject parses a single direct object from b.
+func ParseObject(b []byte) (Object, error) {
+	p := newParser(b, 0)
+	obj, err := p.parseObject()
+	if err != nil {
+		return nil, malformed("parse object", err)
+	}
+	return obj, nil
+}
@@ -0,0 +1,278 @@
+package pdf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseObjectTable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want Object
+	}{
+		{name: "integer", in: "42", want: Integer(42)},
+		{name: "negative", in: "-7", want: Integer(-7)},
+		{name: "real", in: "3.25", want: Real(3.25)},
+		{name: "leading-dot-real", in: ".5", want: Real(0.5)},
+		{name: "bool", in: "true", want: Bool(true)},
+		{name: "null", in: "null", want: Null{}},
+		{name: "name", in: "/Type", want: Name("Type")},
+		{name: "name-hex-escape", in: "/A#20B", want: Name("A B")},
+		{name: "literal", in: "(hello)", want: String{Bytes: []byte("hello")}},
+		{name: "balanced-parens", in: "(Report (final))", want: String{Bytes: []byte("Report (final)")}},
+		{name: "escaped-parens", in: `(a\)b)`, want: String{Bytes: []byte("a)b")}},
+		{name: "endobj-inside-string", in: "(x endobj y)", want: String{Bytes: []byte("x endobj y")}},
+		{name: "hex", in: "<48 65 6C6C6F>", want: String{Bytes: []byte("Hello"), Hex: true}},
+		{name: "hex-odd", in: "<414>", want: String{Bytes: []byte{0x41, 0x40}, Hex: true}},
+		{name: "ref", in: "12 0 R", want: Ref{Num: 12}},
+		{name: "array", in: "[1 2 0 R /N]", want: Array{Integer(1), Ref{Num: 2}, Name("N")}},
+		{
+			name: "nested-dict",
+			in:   "<< /A << /B [1 << /C (d) >>] >> /E 5 >>",
+			want: Dict{
+				"A": Dict{"B": Array{Integer(1), Dict{"C": String{Bytes: []byte("d")}}}},
+				"E": Integer(5),
+			},
+		},
+		{
+			name: "comments-and-multiline",
+			in:   "<< % comment with /Fake 1 0 R\n/Title\n  (Multi)\n/Info 3\n0\nR >>",
+			want: Dict{"Title": String{Bytes: []byte("Multi")}, "Info": Ref{Num: 3}},
+		},
+		{name: "null-value-dropped", in: "<< /A null /B 1 >>", want: Dict{"B": Integer(1)}},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseObject([]byte(tc.in))
+			if err != nil {
+				t.Fatalf("ParseObject(%q): %v", tc.in, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("ParseObject(%q)=%#v want %#v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestLexerNameEscapeAtEnd covers names that end the buffer, as the last
+// object in an object stream or a content fragment does.
+func TestLexerNameEscapeAtEnd(t *testing.T) {
+	t.Parallel()
+
+	for in, want := range map[string]string{
+		"/A#41": "AA",
+		"/#41":  "A",
+		"/A#4":  "A#4",
+		"/A#":   "A#",
+	} {
+		tok, err := newLexer([]byte(in), 0).next()
+		if err != nil || tok.kind != tokName || string(tok.value) != want || tok.end != len(in) {
+			t.Fatalf("lex %q: %+v, %v; want name %q", in, tok, err, want)
+		}
+	}
+}
+
+func TestLiteralStringEscapes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "named escapes", in: `(a\nb\rc\td\be\ff)`, want: "a\nb\rc\td\be\ff"},
+		{name: "escaped backslash and parens", in: `(\\ \( \))`, want: `\ ( )`},
+		{name: "octal", in: `(caf\351)`, want: "caf\xe9"},
+		{name: "short octal", in: `(\0\53x)`, want: "\x00+x"},
+		{name: "octal stops after three digits", in: `(\1234)`, want: "S4"},
+		{name: "octal overflow ignored", in: `(\777)`, want: "\xff"},
+		{name: "octal followed by non-octal digit", in: `(\38)`, want: "\x038"},
+		{name: "unknown escape drops backslash", in: `(\q)`, want: "q"},
+		{name: "continuation lf", in: "(long \\\nline)", want: "long line"},
+		{name: "continuation crlf", in: "(long \\\r\nline)", want: "long line"},
+		{name: "continuation cr", in: "(long \\\rline)", want: "long line"},
+		{name: "eol crlf", in: "(a\r\nb)", want: "a\nb"},
+		{name: "eol cr", in: "(a\rb)", want: "a\nb"},
+		{name: "eol lf", in: "(a\nb)", want: "a\nb"},
+		{name: "nested parens", in: "(Report (final (v2)) done)", want: "Report (final (v2)) done"},
+		{name: "escaped paren inside nesting", in: `(a (b\) c) d)`, want: "a (b) c) d"},
+		{name: "empty", in: "()", want: ""},
+	}
+	for _, tc := range tests {
+		got, err := ParseObject([]byte(tc.in))
+		if err != nil {
+			t.Fatalf("%s: ParseObject(%q): %v", tc.name, tc.in, err)
+		}
+		if s, ok := got.(String); !ok || string(s.Bytes) != tc.want {
+			t.Fatalf("%s: ParseObject(%q)=%#v want %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestLiteralStringRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+	corpus := [][]byte{
+		nil,
+		[]byte("Report (final)"),
+		[]byte("unbalanced ( and ) and )("),
+		[]byte(`back\slash \n literal`),
+		[]byte("lines\r\nwith\rall\nendings"),
+		[]byte("ctrl \b\f\t\x00\x1b"),
+		[]byte("\xfe\xff\x00J\x00\xfc"),
+		all,
+	}
+	for _, want := range corpus {
+		enc := AppendObject(nil, String{Bytes: want})
+		got, err := ParseObject(enc)
+		if err != nil {
+			t.Fatalf("ParseObject(%q): %v", enc, err)
+		}
+		if s, ok := got.(String); !ok || string(s.Bytes) != string(want) {
+			t.Fatalf("round trip of %q via %q gave %#v", want, enc, got)
+		}
+	}
+}
+
+func TestParseObjectErrors(t *testing.T) {
+	t.Parallel()
+
+	inputs := []string{"", "<< /A 1", "[1 2", "(unterminated", "<4G>", ">>", "<< 1 2 >>"}
+	for _, in := range inputs {
+		if _, err := ParseObject([]byte(in)); err == nil {
+			t.Fatalf("ParseObject(%q) expected error", in)
+		}
+	}
+}
+
+func TestParseIndirectStream(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "direct-length", in: "4 0 obj\n<< /Length 5 >>\nstream\nab\ncd\nendstream\nendobj\n", want: "ab\ncd"},
+		{name: "crlf", in: "4 0 obj\r\n<< /Length 3 >>\r\nstream\r\nabc\r\nendstream\r\nendobj\r\n", want: "abc"},
+		{name: "wrong-length", in: "4 0 obj\n<< /Length 99 >>\nstream\nabc\nendstream\nendobj\n", want: "abc"},
+		{name: "indirect-length", in: "4 0 obj\n<< /Length 9 0 R >>\nstream\nxyz\nendstream\nendobj\n", want: "xyz"},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			p := newParser([]byte(tc.in), 0)
+			ref, obj, end, err := p.parseIndirectObject()
+			if err != nil {
+				t.Fatalf("parseIndirectObject: %v", err)
+			}
+			if ref != (Ref{Num: 4}) {
+				t.Fatalf("ref=%v want 4 0", ref)
+			}
+			s, ok := obj.(Stream)
+			if !ok {
+				t.Fatalf("object is %T, want Stream", obj)
+			}
+			if string(s.Data) != tc.want {
+				t.Fatalf("stream data=%q want %q", s.Data, tc.want)
+			}
+			if rest := strings.TrimSpace(tc.in[end:]); rest != "" {
+				t.Fatalf("parser stopped before %q", rest)
+			}
+		})
+	}
+}
+
+func TestAppendObjectRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	objs := []Object{
+		Dict{
+			"Title":  String{Bytes: []byte(`a (b) \ c` + "\n")},
+			"Key":    String{Bytes: []byte{0x00, 0xff}, Hex: true},
+			"Spaced": Name("has space#"),
+			"Arr":    Array{Integer(-1), Real(1.5), Bool(false), Ref{Num: 3, Gen: 1}},
+			"Nested": Dict{"X": Integer(0)},
+		},
+	}
+	for _, obj := range objs {
+		b := AppendObject(nil, obj)
+		got, err := ParseObject(b)
+		if err != nil {
+			t.Fatalf("ParseObject(%q): %v", b, err)
+		}
+		if !reflect.DeepEqual(got, obj) {
+			t.Fatalf("round trip mismatch:\n got %#v\nwant %#v\nsyntax %q", got, obj, b)
+		}
+	}
+}
+
+func TestDocumentObjectLookup(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("%PDF-1.4\n" +
+		"1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Note (contains 3 0 obj and endobj) /Count 0 >>\nendobj\n" +
+		"3 0 obj\n(old)\nendobj\n" +
+		"3 0 obj\n(new)\nendobj\n" +
+		"trailer\n<< /Root 1 0 R /Size 4 >>\nstartxref\n0\n%%EOF\n")
+	doc, err := ParseBytes("lookup.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	root, err := doc.Resolve(trailer.Get("Root"))
+	if err != nil {
+		t.Fatalf("Resolve(Root): %v", err)
+	}
+	if root.(Dict).TypeName() != "Catalog" {
+		t.Fatalf("unexpected root: %#v", root)
+	}
+
+	pages, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if n, _ := pages.(Dict).Int("Count"); n != 0 {
+		t.Fatalf("unexpected pages dict: %#v", pages)
+	}
+
+	three, err := doc.Object(Ref{Num: 3})
+	if err != nil {
+		t.Fatalf("Object(3): %v", err)
+	}
+	if got := string(three.(String).Bytes); got != "new" {
+		t.Fatalf("Object(3)=%q want newest definition", got)
+	}
+
+	if got := doc.MaxObjectNumber(); got != 3 {
+		t.Fatalf("MaxObjectNumber()=%d want 3", got)
+	}
+	missing, err := doc.Resolve(Ref{Num: 40})
+	if err != nil {
+		t.Fatalf("Resolve(missing): %v", err)
+	}
+	if _, ok := missing.(Null); !ok {
+		t.Fatalf("Resolve(missing)=%#v want Null", missing)
+	}
+	if _, err := doc.Object(Ref{Num: 1, Gen: 2}); err == nil {
+		t.Fatalf("expected generation mismatch error")
+	}
+}
@@ -0,0 +1,141 @@
+package pdf
+
+import "sort"
+
+// Recovery sources for the catalog and Info dictionary.
+const (
+	SourceTrailer = "trailer"
+	SourceScan    = "scan"
+)
+
+// infoKeys are the standard document information entries used to recognize
+// an orphaned Info dictionary.
+var infoKeys = []Name{"Title", "Author", "Subject", "Keywords", "Creator", "Producer", "CreationDate", "ModDate"}
+
+// Recovery reports what Rebuild reconstructed.
+type Recovery struct {
+	// XRefError is why the cross-reference chain was unusable, if it was.
+	XRefError error
+	// Objects is the number of indirect objects found by scanning.
+	Objects    int
+	Root       Ref
+	RootSource string
+	// Info is the zero Ref when no Info dictionary was found.
+	Info       Ref
+	InfoSource string
+}
+
+// Rebuild discards the cross-reference data and indexes the document by
+// scanning for "N G obj" headers. It then replaces the trailer with one built
+// from the recovered objects. /Root and /Info are kept from the old trailer
+// when they resolve to suitable dictionaries; otherwise the newest catalog
+// and Info-like dictionary in the file are used. /ID and /Encrypt carry over.
+func (d *Document) Rebuild() (Recovery, error) {
+	if d == nil {
+		return Recovery{}, malformed("document is nil", nil)
+	}
+	rec := Recovery{XRefError: d.xrefErr}
+	old, _ := d.Trailer()
+
+	d.xref = map[int]xrefEntry{}
+	d.xrefSections = nil
+	d.trailer = nil
+	d.objects = nil
+	d.objStreams = map[int]*objectStream{}
+	rec.Objects = len(d.index())
+	if rec.Objects == 0 {
+		return rec, malformed("no objects found while scanning", nil)
+	}
+
+	if ref, ok := old.Ref("Root"); ok && d.isCatalog(ref) {
+		rec.Root, rec.RootSource = ref, SourceTrailer
+	} else if ref, ok := d.findObject(d.isCatalog); ok {
+		rec.Root, rec.RootSource = ref, SourceScan
+	} else {
+		return rec, malformed("no document catalog found while scanning", nil)
+	}
+	if ref, ok := old.Ref("Info"); ok && d.isInfo(ref) {
+		rec.Info, rec.InfoSource = ref, SourceTrailer
+	} else if ref, ok := d.findObject(d.isInfo); ok {
+		rec.Info, rec.InfoSource = ref, SourceScan
+	}
+
+	trailer := Dict{"Root": rec.Root, "Size": Integer(d.MaxObjectNumber() + 1)}
+	if rec.InfoSource != "" {
+		trailer["Info"] = rec.Info
+	}
+	for _, key := range []Name{"ID", "Encrypt"} {
+		if v, ok := old[key]; ok {
+			trailer[key] = v
+		}
+	}
+	d.trailer = trailer
+	return rec, nil
+}
+
+// findObject returns the object that matches and appears last in the file,
+// since later definitions belong to newer revisions. Objects packed into an
+// object stream rank at the stream's offset.
+func (d *Document) findObject(match func(Ref) bool) (Ref, bool) {
+	locs := make([]objectLoc, 0, len(d.index()))
+	for _, loc := range d.index() {
+		locs = append(locs, loc)
+	}
+	sort.Slice(locs, func(i, j int) bool {
+		if locs[i].offset != locs[j].offset {
+			return locs[i].offset > locs[j].offset
+		}
+		return locs[i].index > locs[j].index
+	})
+	for _, loc := range locs {
+		if match(loc.ref) {
+			return loc.ref, true
+		}
+	}
+	return Ref{}, false
+}
+
+// isCatalog accepts a /Type /Catalog dictionary, or an untyped one whose
+// /Pages resolves to a page tree node.
+func (d *Document) isCatalog(ref Ref) bool {
+	dict, ok := d.dictAt(ref)
+	if !ok {
+		return false
+	}
+	if dict.TypeName() == "Catalog" {
+		return true
+	}
+	if dict.TypeName() != "" {
+		return false
+	}
+	pages, err := d.Resolve(dict.Get("Pages"))
+	if err != nil {
+		return false
+	}
+	node, ok := pages.(Dict)
+	return ok && node.TypeName() == "Pages"
+}
+
+// isInfo accepts an untyped dictionary with at least one standard document
+// information entry holding a string.
+func (d *Document) isInfo(ref Ref) bool {
+	dict, ok := d.dictAt(ref)
+	if !ok || dict.TypeName() != "" {
+		return false
+	}
+	for _, key := range infoKeys {
+		if _, ok := dict.Get(key).(String); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Document) dictAt(ref Ref) (Dict, bool) {
+	obj, err := d.Object(ref)
+	if err != nil {
+		return nil, false
+	}
+	dict, ok := obj.(Dict)
+	return dict, ok
+}
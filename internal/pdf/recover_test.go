@@ -0,0 +1,148 @@
+package pdf
+
+import "testing"
+
+func TestRebuildMalformedTrailer(t *testing.T) {
+	t.Parallel()
+
+	doc, err := ParseBytes("malformed-trailer.pdf", readFixture(t, "malformed-trailer.pdf"))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	rec, err := doc.Rebuild()
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if rec.XRefError == nil || rec.Objects != 3 {
+		t.Fatalf("Rebuild()=%+v want xref error and 3 objects", rec)
+	}
+	// The trailer dictionary does not parse, so the catalog comes from the scan.
+	if rec.Root != (Ref{Num: 1}) || rec.RootSource != SourceScan || rec.InfoSource != "" {
+		t.Fatalf("Rebuild()=%+v want root 1 0 R from scan and no info", rec)
+	}
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	if size, _ := trailer.Int("Size"); size != 4 {
+		t.Fatalf("trailer /Size=%d want 4", size)
+	}
+	if _, ok := trailer["Info"]; ok {
+		t.Fatalf("trailer has /Info: %v", trailer)
+	}
+}
+
+func TestRebuildFindsCatalogAndInfoByScanning(t *testing.T) {
+	t.Parallel()
+
+	src := "%PDF-1.4\n" +
+		"1 0 obj\n<< /Pages 2 0 R >>\nendobj\n" +
+		"2 0 obj\n<< /Type /Pages /Kids [] /Count 0 >>\nendobj\n" +
+		"3 0 obj\n<< /Title (Old) >>\nendobj\n" +
+		"4 0 obj\n<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>\nendobj\n" +
+		"5 0 obj\n<< /Title (Scanned) /Producer (x) >>\nendobj\n"
+	doc, err := ParseBytes("no-trailer.pdf", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	rec, err := doc.Rebuild()
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if rec.Root != (Ref{Num: 1}) || rec.RootSource != SourceScan {
+		t.Fatalf("Root=%v (%s) want 1 0 R (scan)", rec.Root, rec.RootSource)
+	}
+	if rec.Info != (Ref{Num: 5}) || rec.InfoSource != SourceScan {
+		t.Fatalf("Info=%v (%s) want 5 0 R (scan)", rec.Info, rec.InfoSource)
+	}
+	trailer, _ := doc.Trailer()
+	info, err := doc.Resolve(trailer.Get("Info"))
+	if err != nil {
+		t.Fatalf("Resolve Info: %v", err)
+	}
+	if got := string(info.(Dict).Get("Title").(String).Bytes); got != "Scanned" {
+		t.Fatalf("Info /Title=%q want Scanned", got)
+	}
+}
+
+func TestRebuildPrefersNewestDefinition(t *testing.T) {
+	t.Parallel()
+
+	b := buildPDF(
+		testRevision{
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+				{num: 2, body: "<< /Type /Pages /Kids [] /Count 0 >>"},
+				{num: 3, body: "<< /Title (First) >>"},
+			},
+			trailer: "/Root 1 0 R /Info 3 0 R",
+		},
+		testRevision{
+			objects: []testObject{{num: 3, body: "<< /Title (Second) >>"}},
+			trailer: "/Root 1 0 R /Info 3 0 R",
+		},
+	)
+	doc, err := ParseBytes("two-revisions.pdf", b)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	rec, err := doc.Rebuild()
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if rec.XRefError != nil || rec.RootSource != SourceTrailer || rec.InfoSource != SourceTrailer {
+		t.Fatalf("Rebuild()=%+v want intact chain and root and info from trailer", rec)
+	}
+	obj, err := doc.Object(Ref{Num: 3})
+	if err != nil {
+		t.Fatalf("Object: %v", err)
+	}
+	if got := string(obj.(Dict).Get("Title").(String).Bytes); got != "Second" {
+		t.Fatalf("/Title=%q want Second", got)
+	}
+}
+
+func TestRebuildPrefersNewerPackedObject(t *testing.T) {
+	t.Parallel()
+
+	// The trailers name no /Info, so the scan has to pick between a stale
+	// top-level dictionary and the one a later revision packed.
+	b := buildXRefStreamPDF(
+		testRevision{
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+				{num: 2, body: "<< /Type /Pages /Kids [] /Count 0 >>"},
+				{num: 3, body: "<< /Title (Stale) >>"},
+			},
+			trailer: "/Root 1 0 R",
+		},
+		testRevision{
+			packed:  []testObject{{num: 4, body: "<< /Title (Packed) >>"}},
+			trailer: "/Root 1 0 R",
+		},
+	)
+	doc, err := ParseBytes("packed-info.pdf", b)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	rec, err := doc.Rebuild()
+	if err != nil {
+		t.Fatalf("Rebuild: %v", err)
+	}
+	if rec.Info != (Ref{Num: 4}) || rec.InfoSource != SourceScan {
+		t.Fatalf("Info=%v (%s) want 4 0 R (scan)", rec.Info, rec.InfoSource)
+	}
+}
+
+func TestRebuildWithoutCatalog(t *testing.T) {
+	t.Parallel()
+
+	src := "%PDF-1.4\n1 0 obj\n<< /Title (Lonely) >>\nendobj\n"
+	doc, err := ParseBytes("no-catalog.pdf", []byte(src))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if _, err := doc.Rebuild(); err == nil {
+		t.Fatalf("Rebuild() succeeded without a catalog")
+	}
+}
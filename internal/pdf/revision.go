@@ -0,0 +1,76 @@
+package pdf
+
+import "bytes"
+
+const eofMarker = "%%EOF"
+
+// Revision is one saved state of the file. The prefix [0, End) is the
+// complete file as of this revision and [Start, End) holds the bytes its
+// incremental update appended. XRefOffset is the startxref value recorded
+// just before the revision's %%EOF marker.
+type Revision struct {
+	Start      int64
+	End        int64
+	XRefOffset int64
+}
+
+// Size returns the number of bytes appended by the revision.
+func (r Revision) Size() int64 {
+	return r.End - r.Start
+}
+
+// Revisions returns the file's revisions, oldest first. A %%EOF marker only
+// ends a revision when the startxref before it points at a section of the
+// /Prev chain, so stray markers (for example the first-page trailer of a
+// linearized file) are ignored. When the chain is broken, every marker with
+// a parsable startxref counts.
+func (d *Document) Revisions() []Revision {
+	if d == nil {
+		return nil
+	}
+	sections := make(map[int64]bool, len(d.xrefSections))
+	for _, sec := range d.xrefSections {
+		sections[sec.offset] = true
+	}
+	chained := d.xrefErr == nil
+
+	var revs []Revision
+	b := d.content
+	start, from := 0, 0
+	for {
+		idx := bytes.Index(b[from:], []byte(eofMarker))
+		if idx < 0 {
+			break
+		}
+		end := skipEOL(b, from+idx+len(eofMarker))
+		from = end
+		// Only a startxref after the previous boundary belongs to this marker.
+		off, ok := parseStartXRef(b[start:end])
+		if !ok || (chained && !sections[off]) {
+			continue
+		}
+		revs = append(revs, Revision{Start: int64(start), End: int64(end), XRefOffset: off})
+		start = end
+	}
+	return revs
+}
+
+// AtRevision parses the file as it was when rev was saved. The result shares
+// d's bytes and is only usable until d is closed.
+func (d *Document) AtRevision(rev Revision) (*Document, error) {
+	if d == nil || rev.End <= 0 || rev.End > int64(len(d.content)) {
+		return nil, malformed("revision boundary outside file", nil)
+	}
+	return parseContent(d.path, d.content[:rev.End:rev.End])
+}
+
+// skipEOL advances past a single end-of-line sequence at i.
+func skipEOL(b []byte, i int) int {
+	if i < len(b) && b[i] == '\r' {
+		i++
+	}
+	if i < len(b) && b[i] == '\n' {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,72 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRevisionsFollowIncrementalUpdates(t *testing.T) {
+	t.Parallel()
+
+	b := readFixture(t, "incremental-updates.pdf")
+	doc, err := ParseBytes("incremental-updates.pdf", b)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	revs := doc.Revisions()
+	want := []int64{177, 411, 581}
+	if len(revs) != len(want) {
+		t.Fatalf("Revisions()=%d want %d", len(revs), len(want))
+	}
+	var start int64
+	for i, rev := range revs {
+		if rev.XRefOffset != want[i] {
+			t.Fatalf("rev %d XRefOffset=%d want %d", i, rev.XRefOffset, want[i])
+		}
+		if rev.Start != start || !bytes.HasSuffix(b[:rev.End], []byte("%%EOF\n")) {
+			t.Fatalf("rev %d range [%d, %d) does not end at %%%%EOF", i, rev.Start, rev.End)
+		}
+		if rev.Size() != rev.End-rev.Start {
+			t.Fatalf("rev %d Size()=%d", i, rev.Size())
+		}
+		start = rev.End
+	}
+	if revs[len(revs)-1].End != int64(len(b)) {
+		t.Fatalf("last revision ends at %d want %d", revs[len(revs)-1].End, len(b))
+	}
+
+	first, err := doc.AtRevision(revs[0])
+	if err != nil {
+		t.Fatalf("AtRevision: %v", err)
+	}
+	info, err := first.Object(Ref{Num: 3})
+	if err != nil {
+		t.Fatalf("Object(3): %v", err)
+	}
+	if got := string(info.(Dict).Get("Title").(String).Bytes); got != "Revision 1" {
+		t.Fatalf("Title=%q want Revision 1", got)
+	}
+}
+
+func TestRevisionsIgnoreStrayEOFMarkers(t *testing.T) {
+	t.Parallel()
+
+	src := buildPDF(testRevision{
+		objects: []testObject{{num: 1, body: "<< /Type /Catalog >>"}},
+		trailer: "/Root 1 0 R",
+	})
+	// A marker with no startxref of its own does not start a new revision.
+	src = append(src, "% trailing comment %%EOF\n"...)
+
+	doc, err := ParseBytes("stray.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	revs := doc.Revisions()
+	if len(revs) != 1 {
+		t.Fatalf("Revisions()=%d want 1: %#v", len(revs), revs)
+	}
+	if _, err := doc.AtRevision(Revision{End: int64(len(src)) + 1}); err == nil {
+		t.Fatalf("expected error for boundary outside file")
+	}
+}
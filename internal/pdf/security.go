@@ -0,0 +1,342 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+
+	"pdfmeta/internal/crypt"
+	"pdfmeta/internal/model"
+)
+
+// Unlock authenticates password with the document's standard security
+// handler. After a successful Unlock, Object and Resolve return decrypted
+// strings and stream data. The empty string tries the empty user password,
+// which is what most readers do when no password is given. Unlock is a no-op
+// for documents that are not encrypted.
+func (d *Document) Unlock(password string) error {
+	if !d.Encrypted() || d.security != nil {
+		return nil
+	}
+	params, encryptRef, err := d.securityParams()
+	if err != nil {
+		return err
+	}
+	h, err := crypt.Authenticate(params, password)
+	switch {
+	case errors.Is(err, crypt.ErrPassword) && password == "":
+		return &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonPasswordRequired, Message: "pdf requires a password"}
+	case errors.Is(err, crypt.ErrPassword):
+		return &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonIncorrectPassword, Message: "incorrect password"}
+	case err != nil:
+		return &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonUnsupportedEncryption, Message: "cannot decrypt pdf", Cause: err}
+	}
+	d.security = h
+	d.encryptRef = encryptRef
+	return nil
+}
+
+// Unlocked reports whether Unlock succeeded, so objects are decrypted.
+func (d *Document) Unlocked() bool {
+	return d != nil && d.security != nil
+}
+
+// OwnerUnlocked reports whether the document was unlocked with the owner
+// password.
+func (d *Document) OwnerUnlocked() bool {
+	return d.Unlocked() && d.security.Owner()
+}
+
+// Security is the parsed /Encrypt dictionary of an encrypted document.
+type Security struct {
+	Filter    string
+	SubFilter string
+	V         int
+	R         int
+	// KeyLength is the file key length in bits.
+	KeyLength int
+	P         int32
+	// EncryptMetadata is false when the XMP metadata stream is stored in
+	// clear text.
+	EncryptMetadata bool
+	// StmF and StrF name the crypt filters for streams and strings. They
+	// are only set from /V 4 on.
+	StmF         string
+	StrF         string
+	CryptFilters []CryptFilter
+}
+
+// CryptFilter is one entry of the /CF dictionary.
+type CryptFilter struct {
+	Name string
+	// Method is the /CFM value: None, V2, AESV2 or AESV3.
+	Method string
+	// Length is the key length in bits, or 0 when not given.
+	Length int
+}
+
+// Security parses the /Encrypt dictionary. It needs no password.
+func (d *Document) Security() (Security, error) {
+	dict, _, err := d.encryptDict()
+	if err != nil {
+		return Security{}, err
+	}
+	s := Security{EncryptMetadata: true}
+	filter, _ := dict.Name("Filter")
+	subFilter, _ := dict.Name("SubFilter")
+	s.Filter, s.SubFilter = string(filter), string(subFilter)
+	v, _ := dict.Int("V")
+	r, _ := dict.Int("R")
+	perm, _ := dict.Int("P")
+	s.V, s.R, s.P = int(v), int(r), int32(perm)
+	if em, ok := dict.Get("EncryptMetadata").(Bool); ok {
+		s.EncryptMetadata = bool(em)
+	}
+	switch {
+	case s.V == 4:
+		s.KeyLength = 128
+	case s.V >= 5:
+		s.KeyLength = 256
+	default:
+		s.KeyLength = 40
+		if bits, ok := dict.Int("Length"); ok && s.V > 1 {
+			s.KeyLength = int(bits)
+		}
+	}
+	if s.V >= 4 {
+		stmF, _ := dict.Name("StmF")
+		strF, _ := dict.Name("StrF")
+		s.StmF, s.StrF = string(stmF), string(strF)
+		if s.StmF == "" {
+			s.StmF = "Identity"
+		}
+		if s.StrF == "" {
+			s.StrF = "Identity"
+		}
+		filters, _ := dict.Dict("CF")
+		for _, name := range filters.Keys() {
+			cf, _ := filters.Dict(name)
+			method, _ := cf.Name("CFM")
+			length, _ := cf.Int("Length")
+			// /Length is in bits, but many writers store bytes.
+			if length > 0 && length <= 32 {
+				length *= 8
+			}
+			s.CryptFilters = append(s.CryptFilters, CryptFilter{Name: string(name), Method: string(method), Length: int(length)})
+		}
+	}
+	return s, nil
+}
+
+// encryptDict resolves the trailer's /Encrypt dictionary and, when it is
+// indirect, its reference.
+func (d *Document) encryptDict() (Dict, Ref, error) {
+	trailer, err := d.Trailer()
+	if err != nil {
+		return nil, Ref{}, err
+	}
+	encryptRef, _ := trailer.Ref("Encrypt")
+	obj, err := d.Resolve(trailer.Get("Encrypt"))
+	if err != nil {
+		return nil, Ref{}, err
+	}
+	dict, ok := obj.(Dict)
+	if !ok {
+		return nil, Ref{}, &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonUnsupportedEncryption, Message: "cannot decrypt pdf: /Encrypt is not a dictionary"}
+	}
+	return dict, encryptRef, nil
+}
+
+// securityParams reads the /Encrypt dictionary and the first /ID element
+// into crypt parameters. It also returns the reference of an indirect
+// /Encrypt dictionary, whose strings are never encrypted.
+func (d *Document) securityParams() (crypt.Params, Ref, error) {
+	unsupported := func(format string, args ...any) error {
+		return &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonUnsupportedEncryption, Message: "cannot decrypt pdf: unsupported " + fmt.Sprintf(format, args...)}
+	}
+	trailer, err := d.Trailer()
+	if err != nil {
+		return crypt.Params{}, Ref{}, err
+	}
+	dict, encryptRef, err := d.encryptDict()
+	if err != nil {
+		return crypt.Params{}, Ref{}, err
+	}
+	if filter, _ := dict.Name("Filter"); filter != "Standard" {
+		return crypt.Params{}, Ref{}, unsupported("security handler /%s", filter)
+	}
+
+	p := crypt.Params{EncryptMetadata: true}
+	v, _ := dict.Int("V")
+	r, _ := dict.Int("R")
+	perm, _ := dict.Int("P")
+	p.V, p.R, p.P = int(v), int(r), int32(perm)
+	p.O = stringBytes(dict.Get("O"))
+	p.U = stringBytes(dict.Get("U"))
+	p.OE = stringBytes(dict.Get("OE"))
+	p.UE = stringBytes(dict.Get("UE"))
+	p.Perms = stringBytes(dict.Get("Perms"))
+	if em, ok := dict.Get("EncryptMetadata").(Bool); ok {
+		p.EncryptMetadata = bool(em)
+	}
+	if ids, ok := trailer.Get("ID").(Array); ok && len(ids) > 0 {
+		p.ID = stringBytes(ids[0])
+	}
+
+	switch p.V {
+	case 1:
+		p.Length = 5
+		p.StmF, p.StrF = crypt.MethodRC4, crypt.MethodRC4
+	case 2:
+		p.Length = 5
+		if bits, ok := dict.Int("Length"); ok {
+			p.Length = int(bits / 8)
+		}
+		p.StmF, p.StrF = crypt.MethodRC4, crypt.MethodRC4
+	case 4, 5:
+		p.Length = 16
+		if p.V == 5 {
+			p.Length = 32
+		}
+		filters, _ := dict.Dict("CF")
+		if p.StmF, err = cryptFilterMethod(filters, dict.Get("StmF")); err != nil {
+			return crypt.Params{}, Ref{}, unsupported("%v", err)
+		}
+		if p.StrF, err = cryptFilterMethod(filters, dict.Get("StrF")); err != nil {
+			return crypt.Params{}, Ref{}, unsupported("%v", err)
+		}
+	default:
+		return crypt.Params{}, Ref{}, unsupported("/V %d", p.V)
+	}
+	return p, encryptRef, nil
+}
+
+// cryptFilterMethod maps a /StmF or /StrF name to the method of the named
+// crypt filter. A missing name means Identity.
+func cryptFilterMethod(filters Dict, name Object) (crypt.Method, error) {
+	n, _ := name.(Name)
+	if n == "" || n == "Identity" {
+		return crypt.MethodNone, nil
+	}
+	cf, ok := filters.Dict(n)
+	if !ok {
+		return 0, fmt.Errorf("crypt filter /%s is not defined", n)
+	}
+	switch cfm, _ := cf.Name("CFM"); cfm {
+	case "V2":
+		return crypt.MethodRC4, nil
+	case "AESV2":
+		return crypt.MethodAESV2, nil
+	case "AESV3":
+		return crypt.MethodAESV3, nil
+	case "None", "":
+		return crypt.MethodNone, nil
+	default:
+		return 0, fmt.Errorf("crypt filter method /%s", cfm)
+	}
+}
+
+// decryptObject decrypts the strings and stream data of top-level object
+// ref. Cross-reference streams, the /Encrypt dictionary and, when
+// /EncryptMetadata is false, XMP metadata streams are stored in clear.
+func (d *Document) decryptObject(ref Ref, obj Object) (Object, error) {
+	if d.security == nil || ref == d.encryptRef {
+		return obj, nil
+	}
+	out, err := d.cryptObject(ref, obj, d.security.DecryptString, d.security.DecryptStream, false)
+	if err != nil {
+		return nil, &model.AppError{Code: model.ErrPDFEncrypted, Reason: model.ReasonDecryptFailed, Message: fmt.Sprintf("decrypt object %d %d", ref.Num, ref.Gen), Cause: err}
+	}
+	return out, nil
+}
+
+// EncryptObject encrypts the strings and stream data of obj for writing as
+// top-level object ref, undoing what Object decrypted. It returns obj
+// unchanged unless the document is unlocked. Callers must not pass the
+// /Encrypt dictionary or objects that go into an object stream; the object
+// stream itself is encrypted instead.
+func (d *Document) EncryptObject(ref Ref, obj Object) (Object, error) {
+	if d.security == nil {
+		return obj, nil
+	}
+	out, err := d.cryptObject(ref, obj, d.security.EncryptString, d.security.EncryptStream, true)
+	if err != nil {
+		return nil, &model.AppError{Code: model.ErrInternal, Message: fmt.Sprintf("encrypt object %d %d", ref.Num, ref.Gen), Cause: err}
+	}
+	return out, nil
+}
+
+// cryptObject applies str to every string and stm to the stream data in obj,
+// skipping the streams the security handler leaves in clear. toHex writes
+// the resulting strings in hex, which suits binary ciphertext.
+func (d *Document) cryptObject(ref Ref, obj Object, str, stm func(num, gen int, b []byte) ([]byte, error), toHex bool) (Object, error) {
+	var walk func(obj Object) (Object, error)
+	walk = func(obj Object) (Object, error) {
+		switch v := obj.(type) {
+		case String:
+			b, err := str(ref.Num, ref.Gen, v.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			return String{Bytes: b, Hex: v.Hex || toHex}, nil
+		case Array:
+			out := make(Array, len(v))
+			for i, e := range v {
+				var err error
+				if out[i], err = walk(e); err != nil {
+					return nil, err
+				}
+			}
+			return out, nil
+		case Dict:
+			out := make(Dict, len(v))
+			for k, e := range v {
+				var err error
+				if out[k], err = walk(e); err != nil {
+					return nil, err
+				}
+			}
+			return out, nil
+		case Stream:
+			typ := v.Dict.TypeName()
+			if typ == "XRef" {
+				return v, nil
+			}
+			dict, err := walk(v.Dict)
+			if err != nil {
+				return nil, err
+			}
+			data := v.Data
+			if !(typ == "Metadata" && !d.security.EncryptMetadata()) && !identityCryptFilter(v.Dict) {
+				if data, err = stm(ref.Num, ref.Gen, v.Data); err != nil {
+					return nil, err
+				}
+			}
+			return Stream{Dict: dict.(Dict), Data: data}, nil
+		default:
+			return obj, nil
+		}
+	}
+	return walk(obj)
+}
+
+// identityCryptFilter reports whether a stream opts out of encryption with a
+// leading /Crypt filter that names no crypt filter or Identity.
+func identityCryptFilter(dict Dict) bool {
+	filters, params, err := streamFilters(dict)
+	if err != nil || len(filters) == 0 || filters[0] != "Crypt" {
+		return false
+	}
+	return identityCrypt(params[0])
+}
+
+// identityCrypt reports whether /Crypt decode parameters name no crypt
+// filter or Identity.
+func identityCrypt(params Dict) bool {
+	name, _ := params.Name("Name")
+	return name == "" || name == "Identity"
+}
+
+func stringBytes(obj Object) []byte {
+	s, _ := obj.(String)
+	return s.Bytes
+}
@@ -0,0 +1,180 @@
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+func TestUnlockDecryptsFixtures(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name  string
+		user  string
+		empty bool
+	}{
+		{name: "encrypted-rc4-40.pdf", user: "", empty: true},
+		{name: "encrypted-rc4-128.pdf", user: "user"},
+		{name: "encrypted-aes-128.pdf", user: "", empty: true},
+		{name: "encrypted-aes-256-r5.pdf", user: "", empty: true},
+		{name: "encrypted-aes-256.pdf", user: "user"},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			for _, password := range []string{tc.user, "owner"} {
+				doc, err := ParseBytes(tc.name, readFixture(t, tc.name))
+				if err != nil {
+					t.Fatalf("ParseBytes: %v", err)
+				}
+				if err := doc.Unlock(password); err != nil {
+					t.Fatalf("Unlock(%q): %v", password, err)
+				}
+				if got, want := doc.OwnerUnlocked(), password == "owner"; got != want {
+					t.Fatalf("Unlock(%q): OwnerUnlocked()=%v want %v", password, got, want)
+				}
+				assertDecrypted(t, doc)
+			}
+
+			doc, err := ParseBytes(tc.name, readFixture(t, tc.name))
+			if err != nil {
+				t.Fatalf("ParseBytes: %v", err)
+			}
+			err = doc.Unlock("wrong")
+			assertAppErrorCode(t, err, model.ErrPDFEncrypted)
+			if doc.Unlocked() {
+				t.Fatalf("Unlocked() after a wrong password")
+			}
+			if err := doc.Unlock(""); (err == nil) != tc.empty {
+				t.Fatalf("Unlock(\"\")=%v, empty user password expected to work: %v", err, tc.empty)
+			}
+		})
+	}
+}
+
+func assertDecrypted(t *testing.T, doc *Document) {
+	t.Helper()
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	obj, err := doc.Resolve(trailer.Get("Info"))
+	if err != nil {
+		t.Fatalf("resolve Info: %v", err)
+	}
+	info, _ := obj.(Dict)
+	if title, _ := info.Get("Title").(String); string(title.Bytes) != "Secret Title" {
+		t.Fatalf("Title=%q want %q", title.Bytes, "Secret Title")
+	}
+
+	root, _ := trailer.Ref("Root")
+	obj, err = doc.Object(root)
+	if err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	catalog, _ := obj.(Dict)
+	obj, err = doc.Resolve(catalog.Get("Metadata"))
+	if err != nil {
+		t.Fatalf("resolve Metadata: %v", err)
+	}
+	stream, ok := obj.(Stream)
+	if !ok {
+		t.Fatalf("Metadata is %T, want Stream", obj)
+	}
+	data, err := doc.DecodeStream(stream)
+	if err != nil {
+		t.Fatalf("DecodeStream: %v", err)
+	}
+	if !bytes.Contains(data, []byte("Secret Subject")) {
+		t.Fatalf("XMP stream was not decrypted: %q", data)
+	}
+}
+
+func TestEncryptObjectRoundTrip(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"encrypted-rc4-128.pdf", "encrypted-aes-128.pdf", "encrypted-aes-256.pdf"} {
+		doc, err := ParseBytes(name, readFixture(t, name))
+		if err != nil {
+			t.Fatalf("ParseBytes: %v", err)
+		}
+		if err := doc.Unlock("owner"); err != nil {
+			t.Fatalf("%s: Unlock: %v", name, err)
+		}
+		ref := Ref{Num: 9}
+		plain := Dict{"Title": String{Bytes: []byte("Round Trip")}, "Kids": Array{String{Bytes: []byte("x")}}}
+		sealed, err := doc.EncryptObject(ref, plain)
+		if err != nil {
+			t.Fatalf("%s: EncryptObject: %v", name, err)
+		}
+		if title := sealed.(Dict).Get("Title").(String); bytes.Equal(title.Bytes, []byte("Round Trip")) || !title.Hex {
+			t.Fatalf("%s: title not encrypted as a hex string: %+v", name, title)
+		}
+		opened, err := doc.decryptObject(ref, sealed)
+		if err != nil {
+			t.Fatalf("%s: decryptObject: %v", name, err)
+		}
+		if got := AppendObject(nil, opened); string(got) != "<< /Kids [<78>] /Title <526F756E642054726970> >>" {
+			t.Fatalf("%s: round trip=%s", name, got)
+		}
+	}
+}
+
+func TestUnlockUnencryptedIsNoop(t *testing.T) {
+	t.Parallel()
+	doc, err := ParseBytes("minimal.pdf", readFixture(t, "minimal.pdf"))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.Unlock("anything"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if doc.Unlocked() {
+		t.Fatalf("Unlocked() on an unencrypted document")
+	}
+}
+
+func TestUnlockRejectsIncompleteEncryptDictionary(t *testing.T) {
+	t.Parallel()
+	doc, err := ParseBytes("encrypted-marker.pdf", readFixture(t, "encrypted-marker.pdf"))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	err = doc.Unlock("")
+	assertAppErrorCode(t, err, model.ErrPDFEncrypted)
+	var appErr *model.AppError
+	if !errors.As(err, &appErr) || appErr.Reason != model.ReasonUnsupportedEncryption {
+		t.Fatalf("Unlock reason=%+v want %s", err, model.ReasonUnsupportedEncryption)
+	}
+}
+
+func TestSecurityWithoutPassword(t *testing.T) {
+	t.Parallel()
+	doc, err := ParseBytes("encrypted-rc4-128.pdf", readFixture(t, "encrypted-rc4-128.pdf"))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	sec, err := doc.Security()
+	if err != nil {
+		t.Fatalf("Security: %v", err)
+	}
+	want := Security{Filter: "Standard", V: 2, R: 3, KeyLength: 128, P: -3904, EncryptMetadata: true}
+	if !reflect.DeepEqual(sec, want) {
+		t.Fatalf("Security()=%+v want %+v", sec, want)
+	}
+
+	doc, err = ParseBytes("encrypted-marker.pdf", readFixture(t, "encrypted-marker.pdf"))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	sec, err = doc.Security()
+	if err != nil {
+		t.Fatalf("Security: %v", err)
+	}
+	if sec.V != 4 || sec.KeyLength != 128 || sec.StmF != "Identity" || sec.StrF != "Identity" {
+		t.Fatalf("Security()=%+v want V4 with Identity filters", sec)
+	}
+}
@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// AppendObject appends the PDF syntax for obj to dst.
+func AppendObject(dst []byte, obj Object) []byte {
+	switch v := obj.(type) {
+	case nil, Null:
+		return append(dst, "null"...)
+	case Bool:
+		if v {
+			return append(dst, "true"...)
+		}
+		return append(dst, "false"...)
+	case Integer:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case Real:
+		return appendReal(dst, float64(v))
+	case Name:
+		return appendName(dst, v)
+	case String:
+		if v.Hex {
+			return appendHexString(dst, v.Bytes)
+		}
+		return appendLiteralString(dst, v.Bytes)
+	case Ref:
+		return fmt.Appendf(dst, "%d %d R", v.Num, v.Gen)
+	case Array:
+		dst = append(dst, '[')
+		for i, e := range v {
+			if i > 0 {
+				dst = append(dst, ' ')
+			}
+			dst = AppendObject(dst, e)
+		}
+		return append(dst, ']')
+	case Dict:
+		dst = append(dst, "<<"...)
+		for _, k := range v.Keys() {
+			dst = append(dst, ' ')
+			dst = appendName(dst, k)
+			dst = append(dst, ' ')
+			dst = AppendObject(dst, v[k])
+		}
+		return append(dst, " >>"...)
+	case Stream:
+		dict := v.Dict.Clone()
+		dict["Length"] = Integer(len(v.Data))
+		dst = AppendObject(dst, dict)
+		dst = append(dst, "\nstream\n"...)
+		dst = append(dst, v.Data...)
+		return append(dst, "\nendstream"...)
+	default:
+		return append(dst, "null"...)
+	}
+}
+
+// AppendIndirectObject appends "N G obj ... endobj" for obj to dst.
+func AppendIndirectObject(dst []byte, ref Ref, obj Object) []byte {
+	dst = fmt.Appendf(dst, "%d %d obj\n", ref.Num, ref.Gen)
+	dst = AppendObject(dst, obj)
+	return append(dst, "\nendobj\n"...)
+}
+
+func appendReal(dst []byte, f float64) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(dst, '0')
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e15 {
+		return strconv.AppendInt(dst, int64(f), 10)
+	}
+	return strconv.AppendFloat(dst, f, 'f', -1, 64)
+}
+
+func appendName(dst []byte, n Name) []byte {
+	dst = append(dst, '/')
+	for i := 0; i < len(n); i++ {
+		c := n[i]
+		if c < 0x21 || c > 0x7e || c == '#' || isDelimiter(c) {
+			dst = fmt.Appendf(dst, "#%02X", c)
+			continue
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+func appendLiteralString(dst []byte, b []byte) []byte {
+	dst = append(dst, '(')
+	for _, c := range b {
+		switch c {
+		case '\\', '(', ')':
+			dst = append(dst, '\\', c)
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return append(dst, ')')
+}
+
+func appendHexString(dst []byte, b []byte) []byte {
+	const digits = "0123456789ABCDEF"
+	dst = append(dst, '<')
+	for _, c := range b {
+		dst = append(dst, digits[c>>4], digits[c&0x0f])
+	}
+	return append(dst, '>')
+}
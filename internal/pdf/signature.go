@@ -0,0 +1,197 @@
+package pdf
+
+// DocMDP access permission levels (/TransformParams /P) of a certification
+// signature.
+const (
+	DocMDPNoChanges   = 1
+	DocMDPFormFill    = 2
+	DocMDPAnnotations = 3
+)
+
+// Signature is a signed signature field: an AcroForm field with /FT /Sig
+// whose /V is a signature dictionary.
+type Signature struct {
+	// Field is the fully qualified field name.
+	Field     string
+	Ref       Ref
+	Filter    string
+	SubFilter string
+	// ByteRange holds the [offset length] pairs the signature covers.
+	ByteRange []int64
+	Name      string
+	Reason    string
+	Location  string
+	// SigningTime is /M, a PDF date string.
+	SigningTime string
+	// DocMDP is the permission level of a certification signature, or 0
+	// for an approval signature.
+	DocMDP int
+}
+
+// Covered reports how many bytes, counted from the start of the file, the
+// byte ranges span. Bytes appended after that are not signed.
+func (s Signature) Covered() int64 {
+	var end int64
+	for i := 0; i+1 < len(s.ByteRange); i += 2 {
+		if e := s.ByteRange[i] + s.ByteRange[i+1]; e > end {
+			end = e
+		}
+	}
+	return end
+}
+
+// Signatures lists the signed signature fields reachable from the catalog's
+// /AcroForm /Fields tree, in tree order. Fields are visited once, so a /Kids
+// cycle cannot loop; unresolvable fields are skipped.
+func (d *Document) Signatures() []Signature {
+	catalog, ok := d.catalog()
+	if !ok {
+		return nil
+	}
+	form, ok := d.resolveDict(catalog.Get("AcroForm"))
+	if !ok {
+		return nil
+	}
+	certRef, level := d.certification(catalog)
+
+	var sigs []Signature
+	seen := map[Ref]bool{}
+	var walk func(obj Object, parent string, depth int)
+	walk = func(obj Object, parent string, depth int) {
+		if depth > maxNestingDepth {
+			return
+		}
+		if ref, ok := obj.(Ref); ok {
+			if seen[ref] {
+				return
+			}
+			seen[ref] = true
+		}
+		field, ok := d.resolveDict(obj)
+		if !ok {
+			return
+		}
+		name := parent
+		if t, ok := field.Get("T").(String); ok {
+			if name != "" {
+				name += "."
+			}
+			name += DecodeText(t.Bytes)
+		}
+		if ft, _ := field.Name("FT"); ft == "Sig" {
+			if v, ok := d.resolveDict(field.Get("V")); ok {
+				sig := d.signature(v)
+				sig.Field = name
+				sig.Ref, _ = field.Ref("V")
+				if sig.Ref == certRef && certRef != (Ref{}) {
+					sig.DocMDP = level
+				}
+				sigs = append(sigs, sig)
+			}
+		}
+		kids, _ := d.Resolve(field.Get("Kids"))
+		if arr, ok := kids.(Array); ok {
+			for _, kid := range arr {
+				walk(kid, name, depth+1)
+			}
+		}
+	}
+	fields, _ := d.Resolve(form.Get("Fields"))
+	if arr, ok := fields.(Array); ok {
+		for _, f := range arr {
+			walk(f, "", 0)
+		}
+	}
+	return sigs
+}
+
+// DocMDP returns the permission level of the document's certification
+// signature, named by the catalog /Perms /DocMDP entry.
+func (d *Document) DocMDP() (int, bool) {
+	catalog, ok := d.catalog()
+	if !ok {
+		return 0, false
+	}
+	_, level := d.certification(catalog)
+	return level, level != 0
+}
+
+// certification resolves /Perms /DocMDP to the certification signature
+// dictionary and reads its /P level from the DocMDP transform. A missing /P
+// means DocMDPFormFill.
+func (d *Document) certification(catalog Dict) (Ref, int) {
+	perms, ok := d.resolveDict(catalog.Get("Perms"))
+	if !ok {
+		return Ref{}, 0
+	}
+	sig, ok := d.resolveDict(perms.Get("DocMDP"))
+	if !ok {
+		return Ref{}, 0
+	}
+	ref, _ := perms.Ref("DocMDP")
+	level := DocMDPFormFill
+	refs, _ := d.Resolve(sig.Get("Reference"))
+	arr, _ := refs.(Array)
+	for _, r := range arr {
+		tr, ok := d.resolveDict(r)
+		if !ok {
+			continue
+		}
+		if method, _ := tr.Name("TransformMethod"); method != "DocMDP" {
+			continue
+		}
+		if params, ok := d.resolveDict(tr.Get("TransformParams")); ok {
+			if p, ok := params.Int("P"); ok && p >= DocMDPNoChanges && p <= DocMDPAnnotations {
+				level = int(p)
+			}
+		}
+	}
+	return ref, level
+}
+
+// signature reads the descriptive entries of a signature dictionary.
+func (d *Document) signature(v Dict) Signature {
+	var s Signature
+	filter, _ := v.Name("Filter")
+	subFilter, _ := v.Name("SubFilter")
+	s.Filter, s.SubFilter = string(filter), string(subFilter)
+	if br, err := d.Resolve(v.Get("ByteRange")); err == nil {
+		if arr, ok := br.(Array); ok {
+			for _, e := range arr {
+				if n, ok := e.(Integer); ok {
+					s.ByteRange = append(s.ByteRange, int64(n))
+				}
+			}
+		}
+	}
+	text := func(key Name) string {
+		obj, err := d.Resolve(v.Get(key))
+		if err != nil {
+			return ""
+		}
+		str, _ := obj.(String)
+		return DecodeText(str.Bytes)
+	}
+	s.Name, s.Reason, s.Location, s.SigningTime = text("Name"), text("Reason"), text("Location"), text("M")
+	return s
+}
+
+func (d *Document) catalog() (Dict, bool) {
+	trailer, err := d.Trailer()
+	if err != nil {
+		return nil, false
+	}
+	return d.resolveDict(trailer.Get("Root"))
+}
+
+func (d *Document) resolveDict(obj Object) (Dict, bool) {
+	if obj == nil {
+		return nil, false
+	}
+	v, err := d.Resolve(obj)
+	if err != nil {
+		return nil, false
+	}
+	dict, ok := v.(Dict)
+	return dict, ok
+}
@@ -0,0 +1,69 @@
+package pdf
+
+import "testing"
+
+func TestSignatures(t *testing.T) {
+	t.Parallel()
+
+	doc, err := ParseBytes("signed.pdf", buildPDF(testRevision{objects: []testObject{
+		{num: 1, body: "<< /Type /Catalog /AcroForm 2 0 R /Perms << /DocMDP 5 0 R >> >>"},
+		{num: 2, body: "<< /Fields [3 0 R 6 0 R] /SigFlags 3 >>"},
+		{num: 3, body: "<< /T (Approvals) /Kids [4 0 R 3 0 R] >>"},
+		{num: 4, body: "<< /FT /Sig /T (Author) /V 5 0 R >>"},
+		{num: 5, body: "<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached /ByteRange [0 100 200 50] /Name (Jane) /M (D:20240101120000Z)" +
+			" /Reference [<< /TransformMethod /DocMDP /TransformParams << /P 1 /V /1.2 >> >>] >>"},
+		{num: 6, body: "<< /FT /Sig /T (Empty) >>"},
+	}, trailer: "/Root 1 0 R"}))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	sigs := doc.Signatures()
+	if len(sigs) != 1 {
+		t.Fatalf("Signatures()=%+v, want one signed field", sigs)
+	}
+	sig := sigs[0]
+	if sig.Field != "Approvals.Author" || sig.Ref != (Ref{Num: 5}) || sig.SubFilter != "ETSI.CAdES.detached" || sig.Name != "Jane" || sig.SigningTime != "D:20240101120000Z" {
+		t.Fatalf("unexpected signature: %+v", sig)
+	}
+	if sig.DocMDP != DocMDPNoChanges || sig.Covered() != 250 {
+		t.Fatalf("DocMDP=%d Covered=%d", sig.DocMDP, sig.Covered())
+	}
+	if level, ok := doc.DocMDP(); !ok || level != DocMDPNoChanges {
+		t.Fatalf("DocMDP()=%d,%v", level, ok)
+	}
+}
+
+func TestSignaturesDefaultDocMDPLevel(t *testing.T) {
+	t.Parallel()
+
+	doc, err := ParseBytes("certified.pdf", buildPDF(testRevision{objects: []testObject{
+		{num: 1, body: "<< /Type /Catalog /Perms << /DocMDP 2 0 R >> >>"},
+		{num: 2, body: "<< /Type /Sig /Reference [<< /TransformMethod /DocMDP >>] >>"},
+	}, trailer: "/Root 1 0 R"}))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if level, ok := doc.DocMDP(); !ok || level != DocMDPFormFill {
+		t.Fatalf("DocMDP()=%d,%v", level, ok)
+	}
+	if sigs := doc.Signatures(); len(sigs) != 0 {
+		t.Fatalf("Signatures()=%+v without AcroForm", sigs)
+	}
+}
+
+func TestSignaturesUnsigned(t *testing.T) {
+	t.Parallel()
+
+	doc, err := ParseBytes("plain.pdf", buildPDF(testRevision{objects: []testObject{
+		{num: 1, body: "<< /Type /Catalog >>"},
+	}, trailer: "/Root 1 0 R"}))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if _, ok := doc.DocMDP(); ok {
+		t.Fatalf("expected no certification")
+	}
+	if sigs := doc.Signatures(); sigs != nil {
+		t.Fatalf("Signatures()=%+v", sigs)
+	}
+}
@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"bytes"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Byte order marks that select a text string encoding (ISO 32000-2, 7.9.2.2).
+var (
+	bomUTF16BE = []byte{0xFE, 0xFF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+)
+
+// pdfDocHigh maps the PDFDocEncoding bytes that differ from ISO Latin-1.
+// Bytes 0x7F, 0x9F and 0xAD are undefined and absent.
+var pdfDocHigh = map[byte]rune{
+	0x18: '˘', 0x19: 'ˇ', 0x1A: 'ˆ', 0x1B: '˙',
+	0x1C: '˝', 0x1D: '˛', 0x1E: '˚', 0x1F: '˜',
+	0x80: '•', 0x81: '†', 0x82: '‡', 0x83: '…',
+	0x84: '—', 0x85: '–', 0x86: 'ƒ', 0x87: '⁄',
+	0x88: '‹', 0x89: '›', 0x8A: '−', 0x8B: '‰',
+	0x8C: '„', 0x8D: '“', 0x8E: '”', 0x8F: '‘',
+	0x90: '’', 0x91: '‚', 0x92: '™', 0x93: 'ﬁ',
+	0x94: 'ﬂ', 0x95: 'Ł', 0x96: 'Œ', 0x97: 'Š',
+	0x98: 'Ÿ', 0x99: 'Ž', 0x9A: 'ı', 0x9B: 'ł',
+	0x9C: 'œ', 0x9D: 'š', 0x9E: 'ž', 0xA0: '€',
+}
+
+// pdfDocReverse maps runes back to their PDFDocEncoding byte.
+var pdfDocReverse = func() map[rune]byte {
+	m := make(map[rune]byte, 256)
+	for b := 0; b < 256; b++ {
+		if r, ok := pdfDocRune(byte(b)); ok {
+			m[r] = byte(b)
+		}
+	}
+	return m
+}()
+
+func pdfDocRune(b byte) (rune, bool) {
+	if r, ok := pdfDocHigh[b]; ok {
+		return r, true
+	}
+	if b == 0x7F || b == 0x9F || b == 0xAD {
+		return 0, false
+	}
+	return rune(b), true
+}
+
+// DecodeText converts a PDF text string to a Go string. A leading byte order
+// mark selects UTF-16BE, UTF-16LE or (PDF 2.0) UTF-8; anything else is
+// PDFDocEncoding, even when the bytes happen to be valid UTF-8. Undefined
+// PDFDocEncoding bytes become U+FFFD.
+func DecodeText(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, bomUTF16BE):
+		return decodeUTF16(b[2:], func(p []byte) uint16 { return uint16(p[0])<<8 | uint16(p[1]) })
+	case bytes.HasPrefix(b, bomUTF16LE):
+		return decodeUTF16(b[2:], func(p []byte) uint16 { return uint16(p[1])<<8 | uint16(p[0]) })
+	case bytes.HasPrefix(b, bomUTF8):
+		return string(bytes.ToValidUTF8(b[3:], []byte("�")))
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		r, ok := pdfDocRune(c)
+		if !ok {
+			r = utf8.RuneError
+		}
+		runes[i] = r
+	}
+	return string(runes)
+}
+
+func decodeUTF16(b []byte, unit func([]byte) uint16) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, unit(b[i:]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// EncodeText converts s to a PDF text string: a literal PDFDocEncoding
+// string when every character is representable, otherwise a UTF-16BE hex
+// string with a byte order mark. PDFDocEncoding bytes that DecodeText would
+// take for a byte order mark also fall back to UTF-16BE, so every value
+// round-trips.
+func EncodeText(s string) String {
+	if doc, ok := encodePDFDoc(s); ok && !bytes.HasPrefix(doc, bomUTF16BE) && !bytes.HasPrefix(doc, bomUTF16LE) && !bytes.HasPrefix(doc, bomUTF8) {
+		return String{Bytes: doc}
+	}
+	out := append([]byte(nil), bomUTF16BE...)
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u>>8), byte(u))
+	}
+	return String{Bytes: out, Hex: true}
+}
+
+func encodePDFDoc(s string) ([]byte, bool) {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := pdfDocReverse[r]
+		if !ok {
+			return nil, false
+		}
+		out = append(out, b)
+	}
+	return out, true
+}
@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{name: "ascii", in: []byte("Report"), want: "Report"},
+		{name: "pdfdoc latin1", in: []byte("J\xfcrgen M\xfcller"), want: "Jürgen Müller"},
+		{name: "pdfdoc specials", in: []byte("\x84 \x92 \xa0 \x18"), want: "— ™ € ˘"},
+		{name: "pdfdoc undefined", in: []byte("a\x7fb"), want: "a�b"},
+		{name: "utf-16be", in: []byte("\xfe\xff\x00J\x00\xfc\x65\x87\xd8\x3d\xde\x00"), want: "Jü文😀"},
+		{name: "utf-16le", in: []byte("\xff\xfeJ\x00\xfc\x00"), want: "Jü"},
+		{name: "utf-8 bom", in: []byte("\xef\xbb\xbf\xe6\x96\x87\xe6\x9b\xb8"), want: "文書"},
+		// Without a byte order mark, bytes that are also valid UTF-8 are
+		// still PDFDocEncoding.
+		{name: "unmarked utf-8 bytes", in: []byte("J\xc3\xbcrgen"), want: "JÃ¼rgen"},
+		{name: "pdfdoc a-tilde copyright", in: []byte("\xc3\xa9"), want: "Ã©"},
+	}
+	for _, tc := range tests {
+		if got := DecodeText(tc.in); got != tc.want {
+			t.Fatalf("%s: DecodeText=%q want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want String
+	}{
+		{in: "Report", want: String{Bytes: []byte("Report")}},
+		{in: "Jürgen Müller", want: String{Bytes: []byte("J\xfcrgen M\xfcller")}},
+		{in: "€5 – ﬁne", want: String{Bytes: []byte("\xa05 \x85 \x93ne")}},
+		{in: "文書", want: String{Bytes: []byte("\xfe\xff\x65\x87\x66\xf8"), Hex: true}},
+		{in: "Ã©", want: String{Bytes: []byte("\xc3\xa9")}},
+		// PDFDocEncoding bytes that would read back as a byte order mark.
+		{in: "þÿ", want: String{Bytes: []byte("\xfe\xff\x00\xfe\x00\xff"), Hex: true}},
+		{in: "ï»¿x", want: String{Bytes: []byte("\xfe\xff\x00\xef\x00\xbb\x00\xbf\x00x"), Hex: true}},
+	}
+	for _, tc := range tests {
+		got := EncodeText(tc.in)
+		if !bytes.Equal(got.Bytes, tc.want.Bytes) || got.Hex != tc.want.Hex {
+			t.Fatalf("EncodeText(%q)=%+v want %+v", tc.in, got, tc.want)
+		}
+		if back := DecodeText(got.Bytes); back != tc.in {
+			t.Fatalf("round trip of %q gave %q", tc.in, back)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package pdf
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// maxXRefEntries bounds subsection sizes so corrupt counts cannot force huge
+// allocations.
+const maxXRefEntries = 8 << 20
+
+type xrefEntryType int
+
+const (
+	xrefFree xrefEntryType = iota
+	xrefInUse
+	xrefCompressed
+)
+
+// xrefEntry is one cross-reference record for an object number. Compressed
+// entries live at position index inside object stream number stream.
+type xrefEntry struct {
+	typ    xrefEntryType
+	offset int64
+	gen    int
+	stream int
+	index  int
+}
+
+// xrefSection is one parsed cross-reference section of the /Prev chain.
+// stream is true for /Type /XRef stream sections.
+type xrefSection struct {
+	offset  int64
+	stream  bool
+	entries map[int]xrefEntry
+	trailer Dict
+}
+
+// loadXRef follows startxref and the /Prev chain. Sections are merged newest
+// first, so every object number resolves to its latest revision. Failures are
+// recorded in xrefErr and callers fall back to scanning object headers.
+func (d *Document) loadXRef() {
+	d.xref = map[int]xrefEntry{}
+	start, ok := parseStartXRef(d.content)
+	if !ok {
+		d.xrefErr = errors.New("startxref not found")
+		return
+	}
+	d.xrefShift = d.detectXRefShift(start)
+
+	visited := map[int64]bool{}
+	off := start
+	for {
+		if visited[off] {
+			d.xrefErr = fmt.Errorf("xref /Prev chain loops at offset %d", off)
+			return
+		}
+		visited[off] = true
+
+		sec, err := parseXRefSection(d.content, off+int64(d.xrefShift))
+		if err != nil {
+			d.xrefErr = fmt.Errorf("xref section at offset %d: %w", off, err)
+			return
+		}
+		sec.offset = off
+		if stmOff, ok := sec.trailer.Int("XRefStm"); ok && !sec.stream {
+			d.mergeHybridStream(&sec, stmOff)
+		}
+		d.xrefSections = append(d.xrefSections, sec)
+		for num, e := range sec.entries {
+			if _, seen := d.xref[num]; !seen {
+				d.xref[num] = e
+			}
+		}
+		if d.trailer == nil {
+			d.trailer = sec.trailer
+		}
+
+		prev, ok := sec.trailer.Int("Prev")
+		if !ok {
+			return
+		}
+		off = prev
+	}
+}
+
+// mergeHybridStream adds entries from a hybrid file's /XRefStm stream. The
+// classic table marks those objects free (or omits them) so that pre-1.5
+// readers ignore them; the stream entries take precedence.
+func (d *Document) mergeHybridStream(sec *xrefSection, off int64) {
+	stm, err := parseXRefSection(d.content, off+int64(d.xrefShift))
+	if err != nil || !stm.stream {
+		return
+	}
+	for num, e := range stm.entries {
+		if cur, ok := sec.entries[num]; !ok || cur.typ == xrefFree {
+			sec.entries[num] = e
+		}
+	}
+}
+
+// detectXRefShift handles files whose offsets were computed without leading
+// junk before the %PDF header.
+func (d *Document) detectXRefShift(start int64) int {
+	if d.headerOffset <= 0 || looksLikeXRef(d.content, start) {
+		return 0
+	}
+	if looksLikeXRef(d.content, start+int64(d.headerOffset)) {
+		return d.headerOffset
+	}
+	return 0
+}
+
+func looksLikeXRef(b []byte, off int64) bool {
+	if off < 0 || off >= int64(len(b)) {
+		return false
+	}
+	tok, err := newLexer(b, int(off)).next()
+	if err != nil {
+		return false
+	}
+	if tok.kind == tokKeyword && string(tok.value) == "xref" {
+		return true
+	}
+	_, err = newParser(b, int(off)).parseObjectHeader()
+	return err == nil
+}
+
+// parseXRefSection parses either a classic "xref ... trailer << >>" section
+// or a /Type /XRef stream object at off.
+func parseXRefSection(b []byte, off int64) (xrefSection, error) {
+	if off < 0 || off >= int64(len(b)) {
+		return xrefSection{}, errors.New("offset outside file")
+	}
+	l := newLexer(b, int(off))
+	tok, err := l.next()
+	if err != nil {
+		return xrefSection{}, err
+	}
+	if tok.kind == tokInteger {
+		return parseXRefStream(b, off)
+	}
+	if tok.kind != tokKeyword || string(tok.value) != "xref" {
+		return xrefSection{}, errors.New("xref keyword not found")
+	}
+
+	sec := xrefSection{entries: map[int]xrefEntry{}}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return xrefSection{}, err
+		}
+		if tok.kind == tokKeyword && string(tok.value) == "trailer" {
+			break
+		}
+		if tok.kind != tokInteger {
+			return xrefSection{}, fmt.Errorf("unexpected token at offset %d", tok.pos)
+		}
+		first, err := strconv.Atoi(string(tok.value))
+		if err != nil || first < 0 {
+			return xrefSection{}, fmt.Errorf("invalid subsection start at offset %d", tok.pos)
+		}
+		countTok, err := l.next()
+		if err != nil {
+			return xrefSection{}, err
+		}
+		count, err := strconv.Atoi(string(countTok.value))
+		if countTok.kind != tokInteger || err != nil || count < 0 || count > maxXRefEntries {
+			return xrefSection{}, fmt.Errorf("invalid subsection count at offset %d", countTok.pos)
+		}
+		for i := 0; i < count; i++ {
+			e, err := parseXRefEntry(l)
+			if err != nil {
+				return xrefSection{}, err
+			}
+			if _, dup := sec.entries[first+i]; !dup {
+				sec.entries[first+i] = e
+			}
+		}
+	}
+
+	p := newParser(b, l.pos)
+	obj, err := p.parseObject()
+	if err != nil {
+		return xrefSection{}, fmt.Errorf("trailer: %w", err)
+	}
+	trailer, ok := obj.(Dict)
+	if !ok {
+		return xrefSection{}, errors.New("trailer is not a dictionary")
+	}
+	sec.trailer = trailer
+	return sec, nil
+}
+
+func parseXRefEntry(l *lexer) (xrefEntry, error) {
+	offTok, err := l.next()
+	if err != nil {
+		return xrefEntry{}, err
+	}
+	genTok, err := l.next()
+	if err != nil {
+		return xrefEntry{}, err
+	}
+	typTok, err := l.next()
+	if err != nil {
+		return xrefEntry{}, err
+	}
+	if offTok.kind != tokInteger || genTok.kind != tokInteger || typTok.kind != tokKeyword {
+		return xrefEntry{}, fmt.Errorf("malformed xref entry at offset %d", offTok.pos)
+	}
+	off, err1 := strconv.ParseInt(string(offTok.value), 10, 64)
+	gen, err2 := strconv.Atoi(string(genTok.value))
+	if err1 != nil || err2 != nil {
+		return xrefEntry{}, fmt.Errorf("malformed xref entry at offset %d", offTok.pos)
+	}
+	switch string(typTok.value) {
+	case "n":
+		return xrefEntry{typ: xrefInUse, offset: off, gen: gen}, nil
+	case "f":
+		return xrefEntry{typ: xrefFree, gen: gen}, nil
+	default:
+		return xrefEntry{}, fmt.Errorf("unknown xref entry type %q at offset %d", typTok.value, typTok.pos)
+	}
+}
+
+// parseXRefStream decodes a cross-reference stream (PDF 1.5+). The stream
+// dictionary doubles as the trailer for this section.
+func parseXRefStream(b []byte, off int64) (xrefSection, error) {
+	_, obj, _, err := newParser(b, int(off)).parseIndirectObject()
+	if err != nil {
+		return xrefSection{}, err
+	}
+	s, ok := obj.(Stream)
+	if !ok || s.Dict.TypeName() != "XRef" {
+		return xrefSection{}, errors.New("object is not an xref stream")
+	}
+
+	widths, err := xrefStreamWidths(s.Dict)
+	if err != nil {
+		return xrefSection{}, err
+	}
+	index, err := xrefStreamIndex(s.Dict)
+	if err != nil {
+		return xrefSection{}, err
+	}
+	data, err := decodeStream(s)
+	if err != nil {
+		return xrefSection{}, fmt.Errorf("decode xref stream: %w", err)
+	}
+
+	rowLen := widths[0] + widths[1] + widths[2]
+	if rowLen == 0 {
+		return xrefSection{}, errors.New("xref stream /W has zero width")
+	}
+	sec := xrefSection{stream: true, entries: map[int]xrefEntry{}, trailer: s.Dict}
+	pos := 0
+	for i := 0; i+1 < len(index); i += 2 {
+		first, count := index[i], index[i+1]
+		for j := 0; j < count; j++ {
+			if pos+rowLen > len(data) {
+				return xrefSection{}, errors.New("xref stream data is truncated")
+			}
+			row := data[pos : pos+rowLen]
+			pos += rowLen
+
+			typ := int64(1)
+			if widths[0] > 0 {
+				typ = readField(row[:widths[0]])
+			}
+			f2 := readField(row[widths[0] : widths[0]+widths[1]])
+			f3 := readField(row[widths[0]+widths[1]:])
+			var e xrefEntry
+			switch typ {
+			case 0:
+				e = xrefEntry{typ: xrefFree, gen: int(f3)}
+			case 1:
+				e = xrefEntry{typ: xrefInUse, offset: f2, gen: int(f3)}
+			case 2:
+				e = xrefEntry{typ: xrefCompressed, stream: int(f2), index: int(f3)}
+			default:
+				// Unknown types are reserved and must be treated as null.
+				continue
+			}
+			if _, dup := sec.entries[first+j]; !dup {
+				sec.entries[first+j] = e
+			}
+		}
+	}
+	return sec, nil
+}
+
+func xrefStreamWidths(dict Dict) ([3]int, error) {
+	var w [3]int
+	arr, ok := dict.Get("W").(Array)
+	if !ok || len(arr) < 3 {
+		return w, errors.New("xref stream /W must have three entries")
+	}
+	for i := 0; i < 3; i++ {
+		n, ok := arr[i].(Integer)
+		if !ok || n < 0 || n > 8 {
+			return w, errors.New("xref stream /W entries must be integers in 0..8")
+		}
+		w[i] = int(n)
+	}
+	return w, nil
+}
+
+func xrefStreamIndex(dict Dict) ([]int, error) {
+	arr, ok := dict.Get("Index").(Array)
+	if !ok {
+		size, ok := dict.Int("Size")
+		if !ok || size < 0 {
+			return nil, errors.New("xref stream /Size is missing")
+		}
+		return []int{0, int(size)}, nil
+	}
+	if len(arr)%2 != 0 {
+		return nil, errors.New("xref stream /Index must contain pairs")
+	}
+	out := make([]int, len(arr))
+	for i, v := range arr {
+		n, ok := v.(Integer)
+		if !ok || n < 0 || n > maxXRefEntries {
+			return nil, errors.New("xref stream /Index entries must be non-negative integers")
+		}
+		out[i] = int(n)
+	}
+	return out, nil
+}
+
+func readField(b []byte) int64 {
+	var n int64
+	for _, c := range b {
+		n = n<<8 | int64(c)
+	}
+	return n
+}
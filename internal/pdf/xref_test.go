@@ -0,0 +1,175 @@
+package pdf
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestXRefChainResolvesNewestRevision(t *testing.T) {
+	t.Parallel()
+
+	src := buildPDF(
+		testRevision{
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+				{num: 2, body: "<< /Type /Pages /Kids [] /Count 0 >>"},
+				{num: 3, body: "<< /Title (first) >>"},
+			},
+			trailer: "/Root 1 0 R /Info 3 0 R",
+		},
+		testRevision{
+			objects: []testObject{{num: 3, body: "<< /Title (second) >>"}},
+			trailer: "/Root 1 0 R /Info 3 0 R",
+		},
+		testRevision{
+			objects: []testObject{
+				{num: 3, body: "<< /Title (third) >>"},
+				{num: 4, body: "(added)"},
+			},
+			trailer: "/Root 1 0 R /Info 3 0 R",
+		},
+	)
+	doc, err := ParseBytes("chain.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+	if got := len(doc.xrefSections); got != 3 {
+		t.Fatalf("sections=%d want 3", got)
+	}
+
+	info, err := doc.Object(Ref{Num: 3})
+	if err != nil {
+		t.Fatalf("Object(3): %v", err)
+	}
+	title := info.(Dict).Get("Title").(String)
+	if string(title.Bytes) != "third" {
+		t.Fatalf("Title=%q want third", title.Bytes)
+	}
+	if got := doc.MaxObjectNumber(); got != 4 {
+		t.Fatalf("MaxObjectNumber()=%d want 4", got)
+	}
+}
+
+func TestXRefIgnoresUnreferencedObjects(t *testing.T) {
+	t.Parallel()
+
+	src := buildPDF(testRevision{
+		objects: []testObject{
+			{num: 1, body: "<< /Type /Catalog >>"},
+			{num: 2, body: "(live)"},
+		},
+		trailer: "/Root 1 0 R",
+	})
+	// Garbage appended after %%EOF redefines object 2 but is not indexed.
+	src = append(src, []byte("2 0 obj\n(stale)\nendobj\n")...)
+
+	doc, err := ParseBytes("garbage.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if got := string(obj.(String).Bytes); got != "live" {
+		t.Fatalf("Object(2)=%q want live", got)
+	}
+}
+
+func TestXRefFreedObjectResolvesToNull(t *testing.T) {
+	t.Parallel()
+
+	src := buildPDF(
+		testRevision{
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog >>"},
+				{num: 2, body: "(doomed)"},
+			},
+			trailer: "/Root 1 0 R",
+		},
+		testRevision{free: []int{2}, trailer: "/Root 1 0 R"},
+	)
+	doc, err := ParseBytes("freed.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	obj, err := doc.Resolve(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Resolve(2): %v", err)
+	}
+	if _, ok := obj.(Null); !ok {
+		t.Fatalf("Resolve(2)=%#v want Null", obj)
+	}
+}
+
+func TestXRefBrokenChainFallsBackToScan(t *testing.T) {
+	t.Parallel()
+
+	doc, err := Open(fixturePath("minimal.pdf"))
+	if err != nil {
+		t.Fatalf("Open(minimal.pdf): %v", err)
+	}
+	if doc.XRefError() == nil {
+		t.Fatalf("minimal.pdf has bogus offsets; expected xref error")
+	}
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	root, err := doc.Resolve(trailer.Get("Root"))
+	if err != nil {
+		t.Fatalf("Resolve(Root): %v", err)
+	}
+	if d, ok := root.(Dict); !ok || d.TypeName() != "Catalog" {
+		t.Fatalf("unexpected root %#v", root)
+	}
+}
+
+func TestXRefWrongEntryOffsetFallsBackToScan(t *testing.T) {
+	t.Parallel()
+
+	src := buildPDF(testRevision{
+		objects: []testObject{
+			{num: 1, body: "<< /Type /Catalog >>"},
+			{num: 2, body: "(two)"},
+		},
+		trailer: "/Root 1 0 R",
+	})
+	doc, err := ParseBytes("shifted.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	e := doc.xref[2]
+	e.offset -= 3
+	doc.xref[2] = e
+
+	obj, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if got := string(obj.(String).Bytes); got != "two" {
+		t.Fatalf("Object(2)=%q want two", got)
+	}
+}
+
+func TestXRefPrevLoopIsReported(t *testing.T) {
+	t.Parallel()
+
+	src := []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+	xrefOff := len(src)
+	src = append(src, []byte("xref\n0 2\n0000000000 65535 f \n0000000009 00000 n \ntrailer\n<< /Size 2 /Root 1 0 R /Prev "+strconv.Itoa(xrefOff)+" >>\nstartxref\n"+strconv.Itoa(xrefOff)+"\n%%EOF\n")...)
+
+	doc, err := ParseBytes("loop.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if doc.XRefError() == nil {
+		t.Fatalf("expected loop error")
+	}
+	if _, err := doc.Object(Ref{Num: 1}); err != nil {
+		t.Fatalf("Object(1): %v", err)
+	}
+}
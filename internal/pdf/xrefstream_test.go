@@ -0,0 +1,176 @@
+package pdf
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestXRefStreamChain(t *testing.T) {
+	t.Parallel()
+
+	src := buildXRefStreamPDF(
+		testRevision{
+			objects: []testObject{
+				{num: 1, body: "<< /Type /Catalog /Pages 2 0 R >>"},
+				{num: 2, body: "<< /Type /Pages /Kids [] /Count 0 >>"},
+				{num: 3, body: "<< /Title (first) >>"},
+			},
+			trailer: "/Root 1 0 R /Info 3 0 R /ID [<01> <01>]",
+		},
+		testRevision{
+			objects: []testObject{{num: 3, body: "<< /Title (second) >>"}},
+			trailer: "/Root 1 0 R /Info 3 0 R /ID [<01> <02>]",
+		},
+	)
+	doc, err := ParseBytes("xrefstm.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+	if len(doc.xrefSections) != 2 || !doc.xrefSections[0].stream {
+		t.Fatalf("expected two xref stream sections, got %+v", doc.xrefSections)
+	}
+
+	trailer, err := doc.Trailer()
+	if err != nil {
+		t.Fatalf("Trailer: %v", err)
+	}
+	if _, ok := trailer.Ref("Root"); !ok {
+		t.Fatalf("trailer missing /Root: %#v", trailer)
+	}
+	info, err := doc.Resolve(trailer.Get("Info"))
+	if err != nil {
+		t.Fatalf("Resolve(Info): %v", err)
+	}
+	if got := string(info.(Dict).Get("Title").(String).Bytes); got != "second" {
+		t.Fatalf("Title=%q want second", got)
+	}
+	if doc.Encrypted() {
+		t.Fatalf("unexpected encrypted flag")
+	}
+}
+
+func TestXRefStreamEncryptDetection(t *testing.T) {
+	t.Parallel()
+
+	src := buildXRefStreamPDF(testRevision{
+		objects: []testObject{
+			{num: 1, body: "<< /Type /Catalog >>"},
+			{num: 2, body: "<< /Filter /Standard /V 2 /R 3 >>"},
+		},
+		trailer: "/Root 1 0 R /Encrypt 2 0 R",
+	})
+	doc, err := ParseBytes("enc.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if !doc.Encrypted() {
+		t.Fatalf("expected /Encrypt in xref stream dictionary to be detected")
+	}
+}
+
+func TestXRefStreamIndexSubsections(t *testing.T) {
+	t.Parallel()
+
+	body := "%PDF-1.5\n"
+	off1 := len(body)
+	body += "1 0 obj\n<< /Type /Catalog >>\nendobj\n"
+	off7 := len(body)
+	body += "7 0 obj\n(seven)\nendobj\n"
+	xrefOff := len(body)
+
+	// /W [0 2 1]: the type field is omitted and defaults to 1.
+	raw := []byte{
+		byte(off1 >> 8), byte(off1), 0,
+		byte(off7 >> 8), byte(off7), 0,
+		byte(xrefOff >> 8), byte(xrefOff), 0,
+	}
+	body += fmt.Sprintf("8 0 obj\n<< /Type /XRef /Size 9 /W [0 2 1] /Index [1 1 7 2] /Root 1 0 R /Length %d >>\nstream\n", len(raw))
+	src := append([]byte(body), raw...)
+	src = append(src, []byte(fmt.Sprintf("\nendstream\nendobj\nstartxref\n%d\n%%%%EOF\n", xrefOff))...)
+
+	doc, err := ParseBytes("index.pdf", src)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 7})
+	if err != nil {
+		t.Fatalf("Object(7): %v", err)
+	}
+	if got := string(obj.(String).Bytes); got != "seven" {
+		t.Fatalf("Object(7)=%q", got)
+	}
+	if got := doc.MaxObjectNumber(); got != 8 {
+		t.Fatalf("MaxObjectNumber()=%d want 8", got)
+	}
+}
+
+func TestHybridXRefStm(t *testing.T) {
+	t.Parallel()
+
+	body := "%PDF-1.5\n"
+	off1 := len(body)
+	body += "1 0 obj\n<< /Type /Catalog >>\nendobj\n"
+	off2 := len(body)
+	body += "2 0 obj\n(hidden from 1.4 readers)\nendobj\n"
+	stmOff := len(body)
+	raw := []byte{1, byte(off2 >> 8), byte(off2), 0}
+	body += fmt.Sprintf("3 0 obj\n<< /Type /XRef /Size 4 /W [1 2 1] /Index [2 1] /Length %d >>\nstream\n", len(raw))
+	body += string(raw) + "\nendstream\nendobj\n"
+	xrefOff := len(body)
+	body += "xref\n0 4\n0000000000 65535 f \n" +
+		fmt.Sprintf("%010d 00000 n \n", off1) +
+		"0000000000 00001 f \n" +
+		fmt.Sprintf("%010d 00000 n \n", stmOff) +
+		fmt.Sprintf("trailer\n<< /Size 4 /Root 1 0 R /XRefStm %d >>\nstartxref\n%d\n%%%%EOF\n", stmOff, xrefOff)
+
+	doc, err := ParseBytes("hybrid.pdf", []byte(body))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if err := doc.XRefError(); err != nil {
+		t.Fatalf("XRefError: %v", err)
+	}
+	obj, err := doc.Object(Ref{Num: 2})
+	if err != nil {
+		t.Fatalf("Object(2): %v", err)
+	}
+	if got := string(obj.(String).Bytes); got != "hidden from 1.4 readers" {
+		t.Fatalf("Object(2)=%q", got)
+	}
+}
+
+func TestPNGPredictors(t *testing.T) {
+	t.Parallel()
+
+	// Two rows of three bytes, one per PNG filter type, decoding to 1 2 3 / 4 6 8.
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{name: "none", in: []byte{0, 1, 2, 3, 0, 4, 6, 8}},
+		{name: "sub", in: []byte{1, 1, 1, 1, 1, 4, 2, 2}},
+		{name: "up", in: []byte{2, 1, 2, 3, 2, 3, 4, 5}},
+		{name: "average", in: []byte{3, 1, 2, 2, 3, 4, 3, 4}},
+		{name: "paeth", in: []byte{4, 1, 1, 1, 4, 3, 2, 2}},
+	}
+	want := []byte{1, 2, 3, 4, 6, 8}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := pngUnpredict(tc.in, 3, 1)
+			if err != nil {
+				t.Fatalf("pngUnpredict: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Fatalf("pngUnpredict=%v want %v", got, want)
+			}
+		})
+	}
+}
@@ -24,8 +24,8 @@ func TestSaveGetListDelete(t *testing.T) {
 		Name: "release",
 		Note: "release defaults",
 		Metadata: model.MetadataPatch{
-			Author: strPtr("Docs Team"),
-			Title:  strPtr("Release Notes"),
+			Authors: model.List{"Docs Team"},
+			Title:   strPtr("Release Notes"),
 		},
 	}
 	saved, err := store.Save(ctx, in, false)
@@ -43,8 +43,8 @@ func TestSaveGetListDelete(t *testing.T) {
 	if got.Note != in.Note {
 		t.Fatalf("note mismatch: %q", got.Note)
 	}
-	if got.Metadata.Author == nil || *got.Metadata.Author != "Docs Team" {
-		t.Fatalf("author mismatch: %#v", got.Metadata.Author)
+	if len(got.Metadata.Authors) != 1 || got.Metadata.Authors[0] != "Docs Team" {
+		t.Fatalf("author mismatch: %#v", got.Metadata.Authors)
 	}
 
 	list, err := store.List(ctx)
@@ -15,11 +15,22 @@ func ShowRequest(req model.ShowRequest) error {
 	return nil
 }
 
+// HistoryRequest validates revision listing input.
+func HistoryRequest(req model.HistoryRequest) error {
+	if strings.TrimSpace(req.InputPath) == "" {
+		return validationError("input path is required")
+	}
+	return nil
+}
+
 // SetRequest validates write destination and metadata changes.
 func SetRequest(req model.SetRequest) error {
 	if err := ioOptions(req.IO); err != nil {
 		return err
 	}
+	if err := writeOptions(req.Write); err != nil {
+		return err
+	}
 	if err := metadataPatch(req.Changes, req.Exec.Strict); err != nil {
 		return err
 	}
@@ -34,6 +45,9 @@ func UnsetRequest(req model.UnsetRequest) error {
 	if err := ioOptions(req.IO); err != nil {
 		return err
 	}
+	if err := writeOptions(req.Write); err != nil {
+		return err
+	}
 	if req.All && len(req.Fields) > 0 {
 		return validationError("--all cannot be combined with explicit fields")
 	}
@@ -47,6 +61,25 @@ func UnsetRequest(req model.UnsetRequest) error {
 	return nil
 }
 
+// RevertRequest validates write destination and revision selection.
+func RevertRequest(req model.RevertRequest) error {
+	if err := ioOptions(req.IO); err != nil {
+		return err
+	}
+	if (req.To > 0) == (req.Steps > 0) {
+		return validationError("exactly one of --to or --steps is required")
+	}
+	if req.To < 0 || req.Steps < 0 {
+		return validationError("--to and --steps must be positive")
+	}
+	return nil
+}
+
+// RepairRequest validates the write destination.
+func RepairRequest(req model.RepairRequest) error {
+	return ioOptions(req.IO)
+}
+
 // TemplateSaveRequest validates persisted template payloads.
 func TemplateSaveRequest(req model.TemplateSaveRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
@@ -66,12 +99,15 @@ func TemplateApplyRequest(req model.TemplateApplyRequest) error {
 	if strings.TrimSpace(req.Name) == "" {
 		return validationError("template name is required")
 	}
-	return ioOptions(req.IO)
+	if err := ioOptions(req.IO); err != nil {
+		return err
+	}
+	return writeOptions(req.Write)
 }
 
 // HasAnyPatchField returns true when at least one metadata field is explicitly present.
 func HasAnyPatchField(patch model.MetadataPatch) bool {
-	return patch.Title != nil || patch.Author != nil || patch.Subject != nil || patch.Keywords != nil || patch.Creator != nil || patch.Producer != nil || patch.CreationDate != nil || patch.ModDate != nil
+	return patch.Title != nil || patch.Authors != nil || patch.Subject != nil || patch.Keywords != nil || patch.Creator != nil || patch.Producer != nil || patch.CreationDate != nil || patch.ModDate != nil
 }
 
 func ioOptions(io model.IOOptions) error {
@@ -88,6 +124,19 @@ func ioOptions(io model.IOOptions) error {
 	return nil
 }
 
+func writeOptions(opts model.WriteOptions) error {
+	switch opts.Linearized {
+	case "", model.LinearizedWarn, model.LinearizedRefuse:
+		return nil
+	case model.LinearizedRelinearize:
+		if opts.ObjectStreams {
+			return validationError("--object-streams cannot be combined with --linearized relinearize; linearized files use classic xref tables")
+		}
+		return nil
+	}
+	return validationError("linearized policy must be one of warn, refuse or relinearize, got %q", opts.Linearized)
+}
+
 func metadataPatch(patch model.MetadataPatch, strict bool) error {
 	if patch.CreationDate != nil {
 		if err := dateValue(*patch.CreationDate, strict); err != nil {
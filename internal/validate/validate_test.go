@@ -71,6 +71,14 @@ func TestSetRequestValidation(t *testing.T) {
 	bad := model.SetRequest{IO: model.IOOptions{InputPath: "in.pdf", InPlace: true}}
 	assertValidationError(t, SetRequest(bad))
 
+	badPolicy := ok
+	badPolicy.Write.Linearized = "ignore"
+	assertValidationError(t, SetRequest(badPolicy))
+
+	relinearizePacked := ok
+	relinearizePacked.Write = model.WriteOptions{Linearized: model.LinearizedRelinearize, ObjectStreams: true}
+	assertValidationError(t, SetRequest(relinearizePacked))
+
 	dateLoose := "2026/02/17"
 	lenient := model.SetRequest{
 		IO: model.IOOptions{InputPath: "in.pdf", OutputPath: "out.pdf"},
@@ -111,11 +119,29 @@ func TestUnsetRequestValidation(t *testing.T) {
 	assertValidationError(t, UnsetRequest(none))
 }
 
+func TestRevertRequestValidation(t *testing.T) {
+	t.Parallel()
+
+	ok := model.RevertRequest{IO: model.IOOptions{InputPath: "in.pdf", InPlace: true}, Steps: 1}
+	if err := RevertRequest(ok); err != nil {
+		t.Fatalf("RevertRequest unexpected error: %v", err)
+	}
+
+	for _, bad := range []model.RevertRequest{
+		{IO: model.IOOptions{InputPath: "in.pdf", InPlace: true}},
+		{IO: model.IOOptions{InputPath: "in.pdf", InPlace: true}, To: 1, Steps: 1},
+		{IO: model.IOOptions{InputPath: "in.pdf", InPlace: true}, To: -1, Steps: 1},
+		{IO: model.IOOptions{InputPath: "in.pdf"}, To: 1},
+	} {
+		assertValidationError(t, RevertRequest(bad))
+	}
+}
+
 func TestTemplateValidation(t *testing.T) {
 	t.Parallel()
 
 	author := "alice"
-	save := model.TemplateSaveRequest{Name: "release", Metadata: model.MetadataPatch{Author: &author}}
+	save := model.TemplateSaveRequest{Name: "release", Metadata: model.MetadataPatch{Authors: model.List{author}}}
 	if err := TemplateSaveRequest(save); err != nil {
 		t.Fatalf("TemplateSaveRequest unexpected error: %v", err)
 	}
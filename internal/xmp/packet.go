@@ -3,9 +3,7 @@ package xmp
 import (
 	"bytes"
 	"encoding/xml"
-	"errors"
-	"fmt"
-	"io"
+	"strconv"
 	"strings"
 
 	"pdfmeta/internal/model"
@@ -20,23 +18,42 @@ const (
 
 // Marshal converts canonical metadata into an XMP packet.
 func Marshal(m model.Metadata) ([]byte, error) {
+	return marshal(m, nil)
+}
+
+// MarshalPDFA is Marshal for a PDF/A document: the packet also carries the
+// pdfaid identification.
+func MarshalPDFA(m model.Metadata, id model.PDFA) ([]byte, error) {
+	return marshal(m, &id)
+}
+
+func marshal(m model.Metadata, id *model.PDFA) ([]byte, error) {
 	var b strings.Builder
 	b.WriteString(xpacketBegin + "\n")
-	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="pdfmeta">` + "\n")
-	b.WriteString(`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="` + xNS + `" x:xmptk="pdfmeta">` + "\n")
+	b.WriteString(`<rdf:RDF xmlns:rdf="` + rdfNS + `">` + "\n")
 	b.WriteString(`<rdf:Description rdf:about=""`)
-	b.WriteString(` xmlns:dc="http://purl.org/dc/elements/1.1/"`)
-	b.WriteString(` xmlns:pdf="http://ns.adobe.com/pdf/1.3/"`)
-	b.WriteString(` xmlns:xmp="http://ns.adobe.com/xap/1.0/">` + "\n")
+	b.WriteString(` xmlns:dc="` + dcNS + `"`)
+	b.WriteString(` xmlns:pdf="` + pdfNS + `"`)
+	b.WriteString(` xmlns:xmp="` + xapNS + `"`)
+	if id != nil {
+		b.WriteString(` xmlns:pdfaid="` + pdfaidNS + `"`)
+	}
+	b.WriteString(">\n")
 
 	writeLangAlt(&b, "dc:title", m.Title)
-	writeSeq(&b, "dc:creator", m.Author)
+	writeArray(&b, "dc:creator", "rdf:Seq", m.Authors)
 	writeLangAlt(&b, "dc:description", m.Subject)
-	writeValue(&b, "pdf:Keywords", m.Keywords)
+	writeArray(&b, "dc:subject", "rdf:Bag", m.Keywords)
+	writeValue(&b, "pdf:Keywords", model.JoinKeywords(m.Keywords))
 	writeValue(&b, "xmp:CreatorTool", m.Creator)
 	writeValue(&b, "pdf:Producer", m.Producer)
 	writeValue(&b, "xmp:CreateDate", m.CreationDate)
 	writeValue(&b, "xmp:ModifyDate", m.ModDate)
+	if id != nil {
+		writeValue(&b, "pdfaid:part", strconv.Itoa(id.Part))
+		writeValue(&b, "pdfaid:conformance", id.Conformance)
+	}
 
 	b.WriteString(`</rdf:Description>` + "\n")
 	b.WriteString(`</rdf:RDF>` + "\n")
@@ -54,13 +71,17 @@ func writeLangAlt(b *strings.Builder, key, value string) {
 	b.WriteString("</rdf:li></rdf:Alt></" + key + ">\n")
 }
 
-func writeSeq(b *strings.Builder, key, value string) {
-	if value == "" {
+func writeArray(b *strings.Builder, key, array string, items []string) {
+	if len(items) == 0 {
 		return
 	}
-	b.WriteString("<" + key + "><rdf:Seq><rdf:li>")
-	xmlEscape(b, value)
-	b.WriteString("</rdf:li></rdf:Seq></" + key + ">\n")
+	b.WriteString("<" + key + "><" + array + ">")
+	for _, v := range items {
+		b.WriteString("<rdf:li>")
+		xmlEscape(b, v)
+		b.WriteString("</rdf:li>")
+	}
+	b.WriteString("</" + array + "></" + key + ">\n")
 }
 
 func writeValue(b *strings.Builder, key, value string) {
@@ -79,82 +100,27 @@ func xmlEscape(b *strings.Builder, s string) {
 }
 
 // Unmarshal parses an XMP packet and maps known fields into canonical metadata.
+// Keywords come from the dc:subject bag, or from pdf:Keywords in packets
+// without one.
 func Unmarshal(packet []byte) (model.Metadata, error) {
-	dec := xml.NewDecoder(bytes.NewReader(packet))
-	var (
-		stack  []string
-		meta   model.Metadata
-		sawXMP bool
-	)
-
-	for {
-		tok, err := dec.Token()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				break
-			}
-			return model.Metadata{}, fmt.Errorf("decode xml: %w", err)
-		}
-
-		switch t := tok.(type) {
-		case xml.StartElement:
-			stack = append(stack, t.Name.Local)
-			if t.Name.Local == "xmpmeta" {
-				sawXMP = true
-			}
-		case xml.EndElement:
-			if len(stack) > 0 {
-				stack = stack[:len(stack)-1]
-			}
-		case xml.CharData:
-			value := strings.TrimSpace(string(t))
-			if value == "" {
-				continue
-			}
-			switch {
-			case hasSuffix(stack, "title", "Alt", "li"):
-				if meta.Title == "" {
-					meta.Title = value
-				}
-			case hasSuffix(stack, "creator", "Seq", "li"):
-				if meta.Author == "" {
-					meta.Author = value
-				}
-			case hasSuffix(stack, "description", "Alt", "li"):
-				if meta.Subject == "" {
-					meta.Subject = value
-				}
-			case hasSuffix(stack, "Keywords"):
-				meta.Keywords = value
-			case hasSuffix(stack, "CreatorTool"):
-				meta.Creator = value
-			case hasSuffix(stack, "Producer"):
-				meta.Producer = value
-			case hasSuffix(stack, "CreateDate"):
-				meta.CreationDate = value
-			case hasSuffix(stack, "ModifyDate"):
-				meta.ModDate = value
-			}
-		}
-	}
-
-	if !sawXMP {
-		return model.Metadata{}, errors.New("xmp packet not found")
+	tree, err := parsePacket(packet)
+	if err != nil {
+		return model.Metadata{}, err
 	}
-	return meta, nil
-}
-
-func hasSuffix(stack []string, want ...string) bool {
-	if len(stack) < len(want) {
-		return false
+	meta := model.Metadata{
+		Title:        first(dcTitle.read(tree)),
+		Authors:      dcCreator.read(tree),
+		Subject:      first(dcDescription.read(tree)),
+		Keywords:     dcSubject.read(tree),
+		Creator:      first(xmpCreatorTool.read(tree)),
+		Producer:     first(pdfProducer.read(tree)),
+		CreationDate: first(xmpCreateDate.read(tree)),
+		ModDate:      first(xmpModifyDate.read(tree)),
 	}
-	start := len(stack) - len(want)
-	for i := range want {
-		if stack[start+i] != want[i] {
-			return false
-		}
+	if meta.Keywords == nil {
+		meta.Keywords = model.SplitKeywords(first(pdfKeywords.read(tree)))
 	}
-	return true
+	return meta, nil
 }
 
 // Extract returns the first XMP packet found in PDF bytes.
@@ -17,9 +17,9 @@ func fixturePath(name string) string {
 func TestMarshalUnmarshalRoundTrip(t *testing.T) {
 	in := model.Metadata{
 		Title:        "Doc",
-		Author:       "Author",
+		Authors:      []string{"Author", "Second Author"},
 		Subject:      "Subject",
-		Keywords:     "a,b",
+		Keywords:     []string{"a", "b"},
 		Creator:      "pdfmeta",
 		Producer:     "go-test",
 		CreationDate: "2026-02-17T00:00:00Z",
@@ -33,7 +33,7 @@ func TestMarshalUnmarshalRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unmarshal: %v", err)
 	}
-	if out != in {
+	if !out.Equal(in) {
 		t.Fatalf("roundtrip mismatch: got %#v want %#v", out, in)
 	}
 }
@@ -0,0 +1,57 @@
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"pdfmeta/internal/model"
+)
+
+// pdfaidNS is the PDF/A identification schema namespace.
+const pdfaidNS = "http://www.aiim.org/pdfa/ns/id/"
+
+// PDFA reads the PDF/A identification from an XMP packet. The properties
+// may be written as elements or as attributes of rdf:Description. ok is
+// false when no valid pdfaid:part is declared.
+func PDFA(packet []byte) (id model.PDFA, ok bool) {
+	dec := xml.NewDecoder(bytes.NewReader(packet))
+	var field string
+	set := func(local, value string) {
+		value = strings.TrimSpace(value)
+		switch local {
+		case "part":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				id.Part = n
+			}
+		case "conformance":
+			id.Conformance = value
+		}
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			field = ""
+			if t.Name.Space == pdfaidNS {
+				field = t.Name.Local
+			}
+			for _, attr := range t.Attr {
+				if attr.Name.Space == pdfaidNS {
+					set(attr.Name.Local, attr.Value)
+				}
+			}
+		case xml.EndElement:
+			field = ""
+		case xml.CharData:
+			if field != "" {
+				set(field, string(t))
+			}
+		}
+	}
+	return id, id.Part > 0
+}
@@ -0,0 +1,66 @@
+package xmp
+
+import (
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+func TestPDFAElementsAndAttributes(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet string
+		want   model.PDFA
+		ok     bool
+	}{
+		{
+			name: "elements",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+				`<rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/"><pdfaid:part>2</pdfaid:part><pdfaid:conformance>B</pdfaid:conformance></rdf:Description>` +
+				`</rdf:RDF></x:xmpmeta>`,
+			want: model.PDFA{Part: 2, Conformance: "B"},
+			ok:   true,
+		},
+		{
+			name: "attributes",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+				`<rdf:Description rdf:about="" xmlns:id="http://www.aiim.org/pdfa/ns/id/" id:part="1" id:conformance="A"/>` +
+				`</rdf:RDF></x:xmpmeta>`,
+			want: model.PDFA{Part: 1, Conformance: "A"},
+			ok:   true,
+		},
+		{
+			name:   "other namespace",
+			packet: `<x:xmpmeta xmlns:x="adobe:ns:meta/"><p:part xmlns:p="urn:other">2</p:part></x:xmpmeta>`,
+		},
+	}
+	for _, tc := range tests {
+		got, ok := PDFA([]byte(tc.packet))
+		if ok != tc.ok || got != tc.want {
+			t.Fatalf("%s: PDFA()=%+v,%v want %+v,%v", tc.name, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestMarshalPDFAKeepsIdentification(t *testing.T) {
+	in := model.Metadata{Title: "Archived"}
+	packet, err := MarshalPDFA(in, model.PDFA{Part: 2, Conformance: "B"})
+	if err != nil {
+		t.Fatalf("MarshalPDFA: %v", err)
+	}
+	if id, ok := PDFA(packet); !ok || id != (model.PDFA{Part: 2, Conformance: "B"}) {
+		t.Fatalf("PDFA()=%+v,%v", id, ok)
+	}
+	out, err := Unmarshal(packet)
+	if err != nil || !out.Equal(in) {
+		t.Fatalf("Unmarshal=%+v,%v want %+v", out, err, in)
+	}
+
+	plain, err := Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if _, ok := PDFA(plain); ok {
+		t.Fatalf("Marshal declared PDF/A")
+	}
+}
@@ -0,0 +1,349 @@
+package xmp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Namespaces of the properties pdfmeta reads and writes.
+const (
+	xNS   = "adobe:ns:meta/"
+	rdfNS = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmlNS = "http://www.w3.org/XML/1998/namespace"
+	dcNS  = "http://purl.org/dc/elements/1.1/"
+	pdfNS = "http://ns.adobe.com/pdf/1.3/"
+	xapNS = "http://ns.adobe.com/xap/1.0/"
+)
+
+// node is an element of a parsed packet. Element and attribute names keep
+// the prefix they were written with in Name.Space; lookup resolves it.
+// Children are *node, xml.CharData, xml.Comment, xml.ProcInst or
+// xml.Directive, in document order.
+type node struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []any
+	parent   *node
+}
+
+// packetTree is a parsed packet: the root element and the bytes around it
+// (the xpacket wrapper and its padding), which are written back verbatim.
+type packetTree struct {
+	head, tail []byte
+	root       *node
+}
+
+// parsePacket builds the element tree of an XMP packet. The root is the
+// first top-level element; it must be or contain x:xmpmeta or rdf:RDF.
+func parsePacket(packet []byte) (*packetTree, error) {
+	dec := xml.NewDecoder(bytes.NewReader(packet))
+	tree := &packetTree{}
+	var stack []*node
+	for {
+		start := dec.InputOffset()
+		tok, err := dec.RawToken()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("decode xml: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name, attrs: append([]xml.Attr(nil), t.Attr...)}
+			if len(stack) > 0 {
+				n.parent = stack[len(stack)-1]
+				n.parent.children = append(n.parent.children, n)
+			} else if tree.root == nil {
+				tree.root = n
+				tree.head = append([]byte(nil), packet[:start]...)
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) == 0 || stack[len(stack)-1].name != t.Name {
+				return nil, fmt.Errorf("decode xml: unexpected end element </%s>", qname(t.Name))
+			}
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 && tree.tail == nil {
+				tree.tail = append([]byte{}, packet[dec.InputOffset():]...)
+			}
+		default:
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, xml.CopyToken(tok))
+			}
+		}
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("decode xml: unclosed element <%s>", qname(stack[len(stack)-1].name))
+	}
+	if tree.root == nil || tree.find(xNS, "xmpmeta") == nil && tree.rdf() == nil {
+		return nil, errors.New("xmp packet not found")
+	}
+	return tree, nil
+}
+
+// find returns the first element local in namespace ns, in document order.
+func (t *packetTree) find(ns, local string) *node {
+	var walk func(n *node) *node
+	walk = func(n *node) *node {
+		if n.is(ns, local) {
+			return n
+		}
+		for _, c := range n.elements() {
+			if found := walk(c); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return walk(t.root)
+}
+
+func (t *packetTree) rdf() *node {
+	return t.find(rdfNS, "RDF")
+}
+
+// descriptions returns the rdf:Description elements of the packet.
+func (t *packetTree) descriptions() []*node {
+	rdf := t.rdf()
+	if rdf == nil {
+		return nil
+	}
+	var out []*node
+	for _, c := range rdf.elements() {
+		if c.is(rdfNS, "Description") {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// bytes serializes the tree. Markup around the root element is kept as
+// read; inside it, names keep their prefixes and text is re-escaped.
+func (t *packetTree) bytes() []byte {
+	var b bytes.Buffer
+	b.Write(t.head)
+	t.root.write(&b)
+	b.Write(t.tail)
+	return b.Bytes()
+}
+
+func (n *node) write(b *bytes.Buffer) {
+	b.WriteString("<" + qname(n.name))
+	for _, a := range n.attrs {
+		b.WriteString(" " + qname(a.Name) + `="`)
+		escape(b, a.Value, true)
+		b.WriteString(`"`)
+	}
+	if len(n.children) == 0 {
+		b.WriteString("/>")
+		return
+	}
+	b.WriteString(">")
+	for _, c := range n.children {
+		switch c := c.(type) {
+		case *node:
+			c.write(b)
+		case xml.CharData:
+			escape(b, string(c), false)
+		case xml.Comment:
+			b.WriteString("<!--" + string(c) + "-->")
+		case xml.ProcInst:
+			b.WriteString("<?" + c.Target)
+			if len(c.Inst) > 0 {
+				b.WriteString(" " + string(c.Inst))
+			}
+			b.WriteString("?>")
+		case xml.Directive:
+			b.WriteString("<!" + string(c) + ">")
+		}
+	}
+	b.WriteString("</" + qname(n.name) + ">")
+}
+
+// escape writes s as XML text or as a double-quoted attribute value.
+// Whitespace that a parser would normalize is written as a character
+// reference so it survives another round trip.
+func escape(b *bytes.Buffer, s string, attr bool) {
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>' && !attr:
+			b.WriteString("&gt;")
+		case r == '"' && attr:
+			b.WriteString("&quot;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		case (r == '\n' || r == '\t') && attr:
+			fmt.Fprintf(b, "&#x%X;", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func qname(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// lookup resolves a prefix to its namespace URI in the scope of n.
+func (n *node) lookup(prefix string) string {
+	if prefix == "xml" {
+		return xmlNS
+	}
+	for e := n; e != nil; e = e.parent {
+		for _, a := range e.attrs {
+			if prefix == "" && a.Name.Space == "" && a.Name.Local == "xmlns" || prefix != "" && a.Name.Space == "xmlns" && a.Name.Local == prefix {
+				return a.Value
+			}
+		}
+	}
+	return ""
+}
+
+// is reports whether n is the element local in namespace ns.
+func (n *node) is(ns, local string) bool {
+	return n.name.Local == local && n.lookup(n.name.Space) == ns
+}
+
+// attrIndex returns the index of n's attribute local in namespace ns, or -1.
+// Unprefixed attributes have no namespace.
+func (n *node) attrIndex(ns, local string) int {
+	for i, a := range n.attrs {
+		if a.Name.Local == local && a.Name.Space != "" && a.Name.Space != "xmlns" && n.lookup(a.Name.Space) == ns {
+			return i
+		}
+	}
+	return -1
+}
+
+func (n *node) attr(ns, local string) (string, bool) {
+	if i := n.attrIndex(ns, local); i >= 0 {
+		return n.attrs[i].Value, true
+	}
+	return "", false
+}
+
+func (n *node) removeAttr(ns, local string) {
+	if i := n.attrIndex(ns, local); i >= 0 {
+		n.attrs = append(n.attrs[:i], n.attrs[i+1:]...)
+	}
+}
+
+func (n *node) elements() []*node {
+	var out []*node
+	for _, c := range n.children {
+		if e, ok := c.(*node); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// child returns n's first child element local in namespace ns.
+func (n *node) child(ns, local string) *node {
+	for _, c := range n.elements() {
+		if c.is(ns, local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// text returns the character data directly inside n, trimmed.
+func (n *node) text() string {
+	var b strings.Builder
+	for _, c := range n.children {
+		if cd, ok := c.(xml.CharData); ok {
+			b.Write(cd)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// setText replaces n's content with s.
+func (n *node) setText(s string) {
+	n.children = []any{xml.CharData(s)}
+}
+
+// prefix returns a prefix bound to ns in the scope of n, declaring one on n
+// when there is none. The declared prefix is want, or want with a number
+// appended when want is already bound to another namespace.
+func (n *node) prefix(ns, want string) string {
+	if ns == xmlNS {
+		return "xml"
+	}
+	for e := n; e != nil; e = e.parent {
+		for _, a := range e.attrs {
+			if a.Name.Space == "xmlns" && a.Value == ns && n.lookup(a.Name.Local) == ns {
+				return a.Name.Local
+			}
+		}
+	}
+	p := want
+	for i := 1; n.lookup(p) != ""; i++ {
+		p = fmt.Sprintf("%s%d", want, i)
+	}
+	n.attrs = append(n.attrs, xml.Attr{Name: xml.Name{Space: "xmlns", Local: p}, Value: ns})
+	return p
+}
+
+// newChild creates an element in namespace ns under n without attaching it.
+func (n *node) newChild(ns, prefix, local string) *node {
+	c := &node{parent: n}
+	c.name = xml.Name{Space: n.prefix(ns, prefix), Local: local}
+	return c
+}
+
+// insert adds c as n's last child element, on its own line with the
+// indentation of n's existing children.
+func (n *node) insert(c *node) {
+	c.parent = n
+	indent := xml.CharData("\n")
+	for i, e := range n.children {
+		if _, ok := e.(*node); ok {
+			if i > 0 {
+				if ws, ok := n.children[i-1].(xml.CharData); ok && len(bytes.TrimSpace(ws)) == 0 {
+					indent = append(xml.CharData(nil), ws...)
+				}
+			}
+			break
+		}
+	}
+	last := len(n.children) - 1
+	if last >= 0 {
+		if ws, ok := n.children[last].(xml.CharData); ok && len(bytes.TrimSpace(ws)) == 0 {
+			n.children = append(n.children[:last], indent, c, ws)
+			return
+		}
+	}
+	n.children = append(n.children, indent, c, xml.CharData("\n"))
+}
+
+// remove drops child element c from n, with the whitespace before it.
+func (n *node) remove(c *node) {
+	for i, e := range n.children {
+		if e != c {
+			continue
+		}
+		start := i
+		if i > 0 {
+			if ws, ok := n.children[i-1].(xml.CharData); ok && len(bytes.TrimSpace(ws)) == 0 {
+				start = i - 1
+			}
+		}
+		n.children = append(n.children[:start], n.children[i+1:]...)
+		return
+	}
+}
@@ -0,0 +1,282 @@
+package xmp
+
+import (
+	"encoding/xml"
+	"slices"
+	"strings"
+
+	"pdfmeta/internal/model"
+)
+
+// valueKind is the XMP value type of a managed property.
+type valueKind int
+
+const (
+	simpleValue valueKind = iota
+	langAltValue
+	seqValue
+	bagValue
+)
+
+// property maps a metadata field to the XMP property that stores it.
+type property struct {
+	ns, prefix, local string
+	kind              valueKind
+	// get returns the items m stores in the property: at most one for
+	// simple values and language alternatives.
+	get func(model.Metadata) []string
+}
+
+// The XMP properties pdfmeta manages. Keywords are stored twice: as the
+// dc:subject bag and, joined like the Info entry, as pdf:Keywords.
+var (
+	dcTitle        = property{dcNS, "dc", "title", langAltValue, func(m model.Metadata) []string { return item(m.Title) }}
+	dcCreator      = property{dcNS, "dc", "creator", seqValue, func(m model.Metadata) []string { return m.Authors }}
+	dcDescription  = property{dcNS, "dc", "description", langAltValue, func(m model.Metadata) []string { return item(m.Subject) }}
+	dcSubject      = property{dcNS, "dc", "subject", bagValue, func(m model.Metadata) []string { return m.Keywords }}
+	pdfKeywords    = property{pdfNS, "pdf", "Keywords", simpleValue, func(m model.Metadata) []string { return item(model.JoinKeywords(m.Keywords)) }}
+	xmpCreatorTool = property{xapNS, "xmp", "CreatorTool", simpleValue, func(m model.Metadata) []string { return item(m.Creator) }}
+	pdfProducer    = property{pdfNS, "pdf", "Producer", simpleValue, func(m model.Metadata) []string { return item(m.Producer) }}
+	xmpCreateDate  = property{xapNS, "xmp", "CreateDate", simpleValue, func(m model.Metadata) []string { return item(m.CreationDate) }}
+	xmpModifyDate  = property{xapNS, "xmp", "ModifyDate", simpleValue, func(m model.Metadata) []string { return item(m.ModDate) }}
+)
+
+// properties lists the managed properties. Update leaves every other
+// property in a packet alone.
+var properties = []property{dcTitle, dcCreator, dcDescription, dcSubject, pdfKeywords, xmpCreatorTool, pdfProducer, xmpCreateDate, xmpModifyDate}
+
+func item(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+func first(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return items[0]
+}
+
+// Update sets the managed properties of an existing packet to m and keeps
+// everything else: other properties and namespaces, extension schemas,
+// comments, and the xpacket wrapper with its padding. Properties whose value
+// is unchanged are not rewritten, and a packet with no changes is returned
+// as it is.
+func Update(packet []byte, m model.Metadata) ([]byte, error) {
+	tree, err := parsePacket(packet)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	for _, p := range properties {
+		items := p.get(m)
+		if slices.Equal(p.read(tree), items) && p.typed(tree) {
+			continue
+		}
+		p.set(tree, items)
+		changed = true
+	}
+	if !changed {
+		return packet, nil
+	}
+	return tree.bytes(), nil
+}
+
+// read returns the property's items from its first non-empty occurrence,
+// as an attribute of rdf:Description or as a property element.
+func (p property) read(t *packetTree) []string {
+	for _, d := range t.descriptions() {
+		if v, ok := d.attr(p.ns, p.local); ok && p.kind == simpleValue {
+			if v = strings.TrimSpace(v); v != "" {
+				return []string{v}
+			}
+		}
+		for _, e := range d.elements() {
+			if !e.is(p.ns, p.local) {
+				continue
+			}
+			if items := p.items(e); items != nil {
+				return items
+			}
+		}
+	}
+	return nil
+}
+
+// items reads a property element. A language alternative yields its
+// x-default item, or the first one; an array yields its non-empty items in
+// order, whichever array type the packet used.
+func (p property) items(e *node) []string {
+	switch p.kind {
+	case langAltValue:
+		alt := e.child(rdfNS, "Alt")
+		if alt == nil {
+			return item(e.text())
+		}
+		if li := defaultItem(alt); li != nil {
+			return item(li.text())
+		}
+		if li := alt.child(rdfNS, "li"); li != nil {
+			return item(li.text())
+		}
+		return nil
+	case seqValue, bagValue:
+		array := e.child(rdfNS, "Seq")
+		if array == nil {
+			array = e.child(rdfNS, "Bag")
+		}
+		if array == nil {
+			array = e.child(rdfNS, "Alt")
+		}
+		if array == nil {
+			return item(e.text())
+		}
+		var out []string
+		for _, li := range array.elements() {
+			if v := li.text(); li.is(rdfNS, "li") && v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	return item(e.text())
+}
+
+// set writes items into the first occurrence of the property and removes
+// the others; no items removes them all. A property that is not present yet
+// is added to the rdf:Description already using its namespace.
+func (p property) set(t *packetTree, items []string) {
+	kept := false
+	for _, d := range t.descriptions() {
+		if i := d.attrIndex(p.ns, p.local); i >= 0 {
+			if len(items) > 0 && !kept && p.kind == simpleValue {
+				d.attrs[i].Value = items[0]
+				kept = true
+			} else {
+				d.removeAttr(p.ns, p.local)
+			}
+		}
+		for _, e := range d.elements() {
+			if !e.is(p.ns, p.local) {
+				continue
+			}
+			if len(items) > 0 && !kept {
+				p.fill(e, items)
+				kept = true
+			} else {
+				d.remove(e)
+			}
+		}
+	}
+	if len(items) == 0 || kept {
+		return
+	}
+	d := p.home(t)
+	e := d.newChild(p.ns, p.prefix, p.local)
+	p.fill(e, items)
+	d.insert(e)
+}
+
+// fill replaces the value of property element e with items. A language
+// alternative keeps its other languages; an array of the wrong type, such
+// as a dc:creator Bag, is replaced.
+func (p property) fill(e *node, items []string) {
+	switch p.kind {
+	case langAltValue:
+		alt := e.child(rdfNS, "Alt")
+		if alt == nil {
+			alt = e.newChild(rdfNS, "rdf", "Alt")
+			e.children = []any{alt}
+		}
+		if li := defaultItem(alt); li != nil {
+			li.setText(items[0])
+			return
+		}
+		li := alt.newChild(rdfNS, "rdf", "li")
+		li.attrs = append(li.attrs, xml.Attr{Name: xml.Name{Space: "xml", Local: "lang"}, Value: "x-default"})
+		li.setText(items[0])
+		alt.children = append([]any{li}, alt.children...)
+	case seqValue, bagValue:
+		array := e.child(rdfNS, p.array())
+		if array == nil {
+			array = e.newChild(rdfNS, "rdf", p.array())
+			e.children = []any{array}
+		}
+		array.children = nil
+		for _, v := range items {
+			li := array.newChild(rdfNS, "rdf", "li")
+			li.setText(v)
+			array.children = append(array.children, li)
+		}
+	default:
+		e.setText(items[0])
+	}
+}
+
+// array returns the RDF container an array property is written as.
+func (p property) array() string {
+	if p.kind == bagValue {
+		return "Bag"
+	}
+	return "Seq"
+}
+
+// typed reports whether every element of an array property uses its
+// container, so a dc:creator written as a Bag is rewritten even when its
+// items are unchanged.
+func (p property) typed(t *packetTree) bool {
+	if p.kind != seqValue && p.kind != bagValue {
+		return true
+	}
+	for _, d := range t.descriptions() {
+		for _, e := range d.elements() {
+			if e.is(p.ns, p.local) && e.child(rdfNS, p.array()) == nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// home returns the rdf:Description a new occurrence of the property goes
+// into: the first one with a property in the same namespace, else the first
+// one. A packet without any gets an empty rdf:Description.
+func (p property) home(t *packetTree) *node {
+	descs := t.descriptions()
+	for _, d := range descs {
+		for _, a := range d.attrs {
+			if a.Name.Space != "" && a.Name.Space != "xmlns" && d.lookup(a.Name.Space) == p.ns {
+				return d
+			}
+		}
+		for _, e := range d.elements() {
+			if e.lookup(e.name.Space) == p.ns {
+				return d
+			}
+		}
+	}
+	if len(descs) > 0 {
+		return descs[0]
+	}
+	rdf := t.rdf()
+	if rdf == nil {
+		rdf = t.root.newChild(rdfNS, "rdf", "RDF")
+		t.root.insert(rdf)
+	}
+	d := rdf.newChild(rdfNS, "rdf", "Description")
+	d.attrs = append(d.attrs, xml.Attr{Name: xml.Name{Space: d.name.Space, Local: "about"}})
+	rdf.insert(d)
+	return d
+}
+
+// defaultItem returns the x-default item of a language alternative.
+func defaultItem(alt *node) *node {
+	for _, li := range alt.elements() {
+		if lang, ok := li.attr(xmlNS, "lang"); ok && li.is(rdfNS, "li") && lang == "x-default" {
+			return li
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package xmp
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"pdfmeta/internal/model"
+)
+
+// richPacket is a packet as other tools write it: several descriptions,
+// properties as attributes, unmanaged schemas, an extension schema, a
+// comment, a translated title and padding inside the xpacket wrapper.
+var richPacket = xpacketBegin + "\n" +
+	`<x:xmpmeta xmlns:x="adobe:ns:meta/" x:xmptk="Adobe XMP Core 5.1">` + "\n" +
+	` <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` + "\n" +
+	`  <rdf:Description rdf:about="" xmlns:xmpMM="http://ns.adobe.com/xap/1.0/mm/" xmpMM:DocumentID="uuid:doc" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" pdf:Producer="Distiller">` + "\n" +
+	`   <xmpMM:InstanceID>uuid:instance</xmpMM:InstanceID>` + "\n" +
+	`   <!-- kept -->` + "\n" +
+	`  </rdf:Description>` + "\n" +
+	`  <rdf:Description rdf:about="" xmlns:d="http://purl.org/dc/elements/1.1/" xmlns:photoshop="http://ns.adobe.com/photoshop/1.0/">` + "\n" +
+	`   <d:title><rdf:Alt><rdf:li xml:lang="x-default">Old</rdf:li><rdf:li xml:lang="de">Alt &amp; neu</rdf:li></rdf:Alt></d:title>` + "\n" +
+	`   <photoshop:AuthorsPosition>Editor</photoshop:AuthorsPosition>` + "\n" +
+	`   <d:description><rdf:Alt><rdf:li xml:lang="x-default">Gone</rdf:li></rdf:Alt></d:description>` + "\n" +
+	`  </rdf:Description>` + "\n" +
+	`  <rdf:Description rdf:about="" xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/" xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#">` + "\n" +
+	`   <pdfaExtension:schemas><rdf:Bag><rdf:li rdf:parseType="Resource"><pdfaSchema:prefix>prism</pdfaSchema:prefix></rdf:li></rdf:Bag></pdfaExtension:schemas>` + "\n" +
+	`  </rdf:Description>` + "\n" +
+	` </rdf:RDF>` + "\n" +
+	`</x:xmpmeta>` + "\n" +
+	strings.Repeat(strings.Repeat(" ", 99)+"\n", 3) +
+	xpacketEnd
+
+func TestUnmarshalRichPacket(t *testing.T) {
+	got, err := Unmarshal([]byte(richPacket))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := model.Metadata{Title: "Old", Subject: "Gone", Producer: "Distiller"}
+	if !got.Equal(want) {
+		t.Fatalf("got %#v want %#v", got, want)
+	}
+}
+
+func TestUpdateKeepsUnmanagedContent(t *testing.T) {
+	in := model.Metadata{Title: "New", Authors: []string{"Ann"}, Producer: "pdfmeta", ModDate: "2024-05-01T10:00:00Z"}
+	out, err := Update([]byte(richPacket), in)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := Unmarshal(out)
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !got.Equal(in) {
+		t.Fatalf("got %#v want %#v", got, in)
+	}
+	for _, keep := range []string{
+		`xmpMM:DocumentID="uuid:doc"`,
+		`<xmpMM:InstanceID>uuid:instance</xmpMM:InstanceID>`,
+		`<!-- kept -->`,
+		`<photoshop:AuthorsPosition>Editor</photoshop:AuthorsPosition>`,
+		`<rdf:li xml:lang="de">Alt &amp; neu</rdf:li>`,
+		`<rdf:li rdf:parseType="Resource"><pdfaSchema:prefix>prism</pdfaSchema:prefix></rdf:li>`,
+		`x:xmptk="Adobe XMP Core 5.1"`,
+		`pdf:Producer="pdfmeta"`,
+	} {
+		if !bytes.Contains(out, []byte(keep)) {
+			t.Fatalf("missing %s in\n%s", keep, out)
+		}
+	}
+	if !bytes.HasPrefix(out, []byte(xpacketBegin+"\n")) || !bytes.HasSuffix(out, []byte(strings.Repeat(" ", 99)+"\n"+xpacketEnd)) {
+		t.Fatalf("xpacket wrapper or padding lost:\n%s", out)
+	}
+	for _, gone := range []string{"Gone", "d:description", "Old"} {
+		if bytes.Contains(out, []byte(gone)) {
+			t.Fatalf("%s still present:\n%s", gone, out)
+		}
+	}
+	// New dc properties join the description that already uses dc, under
+	// its prefix; xmp needs a declaration.
+	if !bytes.Contains(out, []byte("<d:creator><rdf:Seq><rdf:li>Ann</rdf:li></rdf:Seq></d:creator>")) {
+		t.Fatalf("creator not added with the existing prefix:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte(`xmlns:xmp="http://ns.adobe.com/xap/1.0/"`)) || !bytes.Contains(out, []byte("<xmp:ModifyDate>2024-05-01T10:00:00Z</xmp:ModifyDate>")) {
+		t.Fatalf("xmp:ModifyDate not added:\n%s", out)
+	}
+}
+
+func TestUpdateUnchangedReturnsPacket(t *testing.T) {
+	out, err := Update([]byte(richPacket), model.Metadata{Title: "Old", Subject: "Gone", Producer: "Distiller"})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if string(out) != richPacket {
+		t.Fatalf("unchanged packet was rewritten:\n%s", out)
+	}
+}
+
+func TestUpdateMarshaledPacket(t *testing.T) {
+	packet, err := Marshal(model.Metadata{Title: "Doc", Keywords: []string{"a"}})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	in := model.Metadata{Title: "Doc", Authors: []string{"Bo", "Cy"}, Creator: "tool"}
+	out, err := Update(packet, in)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := Unmarshal(out); !got.Equal(in) {
+		t.Fatalf("got %#v want %#v", got, in)
+	}
+	if _, err := parsePacket(out); err != nil {
+		t.Fatalf("updated packet does not parse: %v", err)
+	}
+}
+
+func TestUpdateMalformedPacket(t *testing.T) {
+	if _, err := Update([]byte(`<x:xmpmeta><rdf:RDF>`), model.Metadata{Title: "x"}); err == nil {
+		t.Fatalf("expected xml decode error")
+	}
+}
+
+func TestUpdateListProperties(t *testing.T) {
+	packet := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+		`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/">` +
+		`<dc:creator><rdf:Bag><rdf:li>Ann</rdf:li><rdf:li>Bo</rdf:li></rdf:Bag></dc:creator><pdf:Keywords>x; y</pdf:Keywords>` +
+		`</rdf:Description></rdf:RDF></x:xmpmeta>`
+	got, err := Unmarshal([]byte(packet))
+	if err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	want := model.Metadata{Authors: []string{"Ann", "Bo"}, Keywords: []string{"x", "y"}}
+	if !got.Equal(want) {
+		t.Fatalf("got %#v want %#v", got, want)
+	}
+
+	out, err := Update([]byte(packet), want)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	for _, s := range []string{
+		"<dc:creator><rdf:Seq><rdf:li>Ann</rdf:li><rdf:li>Bo</rdf:li></rdf:Seq></dc:creator>",
+		"<dc:subject><rdf:Bag><rdf:li>x</rdf:li><rdf:li>y</rdf:li></rdf:Bag></dc:subject>",
+		"<pdf:Keywords>x, y</pdf:Keywords>",
+	} {
+		if !bytes.Contains(out, []byte(s)) {
+			t.Fatalf("missing %s in\n%s", s, out)
+		}
+	}
+
+	out, err = Update(out, model.Metadata{Authors: []string{"Bo"}, Keywords: []string{"z"}})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := Unmarshal(out); !got.Equal(model.Metadata{Authors: []string{"Bo"}, Keywords: []string{"z"}}) {
+		t.Fatalf("got %#v", got)
+	}
+	// dc:subject wins over a pdf:Keywords string another tool left stale.
+	stale := bytes.Replace(out, []byte("<pdf:Keywords>z</pdf:Keywords>"), []byte("<pdf:Keywords>old</pdf:Keywords>"), 1)
+	if bytes.Equal(stale, out) {
+		t.Fatalf("pdf:Keywords not synced:\n%s", out)
+	}
+	if got, _ := Unmarshal(stale); !slices.Equal(got.Keywords, []string{"z"}) {
+		t.Fatalf("Keywords=%q", got.Keywords)
+	}
+}